@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/taraslis453/solid-software-test/pkg/logging"
+	"github.com/taraslis453/solid-software-test/pkg/password"
+)
+
+const buildBreachFilterCommand = "build-breach-filter"
+
+// runBuildBreachFilter builds the breached passwords Bloom filter from the HIBP SHA-1 corpus.
+func runBuildBreachFilter(logger logging.Logger, args []string) {
+	flags := flag.NewFlagSet(buildBreachFilterCommand, flag.ExitOnError)
+	source := flags.String("source", "", "directory with HIBP range files or a single file with HASH:COUNT lines")
+	output := flags.String("output", "breached-passwords.bloom", "path of the Bloom filter file to create")
+	falsePositiveRate := flags.Float64("fp-rate", 0.001, "false positive rate of the Bloom filter")
+	_ = flags.Parse(args)
+
+	if *source == "" {
+		logger.Fatal("source is required")
+	}
+
+	count, err := password.BuildBloomFilter(&password.BuildBloomFilterOptions{
+		Source:            *source,
+		Output:            *output,
+		FalsePositiveRate: *falsePositiveRate,
+	})
+	if err != nil {
+		logger.Fatal("failed to build breach filter", "err", err)
+	}
+
+	logger.Info("built breach filter", "hashes", count, "output", *output)
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+
 	"github.com/ilyakaznacheev/cleanenv"
 
 	"github.com/taraslis453/solid-software-test/config"
@@ -12,6 +14,11 @@ import (
 func main() {
 	logger := logging.NewZapLogger("main")
 
+	if len(os.Args) > 1 && os.Args[1] == buildBreachFilterCommand {
+		runBuildBreachFilter(logger, os.Args[2:])
+		return
+	}
+
 	var cfg config.Config
 	err := cleanenv.ReadEnv(&cfg)
 	if err != nil {
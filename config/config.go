@@ -2,16 +2,47 @@ package config
 
 import "time"
 
+// Email verification enforcement modes. With "routes" unverified users can login, but can not access
+// routes requiring verified email. With "login" unverified users can not login at all.
+const (
+	EmailVerificationEnforcementNone   = "none"
+	EmailVerificationEnforcementRoutes = "routes"
+	EmailVerificationEnforcementLogin  = "login"
+)
+
 type (
 	Config struct {
+		App
 		HTTP
 		Log
 		Auth
+		PasswordPolicy
+		Lockout
+		Policy
+		Relation
+		Tenancy
+		MFA
+		WebAuthn
+		OTP
+		EmailVerification
+		Mailer
+		SMS
+		Scheduler
 		PostgreSQL
 	}
 
+	App struct {
+		PublicURL string `env:"APP_PUBLIC_URL" env-default:"http://localhost:8080"`
+		// FrontendURL is the base URL of the frontend. Emailed links to its pages submitting tokens to the API
+		// (password reset, email change confirmation and undo) point to it.
+		FrontendURL string `env:"APP_FRONTEND_URL" env-default:"http://localhost:3000"`
+	}
+
 	HTTP struct {
 		Port string `env:"HTTP_PORT" env-default:"8080"`
+		// TrustedProxies are addresses or CIDRs of proxies client IP is taken from X-Forwarded-For header for.
+		// If empty, the header is ignored.
+		TrustedProxies []string `env:"HTTP_TRUSTED_PROXIES"`
 	}
 
 	Log struct {
@@ -19,10 +50,100 @@ type (
 	}
 
 	Auth struct {
-		TokenIssuer          string        `env:"AUTH_TOKEN_ISSUER"                    env-default:"API"`
-		TokenSecretKey       string        `env:"AUTH_TOKEN_SECRET_KEY"                env-default:"2fg6wuCkkQ4HNjCo"`
-		AccessTokenLifetime  time.Duration `env:"AUTH_ACCESS_TOKEN_LIFETIME"           env-default:"1h"`
-		RefreshTokenLifetime time.Duration `env:"AUTH_REFRESH_TOKEN_LIFETIME"          env-default:"24h"`
+		TokenIssuer                string        `env:"AUTH_TOKEN_ISSUER"                   env-default:"API"`
+		TokenSecretKey             string        `env:"AUTH_TOKEN_SECRET_KEY"               env-default:"2fg6wuCkkQ4HNjCo"`
+		AccessTokenLifetime        time.Duration `env:"AUTH_ACCESS_TOKEN_LIFETIME"          env-default:"1h"`
+		RefreshTokenLifetime       time.Duration `env:"AUTH_REFRESH_TOKEN_LIFETIME"         env-default:"24h"`
+		PasswordResetTokenLifetime time.Duration `env:"AUTH_PASSWORD_RESET_TOKEN_LIFETIME"  env-default:"30m"`
+		MagicLinkTokenLifetime     time.Duration `env:"AUTH_MAGIC_LINK_TOKEN_LIFETIME"      env-default:"15m"`
+		MagicLinkResendCooldown    time.Duration `env:"AUTH_MAGIC_LINK_RESEND_COOLDOWN"     env-default:"1m"`
+		ReauthWindow               time.Duration `env:"AUTH_REAUTH_WINDOW"                  env-default:"10m"`
+		EmailChangeTokenLifetime   time.Duration `env:"AUTH_EMAIL_CHANGE_TOKEN_LIFETIME"    env-default:"1h"`
+		EmailChangeUndoLifetime    time.Duration `env:"AUTH_EMAIL_CHANGE_UNDO_LIFETIME"     env-default:"72h"`
+		AntiEnumeration            bool          `env:"AUTH_ANTI_ENUMERATION"               env-default:"false"`
+		RegistrationNoticeCooldown time.Duration `env:"AUTH_REGISTRATION_NOTICE_COOLDOWN"   env-default:"1h"`
+		GroupsClaim                bool          `env:"AUTH_GROUPS_CLAIM"                   env-default:"false"`
+		DeletionGracePeriod        time.Duration `env:"AUTH_DELETION_GRACE_PERIOD"          env-default:"720h"`
+	}
+
+	PasswordPolicy struct {
+		MinLength        int    `env:"PASSWORD_MIN_LENGTH"          env-default:"8"`
+		MaxLength        int    `env:"PASSWORD_MAX_LENGTH"          env-default:"72"`
+		RequireUpper     bool   `env:"PASSWORD_REQUIRE_UPPER"       env-default:"false"`
+		RequireLower     bool   `env:"PASSWORD_REQUIRE_LOWER"       env-default:"false"`
+		RequireDigit     bool   `env:"PASSWORD_REQUIRE_DIGIT"       env-default:"false"`
+		RequireSymbol    bool   `env:"PASSWORD_REQUIRE_SYMBOL"      env-default:"false"`
+		MinStrength      int    `env:"PASSWORD_MIN_STRENGTH"        env-default:"2"`
+		BreachFilterPath string `env:"PASSWORD_BREACH_FILTER_PATH"`
+		BreachRangesDir  string `env:"PASSWORD_BREACH_RANGES_DIR"`
+		HistorySize      int    `env:"PASSWORD_HISTORY_SIZE"        env-default:"5"`
+	}
+
+	Lockout struct {
+		UserMaxAttempts int           `env:"LOCKOUT_USER_MAX_ATTEMPTS"  env-default:"5"`
+		IPMaxAttempts   int           `env:"LOCKOUT_IP_MAX_ATTEMPTS"    env-default:"20"`
+		Duration        time.Duration `env:"LOCKOUT_DURATION"           env-default:"15m"`
+		BaseDelay       time.Duration `env:"LOCKOUT_BASE_DELAY"         env-default:"1s"`
+		MaxDelay        time.Duration `env:"LOCKOUT_MAX_DELAY"          env-default:"30s"`
+		AttemptsWindow  time.Duration `env:"LOCKOUT_ATTEMPTS_WINDOW"    env-default:"1h"`
+	}
+
+	Policy struct {
+		File string `env:"POLICY_FILE"`
+	}
+
+	Relation struct {
+		NamespaceFile string `env:"RELATION_NAMESPACE_FILE"`
+	}
+
+	Tenancy struct {
+		BaseDomain         string        `env:"TENANCY_BASE_DOMAIN"`
+		InvitationLifetime time.Duration `env:"TENANCY_INVITATION_LIFETIME"  env-default:"72h"`
+	}
+
+	MFA struct {
+		Issuer                        string        `env:"MFA_ISSUER"                            env-default:"API"`
+		EncryptionKey                 string        `env:"MFA_ENCRYPTION_KEY"                    env-default:"Xk2Jv8QpL0sT4wZm"`
+		ChallengeTokenLifetime        time.Duration `env:"MFA_CHALLENGE_TOKEN_LIFETIME"          env-default:"5m"`
+		ChallengeMaxAttempts          int           `env:"MFA_CHALLENGE_MAX_ATTEMPTS"            env-default:"3"`
+		RecoveryCodesWarningThreshold int           `env:"MFA_RECOVERY_CODES_WARNING_THRESHOLD"  env-default:"3"`
+	}
+
+	WebAuthn struct {
+		RPID                    string        `env:"WEBAUTHN_RP_ID"                      env-default:"localhost"`
+		RPName                  string        `env:"WEBAUTHN_RP_NAME"                    env-default:"API"`
+		Origins                 []string      `env:"WEBAUTHN_ORIGINS"                    env-default:"http://localhost:8080"`
+		CeremonyTimeout         time.Duration `env:"WEBAUTHN_CEREMONY_TIMEOUT"           env-default:"5m"`
+		RequireUserVerification bool          `env:"WEBAUTHN_REQUIRE_USER_VERIFICATION"  env-default:"false"`
+	}
+
+	OTP struct {
+		CodeLength     int           `env:"OTP_CODE_LENGTH"      env-default:"6"`
+		CodeLifetime   time.Duration `env:"OTP_CODE_LIFETIME"    env-default:"5m"`
+		MaxAttempts    int           `env:"OTP_MAX_ATTEMPTS"     env-default:"5"`
+		ResendCooldown time.Duration `env:"OTP_RESEND_COOLDOWN"  env-default:"1m"`
+	}
+
+	EmailVerification struct {
+		TokenLifetime  time.Duration `env:"EMAIL_VERIFICATION_TOKEN_LIFETIME"   env-default:"24h"`
+		ResendCooldown time.Duration `env:"EMAIL_VERIFICATION_RESEND_COOLDOWN"  env-default:"1m"`
+		Enforcement    string        `env:"EMAIL_VERIFICATION_ENFORCEMENT"      env-default:"none"`
+	}
+
+	Mailer struct {
+		Driver  string `env:"MAILER_DRIVER"    env-default:"log"`
+		FileDir string `env:"MAILER_FILE_DIR"  env-default:"./mail"`
+	}
+
+	SMS struct {
+		Driver   string `env:"SMS_DRIVER"     env-default:"log"`
+		FilePath string `env:"SMS_FILE_PATH"  env-default:"./sms.log"`
+	}
+
+	Scheduler struct {
+		Enabled                   bool          `env:"SCHEDULER_ENABLED"                      env-default:"true"`
+		PurgeDeletedUsersInterval time.Duration `env:"SCHEDULER_PURGE_DELETED_USERS_INTERVAL" env-default:"1h"`
+		PurgeAuthDataInterval     time.Duration `env:"SCHEDULER_PURGE_AUTH_DATA_INTERVAL"     env-default:"1h"`
 	}
 
 	PostgreSQL struct {
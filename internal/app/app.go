@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -10,10 +11,17 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/taraslis453/solid-software-test/config"
+	"github.com/taraslis453/solid-software-test/pkg/encryption"
 	"github.com/taraslis453/solid-software-test/pkg/httpserver"
 	"github.com/taraslis453/solid-software-test/pkg/logging"
+	"github.com/taraslis453/solid-software-test/pkg/mailer"
 	"github.com/taraslis453/solid-software-test/pkg/password"
+	"github.com/taraslis453/solid-software-test/pkg/policy"
 	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+	"github.com/taraslis453/solid-software-test/pkg/rebac"
+	"github.com/taraslis453/solid-software-test/pkg/scheduler"
+	"github.com/taraslis453/solid-software-test/pkg/sms"
+	"github.com/taraslis453/solid-software-test/pkg/webauthn"
 
 	httpController "github.com/taraslis453/solid-software-test/internal/controller/http"
 	"github.com/taraslis453/solid-software-test/internal/entity"
@@ -36,30 +44,211 @@ func Run(cfg *config.Config) {
 	}
 
 	err = postgresql.DB.AutoMigrate(
+		&entity.Organization{},
 		&entity.User{},
+		&entity.PasswordHistory{},
+		&entity.Session{},
+		&entity.PasswordResetToken{},
+		&entity.MFARecoveryCode{},
+		&entity.WebAuthnCredential{},
+		&entity.UsedToken{},
+		&entity.OneTimeCode{},
+		&entity.EmailChangeRequest{},
+		&entity.LoginThrottle{},
+		&entity.Permission{},
+		&entity.Role{},
+		&entity.UserRole{},
+		&entity.Membership{},
+		&entity.Group{},
+		&entity.GroupMember{},
+		&entity.GroupSubgroup{},
+		&entity.GroupRole{},
+		&entity.RelationTuple{},
+		&entity.RelationTupleRevision{},
 	)
 	if err != nil {
 		log.Fatal(fmt.Errorf("automigration failed: %w", err))
 	}
 
+	err = storage.CreateUserSearchIndex(postgresql)
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to create user search index: %w", err))
+	}
+	err = storage.CreateUserEmailIndex(postgresql)
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to create user email index: %w", err))
+	}
+	err = storage.CreateUserPhoneIndex(postgresql)
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to create user phone index: %w", err))
+	}
+
 	storages := service.Storages{
-		User: storage.NewUserStorage(postgresql),
+		User:               storage.NewUserStorage(postgresql),
+		PasswordHistory:    storage.NewPasswordHistoryStorage(postgresql),
+		Session:            storage.NewSessionStorage(postgresql),
+		PasswordReset:      storage.NewPasswordResetTokenStorage(postgresql),
+		MFARecoveryCode:    storage.NewMFARecoveryCodeStorage(postgresql),
+		WebAuthnCredential: storage.NewWebAuthnCredentialStorage(postgresql),
+		UsedToken:          storage.NewUsedTokenStorage(postgresql),
+		OneTimeCode:        storage.NewOneTimeCodeStorage(postgresql),
+		EmailChangeRequest: storage.NewEmailChangeRequestStorage(postgresql),
+		LoginThrottle:      storage.NewLoginThrottleStorage(postgresql),
+		Role:               storage.NewRoleStorage(postgresql),
+		Organization:       storage.NewOrganizationStorage(postgresql),
+		Membership:         storage.NewMembershipStorage(postgresql),
+		Group:              storage.NewGroupStorage(postgresql),
+		RelationTuple:      storage.NewRelationTupleStorage(postgresql),
 	}
 
 	passwordHasher := password.NewBcrypt(logger)
+	passwordValidator := password.NewPolicyValidator(password.Policy{
+		MinLength:     cfg.PasswordPolicy.MinLength,
+		MaxLength:     cfg.PasswordPolicy.MaxLength,
+		RequireUpper:  cfg.PasswordPolicy.RequireUpper,
+		RequireLower:  cfg.PasswordPolicy.RequireLower,
+		RequireDigit:  cfg.PasswordPolicy.RequireDigit,
+		RequireSymbol: cfg.PasswordPolicy.RequireSymbol,
+		MinStrength:   cfg.PasswordPolicy.MinStrength,
+	})
+
+	var passwordBreachChecker password.BreachChecker
+	switch {
+	case cfg.PasswordPolicy.BreachFilterPath != "":
+		passwordBreachChecker, err = password.LoadBloomBreachChecker(cfg.PasswordPolicy.BreachFilterPath)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to load password breach filter: %w", err))
+		}
+	case cfg.PasswordPolicy.BreachRangesDir != "":
+		passwordBreachChecker, err = password.NewRangeFilesBreachChecker(cfg.PasswordPolicy.BreachRangesDir)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to init password breach checker: %w", err))
+		}
+	}
+
+	var mail mailer.Mailer
+	switch cfg.Mailer.Driver {
+	case "log":
+		mail = mailer.NewLogMailer(logger)
+	case "file":
+		mail, err = mailer.NewFileMailer(cfg.Mailer.FileDir)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to init file mailer: %w", err))
+		}
+	default:
+		log.Fatal(fmt.Errorf("unknown mailer driver: %s", cfg.Mailer.Driver))
+	}
+
+	var smsSender sms.Sender
+	switch cfg.SMS.Driver {
+	case "log":
+		smsSender = sms.NewLogSender(logger)
+	case "file":
+		smsSender = sms.NewFileSender(cfg.SMS.FilePath)
+	default:
+		log.Fatal(fmt.Errorf("unknown sms driver: %s", cfg.SMS.Driver))
+	}
+
+	switch cfg.EmailVerification.Enforcement {
+	case config.EmailVerificationEnforcementNone, config.EmailVerificationEnforcementRoutes, config.EmailVerificationEnforcementLogin:
+	default:
+		log.Fatal(fmt.Errorf("unknown email verification enforcement: %s", cfg.EmailVerification.Enforcement))
+	}
+
+	secretEncryptor, err := encryption.NewAESGCM(cfg.MFA.EncryptionKey)
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to init secret encryptor: %w", err))
+	}
+
+	relyingParty, err := webauthn.New(webauthn.Config{
+		RPID:                    cfg.WebAuthn.RPID,
+		RPName:                  cfg.WebAuthn.RPName,
+		Origins:                 cfg.WebAuthn.Origins,
+		Timeout:                 cfg.WebAuthn.CeremonyTimeout,
+		RequireUserVerification: cfg.WebAuthn.RequireUserVerification,
+	})
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to init webauthn relying party: %w", err))
+	}
+
+	policyEngine, err := policy.New(nil)
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to init policy engine: %w", err))
+	}
+	if cfg.Policy.File != "" {
+		policyEngine, err = policy.LoadFile(cfg.Policy.File)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to load policies: %w", err))
+		}
+	}
+
+	relationEngine, err := rebac.New(rebac.Config{})
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to init relation engine: %w", err))
+	}
+	if cfg.Relation.NamespaceFile != "" {
+		relationEngine, err = rebac.LoadFile(cfg.Relation.NamespaceFile)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to load relation namespaces: %w", err))
+		}
+	}
 
 	serviceOptions := service.Options{
-		Storages:       storages,
-		Config:         cfg,
-		Logger:         logger,
-		PasswordHasher: passwordHasher,
+		Storages:              storages,
+		Config:                cfg,
+		Logger:                logger,
+		PasswordHasher:        passwordHasher,
+		PasswordValidator:     passwordValidator,
+		PasswordBreachChecker: passwordBreachChecker,
+		Mailer:                mail,
+		SMSSender:             smsSender,
+		SecretEncryptor:       secretEncryptor,
+		WebAuthn:              relyingParty,
+		PolicyEngine:          policyEngine,
+		RelationEngine:        relationEngine,
 	}
 
 	services := service.Services{
-		User: service.NewUserService(serviceOptions),
+		User:         service.NewUserService(serviceOptions),
+		Role:         service.NewRoleService(serviceOptions),
+		Policy:       service.NewPolicyService(serviceOptions),
+		Organization: service.NewOrganizationService(serviceOptions),
+		Group:        service.NewGroupService(serviceOptions),
+		Relation:     service.NewRelationService(serviceOptions),
+	}
+
+	err = services.Organization.SeedDefaultOrganization(context.Background())
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to seed default organization: %w", err))
+	}
+
+	err = services.Role.SeedDefaultRoles(context.Background())
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to seed default roles: %w", err))
+	}
+
+	jobScheduler := scheduler.New(postgresql, logger,
+		scheduler.Job{
+			Name:     "purge-deleted-users",
+			Interval: cfg.Scheduler.PurgeDeletedUsersInterval,
+			Run:      services.User.PurgeDeletedUsers,
+		},
+		scheduler.Job{
+			Name:     "purge-expired-auth-data",
+			Interval: cfg.Scheduler.PurgeAuthDataInterval,
+			Run:      services.User.PurgeExpiredAuthData,
+		},
+	)
+	if cfg.Scheduler.Enabled {
+		jobScheduler.Start()
 	}
 
 	httpHandler := gin.New()
+	// Client IP is used to throttle logins, so forwarded headers are trusted only from configured proxies
+	err = httpHandler.SetTrustedProxies(cfg.HTTP.TrustedProxies)
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to set trusted proxies: %w", err))
+	}
 
 	httpController.New(httpController.Options{
 		Handler:  httpHandler,
@@ -91,4 +280,7 @@ func Run(cfg *config.Config) {
 	if err != nil {
 		logger.Error("app - Run - httpServer.Shutdown", "err", err)
 	}
+
+	// Running jobs are cancelled and awaited, so they do not use storages after shutdown
+	jobScheduler.Stop()
 }
@@ -0,0 +1,89 @@
+package httpcontroller
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+	"github.com/taraslis453/solid-software-test/pkg/errs"
+)
+
+type adminRoutes struct {
+	routerContext
+}
+
+func newAdminRoutes(options RouterOptions) {
+	r := &adminRoutes{
+		routerContext{
+			services: options.Services,
+			logger:   options.Logger.Named("adminRoutes"),
+			cfg:      options.Config,
+		},
+	}
+
+	p := options.Handler.Group("/admin", newAuthMiddleware(options))
+	{
+		p.GET("/users", newRequirePermissionMiddleware(options, entity.PermissionUsersRead), errorHandler(options, r.listUsers))
+	}
+}
+
+type listUsersRequestQuery struct {
+	Search      string `form:"search"`
+	EmailDomain string `form:"emailDomain"`
+	Status      string `form:"status" binding:"omitempty,oneof=active unverified locked"`
+	// CreatedAfter and CreatedBefore are RFC 3339 times, e.g. 2024-01-02T15:04:05Z.
+	CreatedAfter  *time.Time `form:"createdAfter"`
+	CreatedBefore *time.Time `form:"createdBefore"`
+	SortBy        string     `form:"sortBy" binding:"omitempty,oneof=createdAt emailAddress name"`
+	Order         string     `form:"order" binding:"omitempty,oneof=asc desc"`
+	Cursor        string     `form:"cursor"`
+	Limit         int        `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type listUsersResponse struct {
+	Users []entity.User `json:"users"`
+	// NextCursor is passed as cursor to get the next page. It is empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+func (r *adminRoutes) listUsers(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("listUsers").WithContext(c)
+
+	var query listUsersRequestQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		logger.Info("failed to parse query", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request query", Details: err}
+	}
+	logger = logger.With("query", query)
+	logger.Debug("parsed query")
+
+	output, err := r.services.User.ListUsers(c, service.ListUsersOptions{
+		Search:        query.Search,
+		EmailDomain:   query.EmailDomain,
+		Status:        entity.UserStatus(query.Status),
+		CreatedAfter:  query.CreatedAfter,
+		CreatedBefore: query.CreatedBefore,
+		SortBy:        service.UserSortField(query.SortBy),
+		IsDescending:  query.Order == "desc",
+		Cursor:        query.Cursor,
+		Limit:         query.Limit,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to list users", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to list users", Details: err}
+	}
+
+	logger.Info("successfully listed users")
+	return listUsersResponse{
+		Users:      output.Users,
+		NextCursor: output.NextCursor,
+	}, nil
+}
@@ -3,9 +3,11 @@ package httpcontroller
 import (
 	"bytes"
 	"fmt"
+	"net"
 	"net/http"
 	"runtime/debug"
 	"strings"
+	"time"
 
 	// third party
 	"github.com/DataDog/gostackparse"
@@ -53,8 +55,15 @@ func New(options Options) {
 	}
 
 	options.Handler.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	routerOptions.Handler.Use(newTenantMiddleware(routerOptions))
 	{
 		newUserRoutes(routerOptions)
+		newRoleRoutes(routerOptions)
+		newPolicyRoutes(routerOptions)
+		newOrganizationRoutes(routerOptions)
+		newGroupRoutes(routerOptions)
+		newRelationRoutes(routerOptions)
+		newAdminRoutes(routerOptions)
 	}
 }
 
@@ -120,7 +129,7 @@ func errorHandler(options RouterOptions, handler func(c *gin.Context) (interface
 				c.AbortWithStatusJSON(http.StatusInternalServerError, err)
 			} else {
 				logger.Info("client error")
-				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, err)
+				c.AbortWithStatusJSON(getClientErrStatus(err), err)
 			}
 			return
 		}
@@ -130,6 +139,20 @@ func errorHandler(options RouterOptions, handler func(c *gin.Context) (interface
 	}
 }
 
+// clientErrStatuses maps codes of client errors to HTTP statuses other than default 422.
+var clientErrStatuses = map[string]int{
+	forbiddenErrCode: http.StatusForbidden,
+}
+
+// getClientErrStatus returns HTTP status of the client error.
+func getClientErrStatus(err *httpErr) int {
+	status, ok := clientErrStatuses[err.Code]
+	if !ok {
+		return http.StatusUnprocessableEntity
+	}
+	return status
+}
+
 // corsMiddleware - used to allow incoming cross-origin requests.
 func corsMiddleware(c *gin.Context) {
 	c.Header("Access-Control-Allow-Origin", "*")
@@ -156,7 +179,7 @@ func newAuthMiddleware(options RouterOptions) gin.HandlerFunc {
 			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error()}
 		}
 
-		user, err := options.Services.User.VerifyUserToken(c, token)
+		verified, err := options.Services.User.VerifyUserToken(c, token)
 		if err != nil {
 			if errs.IsExpected(err) {
 				logger.Info(err.Error())
@@ -170,16 +193,170 @@ func newAuthMiddleware(options RouterOptions) gin.HandlerFunc {
 				Details: err,
 			}
 		}
-		logger = logger.With("user", user)
+		logger = logger.With("user", verified.User)
 		logger.Debug("verified token")
 
-		c.Set("userID", user.ID)
+		c.Set("userID", verified.User.ID)
+		c.Set(service.TenantContextKey, verified.TenantID)
+		c.Set("sessionID", verified.SessionID)
+		c.Set("authTime", verified.AuthTime)
+		c.Set("authMethods", verified.AuthMethods)
+		c.Set("emailVerified", verified.User.IsEmailVerified())
+		c.Set("isAdmin", verified.User.IsAdmin)
+		c.Set("permissions", verified.Permissions)
+		c.Set("groups", verified.Groups)
+		c.Set(service.PrincipalContextKey, &service.Principal{
+			UserID:       verified.User.ID,
+			IsAdmin:      verified.User.IsAdmin,
+			Permissions:  verified.Permissions,
+			Groups:       verified.Groups,
+			EmailAddress: verified.User.EmailAddress,
+			AuthMethods:  verified.AuthMethods,
+			AuthTime:     verified.AuthTime,
+		})
 
 		logger.Info("successfully validated auth token")
 		return nil, nil
 	})
 }
 
+// newOptionalAuthMiddleware is used to authenticate the request only if it has auth token.
+func newOptionalAuthMiddleware(options RouterOptions) gin.HandlerFunc {
+	authMiddleware := newAuthMiddleware(options)
+
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			return
+		}
+		authMiddleware(c)
+	}
+}
+
+// reauthRequiredErrCode is returned when the operation requires user to reauthenticate.
+const reauthRequiredErrCode = "reauth_required"
+
+// newReauthMiddleware is used to allow the request only if user authenticated recently enough.
+// It has to be placed after auth middleware.
+func newReauthMiddleware(options RouterOptions) gin.HandlerFunc {
+	logger := options.Logger.Named("reauthMiddleware")
+
+	return errorHandler(options, func(c *gin.Context) (interface{}, *httpErr) {
+		authTime := c.GetTime("authTime")
+		if time.Since(authTime) > options.Config.Auth.ReauthWindow {
+			logger.Info("reauthentication required", "userID", c.GetString("userID"), "authTime", authTime)
+			return nil, &httpErr{
+				Type:    httpErrTypeClient,
+				Code:    reauthRequiredErrCode,
+				Message: "authentication is too old, reauthenticate to continue",
+			}
+		}
+
+		return nil, nil
+	})
+}
+
+// emailNotVerifiedErrCode is returned when the operation requires verified user email.
+const emailNotVerifiedErrCode = "email_not_verified"
+
+// newEmailVerifiedMiddleware is used to allow the request only if user has verified the email,
+// unless email verification is not enforced. It has to be placed after auth middleware.
+func newEmailVerifiedMiddleware(options RouterOptions) gin.HandlerFunc {
+	logger := options.Logger.Named("emailVerifiedMiddleware")
+
+	return errorHandler(options, func(c *gin.Context) (interface{}, *httpErr) {
+		if options.Config.EmailVerification.Enforcement == config.EmailVerificationEnforcementNone {
+			return nil, nil
+		}
+
+		if !c.GetBool("emailVerified") {
+			logger.Info("email is not verified", "userID", c.GetString("userID"))
+			return nil, &httpErr{
+				Type:    httpErrTypeClient,
+				Code:    emailNotVerifiedErrCode,
+				Message: "email is not verified",
+			}
+		}
+
+		return nil, nil
+	})
+}
+
+// forbiddenErrCode is returned when the user is not allowed to perform the operation.
+const forbiddenErrCode = "forbidden"
+
+// newRequirePermissionMiddleware is used to allow the request only if user is an admin or has the permission
+// granted by a role. It has to be placed after auth middleware.
+func newRequirePermissionMiddleware(options RouterOptions, permission string) gin.HandlerFunc {
+	logger := options.Logger.Named("requirePermissionMiddleware").With("permission", permission)
+
+	return errorHandler(options, func(c *gin.Context) (interface{}, *httpErr) {
+		principal := service.PrincipalFromContext(c)
+		if principal == nil || !principal.HasPermission(permission) {
+			logger.Info("user has no permission", "userID", c.GetString("userID"))
+			return nil, &httpErr{
+				Type:    httpErrTypeClient,
+				Code:    forbiddenErrCode,
+				Message: fmt.Sprintf("operation requires %s permission", permission),
+			}
+		}
+
+		return nil, nil
+	})
+}
+
+// tenantHeader is the request header with the ID of the organization request is scoped to.
+const tenantHeader = "X-Tenant-ID"
+
+// newTenantMiddleware is used to scope the request to the organization from X-Tenant-ID header or from
+// the subdomain of the tenancy base domain. Requests without either are scoped to the organization of
+// the auth token, or to the default organization if not authenticated.
+func newTenantMiddleware(options RouterOptions) gin.HandlerFunc {
+	logger := options.Logger.Named("tenantMiddleware")
+
+	return errorHandler(options, func(c *gin.Context) (interface{}, *httpErr) {
+		var opts service.GetOrganizationOptions
+		if tenantID := c.GetHeader(tenantHeader); tenantID != "" {
+			opts.ID = tenantID
+		} else if slug := getSubdomain(c.Request.Host, options.Config.Tenancy.BaseDomain); slug != "" {
+			opts.Slug = slug
+		} else {
+			return nil, nil
+		}
+
+		organization, err := options.Services.Organization.GetOrganization(c, opts)
+		if err != nil {
+			if errs.IsExpected(err) {
+				logger.Info(err.Error())
+				return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+			}
+
+			logger.Error("failed to get organization", "err", err)
+			return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to get organization", Details: err}
+		}
+
+		c.Set(service.TenantContextKey, organization.ID)
+		return nil, nil
+	})
+}
+
+// getSubdomain returns the subdomain of base domain the host belongs to, or empty string if it does not
+// belong to it or base domain is not set.
+func getSubdomain(host, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	suffix := "." + strings.ToLower(baseDomain)
+	host = strings.ToLower(host)
+	if !strings.HasSuffix(host, suffix) {
+		return ""
+	}
+	return strings.TrimSuffix(host, suffix)
+}
+
 func getAuthToken(rawToken string) (string, error) {
 	if rawToken == "" {
 		return "", fmt.Errorf("empty auth token")
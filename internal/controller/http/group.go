@@ -0,0 +1,456 @@
+package httpcontroller
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+	"github.com/taraslis453/solid-software-test/pkg/errs"
+)
+
+type groupRoutes struct {
+	routerContext
+}
+
+func newGroupRoutes(options RouterOptions) {
+	r := &groupRoutes{
+		routerContext{
+			services: options.Services,
+			logger:   options.Logger.Named("groupRoutes"),
+			cfg:      options.Config,
+		},
+	}
+
+	p := options.Handler.Group("/groups", newAuthMiddleware(options))
+	{
+		groupsManage := newRequirePermissionMiddleware(options, entity.PermissionGroupsManage)
+		p.GET("", groupsManage, errorHandler(options, r.listGroups))
+		p.POST("", groupsManage, errorHandler(options, r.createGroup))
+		p.GET("/:id", groupsManage, errorHandler(options, r.getGroup))
+		p.DELETE("/:id", groupsManage, errorHandler(options, r.deleteGroup))
+		p.POST("/:id/members", groupsManage, errorHandler(options, r.addGroupMember))
+		p.DELETE("/:id/members/:userId", groupsManage, errorHandler(options, r.removeGroupMember))
+		p.POST("/:id/subgroups", groupsManage, errorHandler(options, r.addGroupSubgroup))
+		p.DELETE("/:id/subgroups/:subgroupId", groupsManage, errorHandler(options, r.removeGroupSubgroup))
+
+		rolesManage := newRequirePermissionMiddleware(options, entity.PermissionRolesManage)
+		p.POST("/:id/roles", rolesManage, errorHandler(options, r.assignGroupRole))
+		p.DELETE("/:id/roles/:roleId", rolesManage, errorHandler(options, r.unassignGroupRole))
+	}
+
+	u := options.Handler.Group("/users", newAuthMiddleware(options))
+	{
+		u.GET("/:id/groups", errorHandler(options, r.listUserGroups))
+	}
+}
+
+type listGroupsResponse struct {
+	Groups []entity.Group `json:"groups"`
+}
+
+func (r *groupRoutes) listGroups(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("listGroups").WithContext(c)
+
+	groups, err := r.services.Group.ListGroups(c)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to list groups", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to list groups", Details: err}
+	}
+
+	logger.Info("successfully listed groups")
+	return listGroupsResponse{
+		Groups: groups,
+	}, nil
+}
+
+type createGroupRequestBody struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type createGroupResponse struct {
+	Group *entity.Group `json:"group"`
+}
+
+func (r *groupRoutes) createGroup(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("createGroup").WithContext(c)
+
+	var body createGroupRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	group, err := r.services.Group.CreateGroup(c, service.CreateGroupOptions{
+		Name:        body.Name,
+		Description: body.Description,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to create group", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to create group", Details: err}
+	}
+
+	logger.Info("successfully created group")
+	return createGroupResponse{
+		Group: group,
+	}, nil
+}
+
+type groupPathParams struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+type getGroupResponse struct {
+	Group *entity.Group `json:"group"`
+}
+
+func (r *groupRoutes) getGroup(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("getGroup").WithContext(c)
+
+	var pathParams groupPathParams
+	err := c.ShouldBindUri(&pathParams)
+	if err != nil {
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
+	}
+	logger = logger.With("pathParams", pathParams)
+	logger.Debug("parsed path params")
+
+	group, err := r.services.Group.GetGroup(c, pathParams.ID)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to get group", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to get group", Details: err}
+	}
+
+	logger.Info("successfully got group")
+	return getGroupResponse{
+		Group: group,
+	}, nil
+}
+
+type deleteGroupResponse struct {
+}
+
+func (r *groupRoutes) deleteGroup(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("deleteGroup").WithContext(c)
+
+	var pathParams groupPathParams
+	err := c.ShouldBindUri(&pathParams)
+	if err != nil {
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
+	}
+	logger = logger.With("pathParams", pathParams)
+	logger.Debug("parsed path params")
+
+	err = r.services.Group.DeleteGroup(c, pathParams.ID)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to delete group", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to delete group", Details: err}
+	}
+
+	logger.Info("successfully deleted group")
+	return deleteGroupResponse{}, nil
+}
+
+type addGroupMemberRequestBody struct {
+	UserID string `json:"userId" binding:"required"`
+}
+
+type addGroupMemberResponse struct {
+}
+
+func (r *groupRoutes) addGroupMember(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("addGroupMember").WithContext(c)
+
+	var pathParams groupPathParams
+	err := c.ShouldBindUri(&pathParams)
+	if err != nil {
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
+	}
+
+	var body addGroupMemberRequestBody
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("pathParams", pathParams, "body", body)
+	logger.Debug("parsed request")
+
+	err = r.services.Group.AddGroupMember(c, service.AddGroupMemberOptions{
+		GroupID: pathParams.ID,
+		UserID:  body.UserID,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to add group member", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to add group member", Details: err}
+	}
+
+	logger.Info("successfully added group member")
+	return addGroupMemberResponse{}, nil
+}
+
+type removeGroupMemberPathParams struct {
+	ID     string `uri:"id" binding:"required"`
+	UserID string `uri:"userId" binding:"required"`
+}
+
+type removeGroupMemberResponse struct {
+}
+
+func (r *groupRoutes) removeGroupMember(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("removeGroupMember").WithContext(c)
+
+	var pathParams removeGroupMemberPathParams
+	err := c.ShouldBindUri(&pathParams)
+	if err != nil {
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
+	}
+	logger = logger.With("pathParams", pathParams)
+	logger.Debug("parsed path params")
+
+	err = r.services.Group.RemoveGroupMember(c, service.RemoveGroupMemberOptions{
+		GroupID: pathParams.ID,
+		UserID:  pathParams.UserID,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to remove group member", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to remove group member", Details: err}
+	}
+
+	logger.Info("successfully removed group member")
+	return removeGroupMemberResponse{}, nil
+}
+
+type addGroupSubgroupRequestBody struct {
+	GroupID string `json:"groupId" binding:"required"`
+}
+
+type addGroupSubgroupResponse struct {
+}
+
+func (r *groupRoutes) addGroupSubgroup(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("addGroupSubgroup").WithContext(c)
+
+	var pathParams groupPathParams
+	err := c.ShouldBindUri(&pathParams)
+	if err != nil {
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
+	}
+
+	var body addGroupSubgroupRequestBody
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("pathParams", pathParams, "body", body)
+	logger.Debug("parsed request")
+
+	err = r.services.Group.AddGroupSubgroup(c, service.AddGroupSubgroupOptions{
+		GroupID:    pathParams.ID,
+		SubgroupID: body.GroupID,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to add group subgroup", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to add group subgroup", Details: err}
+	}
+
+	logger.Info("successfully added group subgroup")
+	return addGroupSubgroupResponse{}, nil
+}
+
+type removeGroupSubgroupPathParams struct {
+	ID         string `uri:"id" binding:"required"`
+	SubgroupID string `uri:"subgroupId" binding:"required"`
+}
+
+type removeGroupSubgroupResponse struct {
+}
+
+func (r *groupRoutes) removeGroupSubgroup(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("removeGroupSubgroup").WithContext(c)
+
+	var pathParams removeGroupSubgroupPathParams
+	err := c.ShouldBindUri(&pathParams)
+	if err != nil {
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
+	}
+	logger = logger.With("pathParams", pathParams)
+	logger.Debug("parsed path params")
+
+	err = r.services.Group.RemoveGroupSubgroup(c, service.RemoveGroupSubgroupOptions{
+		GroupID:    pathParams.ID,
+		SubgroupID: pathParams.SubgroupID,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to remove group subgroup", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to remove group subgroup", Details: err}
+	}
+
+	logger.Info("successfully removed group subgroup")
+	return removeGroupSubgroupResponse{}, nil
+}
+
+type assignGroupRoleRequestBody struct {
+	RoleID string `json:"roleId" binding:"required"`
+}
+
+type assignGroupRoleResponse struct {
+}
+
+func (r *groupRoutes) assignGroupRole(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("assignGroupRole").WithContext(c)
+
+	var pathParams groupPathParams
+	err := c.ShouldBindUri(&pathParams)
+	if err != nil {
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
+	}
+
+	var body assignGroupRoleRequestBody
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("pathParams", pathParams, "body", body)
+	logger.Debug("parsed request")
+
+	err = r.services.Group.AssignGroupRole(c, service.AssignGroupRoleOptions{
+		GroupID: pathParams.ID,
+		RoleID:  body.RoleID,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to assign group role", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to assign group role", Details: err}
+	}
+
+	logger.Info("successfully assigned group role")
+	return assignGroupRoleResponse{}, nil
+}
+
+type unassignGroupRolePathParams struct {
+	ID     string `uri:"id" binding:"required"`
+	RoleID string `uri:"roleId" binding:"required"`
+}
+
+type unassignGroupRoleResponse struct {
+}
+
+func (r *groupRoutes) unassignGroupRole(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("unassignGroupRole").WithContext(c)
+
+	var pathParams unassignGroupRolePathParams
+	err := c.ShouldBindUri(&pathParams)
+	if err != nil {
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
+	}
+	logger = logger.With("pathParams", pathParams)
+	logger.Debug("parsed path params")
+
+	err = r.services.Group.UnassignGroupRole(c, service.UnassignGroupRoleOptions{
+		GroupID: pathParams.ID,
+		RoleID:  pathParams.RoleID,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to unassign group role", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to unassign group role", Details: err}
+	}
+
+	logger.Info("successfully unassigned group role")
+	return unassignGroupRoleResponse{}, nil
+}
+
+type listUserGroupsResponse struct {
+	Groups []entity.Group `json:"groups"`
+}
+
+func (r *groupRoutes) listUserGroups(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("listUserGroups").WithContext(c)
+
+	var pathParams groupPathParams
+	err := c.ShouldBindUri(&pathParams)
+	if err != nil {
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
+	}
+	logger = logger.With("pathParams", pathParams)
+	logger.Debug("parsed path params")
+
+	groups, err := r.services.Group.ListUserGroups(c, pathParams.ID)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to list user groups", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to list user groups", Details: err}
+	}
+
+	logger.Info("successfully listed user groups")
+	return listUserGroupsResponse{
+		Groups: groups,
+	}, nil
+}
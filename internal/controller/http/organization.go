@@ -0,0 +1,333 @@
+package httpcontroller
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+	"github.com/taraslis453/solid-software-test/pkg/errs"
+)
+
+type organizationRoutes struct {
+	routerContext
+}
+
+func newOrganizationRoutes(options RouterOptions) {
+	r := &organizationRoutes{
+		routerContext{
+			services: options.Services,
+			logger:   options.Logger.Named("organizationRoutes"),
+			cfg:      options.Config,
+		},
+	}
+
+	p := options.Handler.Group("/organizations")
+	{
+		p.GET("/current", errorHandler(options, r.getCurrentOrganization))
+		p.POST("", newAuthMiddleware(options), newRequirePermissionMiddleware(options, entity.PermissionOrganizationsManage), errorHandler(options, r.createOrganization))
+		p.GET("/members", newAuthMiddleware(options), newRequirePermissionMiddleware(options, entity.PermissionMembersManage), errorHandler(options, r.listMembers))
+		p.PUT("/members/:userId", newAuthMiddleware(options), newRequirePermissionMiddleware(options, entity.PermissionMembersManage), errorHandler(options, r.updateMember))
+		p.DELETE("/members/:userId", newAuthMiddleware(options), newRequirePermissionMiddleware(options, entity.PermissionMembersManage), errorHandler(options, r.removeMember))
+		p.POST("/invitations", newAuthMiddleware(options), newRequirePermissionMiddleware(options, entity.PermissionMembersManage), errorHandler(options, r.inviteMember))
+		p.POST("/invitations/accept", newOptionalAuthMiddleware(options), errorHandler(options, r.acceptInvitation))
+		p.POST("/invitations/decline", errorHandler(options, r.declineInvitation))
+	}
+}
+
+type getCurrentOrganizationResponse struct {
+	Organization *entity.Organization `json:"organization"`
+}
+
+func (r *organizationRoutes) getCurrentOrganization(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("getCurrentOrganization").WithContext(c)
+
+	organization, err := r.services.Organization.GetOrganization(c, service.GetOrganizationOptions{
+		ID: service.TenantFromContext(c),
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to get organization", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to get organization", Details: err}
+	}
+
+	logger.Info("successfully got current organization")
+	return getCurrentOrganizationResponse{
+		Organization: organization,
+	}, nil
+}
+
+type createOrganizationRequestBody struct {
+	Name string `json:"name" binding:"required"`
+	Slug string `json:"slug" binding:"required"`
+}
+
+type createOrganizationResponse struct {
+	Organization *entity.Organization `json:"organization"`
+}
+
+func (r *organizationRoutes) createOrganization(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("createOrganization").WithContext(c)
+
+	var body createOrganizationRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	organization, err := r.services.Organization.CreateOrganization(c, service.CreateOrganizationOptions{
+		Name: body.Name,
+		Slug: body.Slug,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to create organization", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to create organization", Details: err}
+	}
+
+	logger.Info("successfully created organization")
+	return createOrganizationResponse{
+		Organization: organization,
+	}, nil
+}
+
+type listMembersResponse struct {
+	Members []entity.Membership `json:"members"`
+}
+
+func (r *organizationRoutes) listMembers(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("listMembers").WithContext(c)
+
+	members, err := r.services.Organization.ListOrganizationMembers(c)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to list members", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to list members", Details: err}
+	}
+
+	logger.Info("successfully listed members")
+	return listMembersResponse{
+		Members: members,
+	}, nil
+}
+
+type memberPathParams struct {
+	UserID string `uri:"userId" binding:"required"`
+}
+
+type updateMemberRequestBody struct {
+	RoleID string `json:"roleId"`
+}
+
+type updateMemberResponse struct {
+	Member *entity.Membership `json:"member"`
+}
+
+func (r *organizationRoutes) updateMember(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("updateMember").WithContext(c)
+
+	var pathParams memberPathParams
+	err := c.ShouldBindUri(&pathParams)
+	if err != nil {
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
+	}
+
+	var body updateMemberRequestBody
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("pathParams", pathParams, "body", body)
+	logger.Debug("parsed request")
+
+	member, err := r.services.Organization.UpdateOrganizationMember(c, service.UpdateOrganizationMemberOptions{
+		UserID: pathParams.UserID,
+		RoleID: body.RoleID,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to update member", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to update member", Details: err}
+	}
+
+	logger.Info("successfully updated member")
+	return updateMemberResponse{
+		Member: member,
+	}, nil
+}
+
+type removeMemberResponse struct {
+}
+
+func (r *organizationRoutes) removeMember(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("removeMember").WithContext(c)
+
+	var pathParams memberPathParams
+	err := c.ShouldBindUri(&pathParams)
+	if err != nil {
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
+	}
+	logger = logger.With("pathParams", pathParams)
+	logger.Debug("parsed path params")
+
+	err = r.services.Organization.RemoveOrganizationMember(c, pathParams.UserID)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to remove member", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to remove member", Details: err}
+	}
+
+	logger.Info("successfully removed member")
+	return removeMemberResponse{}, nil
+}
+
+type inviteMemberRequestBody struct {
+	EmailAddress string `json:"email" binding:"required,email"`
+	RoleID       string `json:"roleId"`
+}
+
+type inviteMemberResponse struct {
+}
+
+func (r *organizationRoutes) inviteMember(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("inviteMember").WithContext(c)
+
+	var body inviteMemberRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	err = r.services.User.InviteUserToOrganization(c, service.InviteUserToOrganizationOptions{
+		EmailAddress: body.EmailAddress,
+		RoleID:       body.RoleID,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to invite member", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to invite member", Details: err}
+	}
+
+	logger.Info("successfully invited member")
+	return inviteMemberResponse{}, nil
+}
+
+type acceptInvitationRequestBody struct {
+	Token string `json:"token" binding:"required"`
+	// Registration data is required if request is not authenticated.
+	Name     string `json:"name"`
+	Surname  string `json:"surname"`
+	Password string `json:"password"`
+	Phone    string `json:"phone"`
+}
+
+type acceptInvitationResponse struct {
+	Member *entity.Membership `json:"member"`
+}
+
+func (r *organizationRoutes) acceptInvitation(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("acceptInvitation").WithContext(c)
+
+	var body acceptInvitationRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	opts := service.AcceptOrganizationInvitationOptions{
+		Token: body.Token,
+	}
+	if body.Password != "" {
+		opts.Registration = &service.RegisterUserOptions{
+			Name:     body.Name,
+			Surname:  body.Surname,
+			Phone:    body.Phone,
+			Password: body.Password,
+		}
+	}
+
+	member, err := r.services.User.AcceptOrganizationInvitation(c, opts)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err), Details: errs.GetDetails(err)}
+		}
+
+		logger.Error("failed to accept invitation", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to accept invitation", Details: err}
+	}
+
+	logger.Info("successfully accepted invitation")
+	return acceptInvitationResponse{
+		Member: member,
+	}, nil
+}
+
+type declineInvitationRequestBody struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type declineInvitationResponse struct {
+}
+
+func (r *organizationRoutes) declineInvitation(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("declineInvitation").WithContext(c)
+
+	var body declineInvitationRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	err = r.services.User.DeclineOrganizationInvitation(c, service.DeclineOrganizationInvitationOptions{
+		Token: body.Token,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to decline invitation", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to decline invitation", Details: err}
+	}
+
+	logger.Info("successfully declined invitation")
+	return declineInvitationResponse{}, nil
+}
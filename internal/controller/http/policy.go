@@ -0,0 +1,102 @@
+package httpcontroller
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+	"github.com/taraslis453/solid-software-test/pkg/errs"
+	"github.com/taraslis453/solid-software-test/pkg/policy"
+)
+
+type policyRoutes struct {
+	routerContext
+}
+
+func newPolicyRoutes(options RouterOptions) {
+	r := &policyRoutes{
+		routerContext{
+			services: options.Services,
+			logger:   options.Logger.Named("policyRoutes"),
+			cfg:      options.Config,
+		},
+	}
+
+	p := options.Handler.Group("/policies", newAuthMiddleware(options), newRequirePermissionMiddleware(options, entity.PermissionPoliciesEvaluate))
+	{
+		p.GET("", errorHandler(options, r.listPolicies))
+		p.POST("/evaluate", errorHandler(options, r.evaluatePolicies))
+	}
+}
+
+type listPoliciesResponse struct {
+	Policies []policy.Policy `json:"policies"`
+}
+
+func (r *policyRoutes) listPolicies(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("listPolicies").WithContext(c)
+
+	policies, err := r.services.Policy.ListPolicies(c)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to list policies", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to list policies", Details: err}
+	}
+
+	logger.Info("successfully listed policies")
+	return listPoliciesResponse{
+		Policies: policies,
+	}, nil
+}
+
+type evaluatePoliciesRequestBody struct {
+	Action   string            `json:"action" binding:"required"`
+	Subject  policy.Attributes `json:"subject"`
+	Resource policy.Attributes `json:"resource"`
+	// Policies are evaluated instead of loaded ones if set.
+	Policies []policy.Policy `json:"policies"`
+}
+
+type evaluatePoliciesResponse struct {
+	Decision *policy.Decision `json:"decision"`
+}
+
+func (r *policyRoutes) evaluatePolicies(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("evaluatePolicies").WithContext(c)
+
+	var body evaluatePoliciesRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	decision, err := r.services.Policy.EvaluatePolicies(c, service.EvaluatePoliciesOptions{
+		Request: policy.Request{
+			Subject:  body.Subject,
+			Resource: body.Resource,
+			Action:   body.Action,
+		},
+		Policies: body.Policies,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to evaluate policies", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to evaluate policies", Details: err}
+	}
+
+	logger.Info("successfully evaluated policies")
+	return evaluatePoliciesResponse{
+		Decision: decision,
+	}, nil
+}
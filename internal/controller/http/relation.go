@@ -0,0 +1,213 @@
+package httpcontroller
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+	"github.com/taraslis453/solid-software-test/pkg/errs"
+	"github.com/taraslis453/solid-software-test/pkg/rebac"
+)
+
+type relationRoutes struct {
+	routerContext
+}
+
+func newRelationRoutes(options RouterOptions) {
+	r := &relationRoutes{
+		routerContext{
+			services: options.Services,
+			logger:   options.Logger.Named("relationRoutes"),
+			cfg:      options.Config,
+		},
+	}
+
+	p := options.Handler.Group("/relations", newAuthMiddleware(options))
+	{
+		p.POST("/tuples", newRequirePermissionMiddleware(options, entity.PermissionRelationsManage), errorHandler(options, r.writeTuples))
+		p.POST("/check", errorHandler(options, r.check))
+		p.POST("/expand", errorHandler(options, r.expand))
+		p.POST("/list-objects", errorHandler(options, r.listObjects))
+	}
+}
+
+type writeTuplesRequestBody struct {
+	// Tuples are written as "namespace:id#relation@subject".
+	Writes  []rebac.Tuple `json:"writes"`
+	Deletes []rebac.Tuple `json:"deletes"`
+}
+
+type writeTuplesResponse struct {
+	ConsistencyToken string `json:"consistencyToken"`
+}
+
+func (r *relationRoutes) writeTuples(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("writeTuples").WithContext(c)
+
+	var body writeTuplesRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	output, err := r.services.Relation.WriteRelationTuples(c, service.WriteRelationTuplesOptions{
+		Writes:  body.Writes,
+		Deletes: body.Deletes,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err), Details: errs.GetDetails(err)}
+		}
+
+		logger.Error("failed to write relation tuples", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to write relation tuples", Details: err}
+	}
+
+	logger.Info("successfully wrote relation tuples")
+	return writeTuplesResponse{
+		ConsistencyToken: output.ConsistencyToken,
+	}, nil
+}
+
+type checkRequestBody struct {
+	Object           rebac.Object  `json:"object"`
+	Relation         string        `json:"relation" binding:"required"`
+	Subject          rebac.Subject `json:"subject"`
+	ConsistencyToken string        `json:"consistencyToken"`
+}
+
+type checkResponse struct {
+	Allowed          bool   `json:"allowed"`
+	ConsistencyToken string `json:"consistencyToken"`
+}
+
+func (r *relationRoutes) check(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("check").WithContext(c)
+
+	var body checkRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	output, err := r.services.Relation.CheckRelation(c, service.CheckRelationOptions{
+		Object:           body.Object,
+		Relation:         body.Relation,
+		Subject:          body.Subject,
+		ConsistencyToken: body.ConsistencyToken,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err), Details: errs.GetDetails(err)}
+		}
+
+		logger.Error("failed to check relation", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to check relation", Details: err}
+	}
+
+	logger.Info("successfully checked relation")
+	return checkResponse{
+		Allowed:          output.Allowed,
+		ConsistencyToken: output.ConsistencyToken,
+	}, nil
+}
+
+type expandRequestBody struct {
+	Object           rebac.Object `json:"object"`
+	Relation         string       `json:"relation" binding:"required"`
+	ConsistencyToken string       `json:"consistencyToken"`
+}
+
+type expandResponse struct {
+	Tree             *rebac.Tree `json:"tree"`
+	ConsistencyToken string      `json:"consistencyToken"`
+}
+
+func (r *relationRoutes) expand(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("expand").WithContext(c)
+
+	var body expandRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	output, err := r.services.Relation.ExpandRelation(c, service.ExpandRelationOptions{
+		Object:           body.Object,
+		Relation:         body.Relation,
+		ConsistencyToken: body.ConsistencyToken,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err), Details: errs.GetDetails(err)}
+		}
+
+		logger.Error("failed to expand relation", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to expand relation", Details: err}
+	}
+
+	logger.Info("successfully expanded relation")
+	return expandResponse{
+		Tree:             output.Tree,
+		ConsistencyToken: output.ConsistencyToken,
+	}, nil
+}
+
+type listObjectsRequestBody struct {
+	Namespace        string        `json:"namespace" binding:"required"`
+	Relation         string        `json:"relation" binding:"required"`
+	Subject          rebac.Subject `json:"subject"`
+	ConsistencyToken string        `json:"consistencyToken"`
+}
+
+type listObjectsResponse struct {
+	ObjectIDs        []string `json:"objectIds"`
+	ConsistencyToken string   `json:"consistencyToken"`
+}
+
+func (r *relationRoutes) listObjects(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("listObjects").WithContext(c)
+
+	var body listObjectsRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	output, err := r.services.Relation.ListRelationObjects(c, service.ListRelationObjectsOptions{
+		Namespace:        body.Namespace,
+		Relation:         body.Relation,
+		Subject:          body.Subject,
+		ConsistencyToken: body.ConsistencyToken,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err), Details: errs.GetDetails(err)}
+		}
+
+		logger.Error("failed to list relation objects", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to list relation objects", Details: err}
+	}
+
+	logger.Info("successfully listed relation objects")
+	return listObjectsResponse{
+		ObjectIDs:        output.ObjectIDs,
+		ConsistencyToken: output.ConsistencyToken,
+	}, nil
+}
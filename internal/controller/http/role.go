@@ -0,0 +1,213 @@
+package httpcontroller
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+	"github.com/taraslis453/solid-software-test/pkg/errs"
+)
+
+type roleRoutes struct {
+	routerContext
+}
+
+func newRoleRoutes(options RouterOptions) {
+	r := &roleRoutes{
+		routerContext{
+			services: options.Services,
+			logger:   options.Logger.Named("roleRoutes"),
+			cfg:      options.Config,
+		},
+	}
+
+	p := options.Handler.Group("/roles", newAuthMiddleware(options), newRequirePermissionMiddleware(options, entity.PermissionRolesManage))
+	{
+		p.GET("", errorHandler(options, r.listRoles))
+		p.POST("", errorHandler(options, r.createRole))
+		p.GET("/permissions", errorHandler(options, r.listPermissions))
+	}
+
+	u := options.Handler.Group("/users", newAuthMiddleware(options), newRequirePermissionMiddleware(options, entity.PermissionRolesManage))
+	{
+		u.POST("/:id/roles", errorHandler(options, r.assignUserRole))
+		u.DELETE("/:id/roles/:roleId", errorHandler(options, r.unassignUserRole))
+	}
+}
+
+type listRolesResponse struct {
+	Roles []entity.Role `json:"roles"`
+}
+
+func (r *roleRoutes) listRoles(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("listRoles").WithContext(c)
+
+	roles, err := r.services.Role.ListRoles(c)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to list roles", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to list roles", Details: err}
+	}
+
+	logger.Info("successfully listed roles")
+	return listRolesResponse{
+		Roles: roles,
+	}, nil
+}
+
+type listPermissionsResponse struct {
+	Permissions []entity.Permission `json:"permissions"`
+}
+
+func (r *roleRoutes) listPermissions(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("listPermissions").WithContext(c)
+
+	permissions, err := r.services.Role.ListPermissions(c)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to list permissions", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to list permissions", Details: err}
+	}
+
+	logger.Info("successfully listed permissions")
+	return listPermissionsResponse{
+		Permissions: permissions,
+	}, nil
+}
+
+type createRoleRequestBody struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+type createRoleResponse struct {
+	Role *entity.Role `json:"role"`
+}
+
+func (r *roleRoutes) createRole(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("createRole").WithContext(c)
+
+	var body createRoleRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	role, err := r.services.Role.CreateRole(c, service.CreateRoleOptions{
+		Name:        body.Name,
+		Description: body.Description,
+		Permissions: body.Permissions,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to create role", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to create role", Details: err}
+	}
+
+	logger.Info("successfully created role")
+	return createRoleResponse{
+		Role: role,
+	}, nil
+}
+
+type assignUserRolePathParams struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+type assignUserRoleRequestBody struct {
+	RoleID string `json:"roleId" binding:"required"`
+}
+
+type assignUserRoleResponse struct {
+}
+
+func (r *roleRoutes) assignUserRole(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("assignUserRole").WithContext(c)
+
+	var pathParams assignUserRolePathParams
+	err := c.ShouldBindUri(&pathParams)
+	if err != nil {
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
+	}
+
+	var body assignUserRoleRequestBody
+	err = c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("pathParams", pathParams, "body", body)
+	logger.Debug("parsed request")
+
+	err = r.services.Role.AssignUserRole(c, service.AssignUserRoleOptions{
+		UserID: pathParams.ID,
+		RoleID: body.RoleID,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to assign user role", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to assign user role", Details: err}
+	}
+
+	logger.Info("successfully assigned user role")
+	return assignUserRoleResponse{}, nil
+}
+
+type unassignUserRolePathParams struct {
+	ID     string `uri:"id" binding:"required"`
+	RoleID string `uri:"roleId" binding:"required"`
+}
+
+type unassignUserRoleResponse struct {
+}
+
+func (r *roleRoutes) unassignUserRole(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("unassignUserRole").WithContext(c)
+
+	var pathParams unassignUserRolePathParams
+	err := c.ShouldBindUri(&pathParams)
+	if err != nil {
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
+	}
+	logger = logger.With("pathParams", pathParams)
+	logger.Debug("parsed path params")
+
+	err = r.services.Role.UnassignUserRole(c, service.UnassignUserRoleOptions{
+		UserID: pathParams.ID,
+		RoleID: pathParams.RoleID,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to unassign user role", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to unassign user role", Details: err}
+	}
+
+	logger.Info("successfully unassigned user role")
+	return unassignUserRoleResponse{}, nil
+}
@@ -1,11 +1,15 @@
 package httpcontroller
 
 import (
+	"errors"
+	"io"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/taraslis453/solid-software-test/internal/entity"
 	"github.com/taraslis453/solid-software-test/internal/service"
 	"github.com/taraslis453/solid-software-test/pkg/errs"
+	"github.com/taraslis453/solid-software-test/pkg/webauthn"
 )
 
 type userRoutes struct {
@@ -25,9 +29,36 @@ func newUserRoutes(options RouterOptions) {
 	{
 		p.POST("register/", errorHandler(options, r.registerUser))
 		p.GET("/login", errorHandler(options, r.loginUser))
+		p.POST("/login/mfa", errorHandler(options, r.loginUserMFA))
+		p.POST("/login/magic-link", errorHandler(options, r.sendMagicLink))
+		p.GET("/login/magic-link/verify", errorHandler(options, r.loginUserMagicLink))
+		p.POST("/login/phone", errorHandler(options, r.sendLoginCode))
+		p.POST("/login/phone/verify", errorHandler(options, r.loginUserPhone))
+		p.POST("/email/verify", errorHandler(options, r.verifyEmail))
+		p.POST("/email/verify/resend", errorHandler(options, r.resendEmailVerification))
+		p.POST("/email/change/confirm", errorHandler(options, r.confirmEmailChange))
+		p.POST("/email/change/undo", errorHandler(options, r.undoEmailChange))
 		p.POST("/refresh-token", errorHandler(options, r.refreshToken))
+		p.POST("/reauthenticate", newAuthMiddleware(options), errorHandler(options, r.reauthenticate))
 		p.GET("/:id", newAuthMiddleware(options), errorHandler(options, r.getUserResponse))
+		p.POST("/:id/unlock", newAuthMiddleware(options), newRequirePermissionMiddleware(options, entity.PermissionUsersUnlock), errorHandler(options, r.unlockUser))
+		p.DELETE("/:id", newAuthMiddleware(options), newRequirePermissionMiddleware(options, entity.PermissionUsersDelete), errorHandler(options, r.deleteUser))
 		p.PUT("", newAuthMiddleware(options), errorHandler(options, r.updateUser))
+		p.DELETE("/me", newAuthMiddleware(options), errorHandler(options, r.deleteAccount))
+		p.POST("/me/email", newAuthMiddleware(options), newReauthMiddleware(options), errorHandler(options, r.requestEmailChange))
+		p.POST("/me/password", newAuthMiddleware(options), newReauthMiddleware(options), errorHandler(options, r.changePassword))
+		p.POST("/me/organization", newAuthMiddleware(options), errorHandler(options, r.switchOrganization))
+		p.POST("/password/forgot", errorHandler(options, r.forgotPassword))
+		p.POST("/password/reset", errorHandler(options, r.resetPassword))
+		p.POST("/me/phone/verify/send", newAuthMiddleware(options), newEmailVerifiedMiddleware(options), errorHandler(options, r.sendPhoneVerificationCode))
+		p.POST("/me/phone/verify", newAuthMiddleware(options), newEmailVerifiedMiddleware(options), errorHandler(options, r.verifyPhone))
+		p.POST("/me/mfa/totp", newAuthMiddleware(options), newEmailVerifiedMiddleware(options), newReauthMiddleware(options), errorHandler(options, r.enrollTOTP))
+		p.POST("/me/mfa/totp/confirm", newAuthMiddleware(options), newEmailVerifiedMiddleware(options), newReauthMiddleware(options), errorHandler(options, r.confirmTOTP))
+		p.POST("/me/mfa/recovery-codes", newAuthMiddleware(options), newEmailVerifiedMiddleware(options), newReauthMiddleware(options), errorHandler(options, r.regenerateRecoveryCodes))
+		p.POST("/me/webauthn/register/begin", newAuthMiddleware(options), newEmailVerifiedMiddleware(options), newReauthMiddleware(options), errorHandler(options, r.beginWebAuthnRegistration))
+		p.POST("/me/webauthn/register/finish", newAuthMiddleware(options), newEmailVerifiedMiddleware(options), newReauthMiddleware(options), errorHandler(options, r.finishWebAuthnRegistration))
+		p.POST("/login/webauthn/begin", errorHandler(options, r.beginWebAuthnLogin))
+		p.POST("/login/webauthn/finish", errorHandler(options, r.finishWebAuthnLogin))
 	}
 }
 
@@ -64,7 +95,7 @@ func (r *userRoutes) registerUser(c *gin.Context) (interface{}, *httpErr) {
 	if err != nil {
 		if errs.IsExpected(err) {
 			logger.Info(err.Error())
-			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err), Details: errs.GetDetails(err)}
 		}
 
 		logger.Error("failed to register user", "err", err)
@@ -81,9 +112,14 @@ type loginUserRequestQuery struct {
 }
 
 type loginUserResponse struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
-	UserID       string `json:"userId"`
+	AccessToken       string `json:"accessToken,omitempty"`
+	RefreshToken      string `json:"refreshToken,omitempty"`
+	UserID            string `json:"userId,omitempty"`
+	MFARequired       bool   `json:"mfaRequired,omitempty"`
+	MFAChallengeToken string `json:"mfaChallengeToken,omitempty"`
+	// RecoveryCodesWarning is set after MFA login if user has few recovery codes left.
+	RecoveryCodesWarning   string `json:"recoveryCodesWarning,omitempty"`
+	RecoveryCodesRemaining *int   `json:"recoveryCodesRemaining,omitempty"`
 }
 
 func (r *userRoutes) loginUser(c *gin.Context) (interface{}, *httpErr) {
@@ -101,11 +137,12 @@ func (r *userRoutes) loginUser(c *gin.Context) (interface{}, *httpErr) {
 	output, err := r.services.User.LoginUser(c, service.LoginUserOptions{
 		EmailAddress: query.Email,
 		Password:     query.Password,
+		IPAddress:    c.ClientIP(),
 	})
 	if err != nil {
 		if errs.IsExpected(err) {
 			logger.Info(err.Error())
-			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err), Details: errs.GetDetails(err)}
 		}
 
 		logger.Error("failed to login user", "err", err)
@@ -114,9 +151,264 @@ func (r *userRoutes) loginUser(c *gin.Context) (interface{}, *httpErr) {
 
 	logger.Info("successfully logged in user")
 	return loginUserResponse{
-		AccessToken:  output.AccessToken,
-		RefreshToken: output.RefreshToken,
-		UserID:       output.UserID,
+		AccessToken:       output.AccessToken,
+		RefreshToken:      output.RefreshToken,
+		UserID:            output.UserID,
+		MFARequired:       output.MFARequired,
+		MFAChallengeToken: output.MFAChallengeToken,
+	}, nil
+}
+
+type loginUserMFARequestBody struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode   string `json:"recoveryCode"`
+}
+
+func (r *userRoutes) loginUserMFA(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("loginUserMFA").WithContext(c)
+
+	var body loginUserMFARequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	output, err := r.services.User.LoginUserMFA(c, service.LoginUserMFAOptions{
+		ChallengeToken: body.ChallengeToken,
+		Code:           body.Code,
+		RecoveryCode:   body.RecoveryCode,
+		IPAddress:      c.ClientIP(),
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err), Details: errs.GetDetails(err)}
+		}
+
+		logger.Error("failed to login user with mfa", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to login user with mfa", Details: err}
+	}
+
+	logger.Info("successfully logged in user with mfa")
+	return loginUserResponse{
+		AccessToken:            output.AccessToken,
+		RefreshToken:           output.RefreshToken,
+		UserID:                 output.UserID,
+		RecoveryCodesWarning:   output.RecoveryCodesWarning,
+		RecoveryCodesRemaining: &output.RecoveryCodesRemaining,
+	}, nil
+}
+
+type sendMagicLinkRequestBody struct {
+	EmailAddress string `json:"email" binding:"required"`
+}
+
+type sendMagicLinkResponse struct {
+}
+
+func (r *userRoutes) sendMagicLink(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("sendMagicLink").WithContext(c)
+
+	var body sendMagicLinkRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	err = r.services.User.SendUserMagicLink(c, service.SendUserMagicLinkOptions{
+		EmailAddress: body.EmailAddress,
+	})
+	if err != nil {
+		logger.Error("failed to send magic link", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to send magic link", Details: err}
+	}
+
+	logger.Info("successfully processed magic link request")
+	return sendMagicLinkResponse{}, nil
+}
+
+type verifyEmailRequestBody struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type verifyEmailResponse struct {
+}
+
+func (r *userRoutes) verifyEmail(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("verifyEmail").WithContext(c)
+
+	var body verifyEmailRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	err = r.services.User.VerifyUserEmail(c, service.VerifyUserEmailOptions{
+		Token: body.Token,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+		logger.Error("failed to verify email", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to verify email", Details: err}
+	}
+
+	logger.Info("successfully verified email")
+	return verifyEmailResponse{}, nil
+}
+
+type resendEmailVerificationRequestBody struct {
+	EmailAddress string `json:"email" binding:"required"`
+}
+
+type resendEmailVerificationResponse struct {
+}
+
+func (r *userRoutes) resendEmailVerification(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("resendEmailVerification").WithContext(c)
+
+	var body resendEmailVerificationRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	err = r.services.User.ResendUserEmailVerification(c, service.ResendUserEmailVerificationOptions{
+		EmailAddress: body.EmailAddress,
+	})
+	if err != nil {
+		logger.Error("failed to resend email verification", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to resend email verification", Details: err}
+	}
+
+	logger.Info("successfully processed email verification resend request")
+	return resendEmailVerificationResponse{}, nil
+}
+
+type loginUserMagicLinkRequestQuery struct {
+	Token string `form:"token" binding:"required"`
+}
+
+func (r *userRoutes) loginUserMagicLink(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("loginUserMagicLink").WithContext(c)
+
+	var query loginUserMagicLinkRequestQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		logger.Info("failed to parse query", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request query", Details: err}
+	}
+	logger.Debug("parsed query")
+
+	output, err := r.services.User.LoginUserMagicLink(c, service.LoginUserMagicLinkOptions{
+		Token: query.Token,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to login user with magic link", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to login user with magic link", Details: err}
+	}
+
+	logger.Info("successfully logged in user with magic link")
+	return loginUserResponse{
+		AccessToken:       output.AccessToken,
+		RefreshToken:      output.RefreshToken,
+		UserID:            output.UserID,
+		MFARequired:       output.MFARequired,
+		MFAChallengeToken: output.MFAChallengeToken,
+	}, nil
+}
+
+type sendLoginCodeRequestBody struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+type sendLoginCodeResponse struct {
+}
+
+func (r *userRoutes) sendLoginCode(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("sendLoginCode").WithContext(c)
+
+	var body sendLoginCodeRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	err = r.services.User.SendUserLoginCode(c, service.SendUserLoginCodeOptions{
+		Phone: body.Phone,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to send login code", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to send login code", Details: err}
+	}
+
+	logger.Info("successfully processed login code request")
+	return sendLoginCodeResponse{}, nil
+}
+
+type loginUserPhoneRequestBody struct {
+	Phone string `json:"phone" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+func (r *userRoutes) loginUserPhone(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("loginUserPhone").WithContext(c)
+
+	var body loginUserPhoneRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	output, err := r.services.User.LoginUserPhone(c, service.LoginUserPhoneOptions{
+		Phone: body.Phone,
+		Code:  body.Code,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to login user with phone", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to login user with phone", Details: err}
+	}
+
+	logger.Info("successfully logged in user with phone")
+	return loginUserResponse{
+		AccessToken:       output.AccessToken,
+		RefreshToken:      output.RefreshToken,
+		UserID:            output.UserID,
+		MFARequired:       output.MFARequired,
+		MFAChallengeToken: output.MFAChallengeToken,
 	}, nil
 }
 
@@ -151,6 +443,94 @@ func (r *userRoutes) refreshToken(c *gin.Context) (interface{}, *httpErr) {
 	}, nil
 }
 
+type reauthenticateRequestBody struct {
+	Password string `json:"password" binding:"required"`
+	// Code is the TOTP code, required if user has MFA enabled. RecoveryCode can be passed instead.
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type reauthenticateResponseBody struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+func (r *userRoutes) reauthenticate(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("reauthenticate").WithContext(c)
+
+	var body reauthenticateRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	tokens, err := r.services.User.ReauthenticateUser(c, service.ReauthenticateUserOptions{
+		UserID:       c.GetString("userID"),
+		SessionID:    c.GetString("sessionID"),
+		Password:     body.Password,
+		Code:         body.Code,
+		RecoveryCode: body.RecoveryCode,
+		IPAddress:    c.ClientIP(),
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err), Details: errs.GetDetails(err)}
+		}
+		logger.Error("failed to reauthenticate user", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to reauthenticate user", Details: err}
+	}
+
+	logger.Info("user successfully reauthenticated")
+	return reauthenticateResponseBody{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+type switchOrganizationRequestBody struct {
+	OrganizationID string `json:"organizationId" binding:"required"`
+}
+
+type switchOrganizationResponseBody struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+func (r *userRoutes) switchOrganization(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("switchOrganization").WithContext(c)
+
+	var body switchOrganizationRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	tokens, err := r.services.User.SwitchUserOrganization(c, service.SwitchUserOrganizationOptions{
+		UserID:         c.GetString("userID"),
+		OrganizationID: body.OrganizationID,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+		logger.Error("failed to switch user organization", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to switch user organization", Details: err}
+	}
+
+	logger.Info("successfully switched user organization")
+	return switchOrganizationResponseBody{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
 type getUserPathParams struct {
 	ID string `uri:"id" binding:"required"`
 }
@@ -188,39 +568,655 @@ func (r *userRoutes) getUserResponse(c *gin.Context) (interface{}, *httpErr) {
 	}, nil
 }
 
-type updateUserRequestBody struct {
-	User *entity.User `json:"user" binding:"required"`
+type unlockUserPathParams struct {
+	ID string `uri:"id" binding:"required"`
 }
 
-type updateUserResponse struct {
-	User *entity.User `json:"user" binding:"required"`
+type unlockUserResponse struct {
 }
 
-func (r *userRoutes) updateUser(c *gin.Context) (interface{}, *httpErr) {
-	logger := r.logger.Named("updateUser").WithContext(c)
+func (r *userRoutes) unlockUser(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("unlockUser").WithContext(c)
 
-	var body updateUserRequestBody
-	err := c.ShouldBindJSON(&body)
+	var pathParams unlockUserPathParams
+	err := c.ShouldBindUri(&pathParams)
 	if err != nil {
-		logger.Info("failed to parse body", "err", err)
-		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
 	}
-	logger = logger.With("body", body)
-	logger.Debug("parsed request body")
+	logger = logger.With("pathParams", pathParams)
+	logger.Debug("parsed path params")
 
-	updatedUser, err := r.services.User.UpdateUser(c, body.User)
+	err = r.services.User.UnlockUser(c, pathParams.ID)
 	if err != nil {
 		if errs.IsExpected(err) {
 			logger.Info(err.Error())
 			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
 		}
 
-		logger.Error("failed to update user", "err", err)
-		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to update user", Details: err}
+		logger.Error("failed to unlock user", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to unlock user", Details: err}
 	}
 
-	logger.Info("successfully updated user")
-	return updateUserResponse{
-		User: updatedUser,
+	logger.Info("successfully unlocked user")
+	return unlockUserResponse{}, nil
+}
+
+type deleteUserPathParams struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+type deleteUserResponse struct {
+}
+
+func (r *userRoutes) deleteUser(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("deleteUser").WithContext(c)
+
+	var pathParams deleteUserPathParams
+	err := c.ShouldBindUri(&pathParams)
+	if err != nil {
+		logger.Info("failed to parse path params", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid path params", Details: err}
+	}
+	logger = logger.With("pathParams", pathParams)
+	logger.Debug("parsed path params")
+
+	err = r.services.User.DeleteUser(c, pathParams.ID)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to delete user", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to delete user", Details: err}
+	}
+
+	logger.Info("successfully deleted user")
+	return deleteUserResponse{}, nil
+}
+
+type deleteAccountRequestBody struct {
+	// Password is optional if user authenticated recently.
+	Password string `json:"password"`
+	// Code is the TOTP code, required with the password if user has MFA enabled. RecoveryCode can be passed instead.
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type deleteAccountResponse struct {
+}
+
+func (r *userRoutes) deleteAccount(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("deleteAccount").WithContext(c)
+
+	// Body is optional, since recently authenticated user does not have to confirm with the password
+	var body deleteAccountRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil && !errors.Is(err, io.EOF) {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	err = r.services.User.DeleteUserAccount(c, service.DeleteUserAccountOptions{
+		UserID:       c.GetString("userID"),
+		Password:     body.Password,
+		Code:         body.Code,
+		RecoveryCode: body.RecoveryCode,
+		IPAddress:    c.ClientIP(),
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err), Details: errs.GetDetails(err)}
+		}
+
+		logger.Error("failed to delete account", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to delete account", Details: err}
+	}
+
+	logger.Info("successfully deleted account")
+	return deleteAccountResponse{}, nil
+}
+
+type updateUserRequestBody struct {
+	User *entity.User `json:"user" binding:"required"`
+}
+
+type updateUserResponse struct {
+	User *entity.User `json:"user" binding:"required"`
+}
+
+func (r *userRoutes) updateUser(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("updateUser").WithContext(c)
+
+	var body updateUserRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	updatedUser, err := r.services.User.UpdateUser(c, body.User)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to update user", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to update user", Details: err}
+	}
+
+	logger.Info("successfully updated user")
+	return updateUserResponse{
+		User: updatedUser,
+	}, nil
+}
+
+type requestEmailChangeRequestBody struct {
+	NewEmailAddress string `json:"email" binding:"required"`
+}
+
+type requestEmailChangeResponse struct {
+}
+
+func (r *userRoutes) requestEmailChange(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("requestEmailChange").WithContext(c)
+
+	var body requestEmailChangeRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	err = r.services.User.RequestUserEmailChange(c, service.RequestUserEmailChangeOptions{
+		UserID:          c.GetString("userID"),
+		NewEmailAddress: body.NewEmailAddress,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+		logger.Error("failed to request email change", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to request email change", Details: err}
+	}
+
+	logger.Info("successfully requested email change")
+	return requestEmailChangeResponse{}, nil
+}
+
+type confirmEmailChangeRequestBody struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type confirmEmailChangeResponse struct {
+}
+
+func (r *userRoutes) confirmEmailChange(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("confirmEmailChange").WithContext(c)
+
+	var body confirmEmailChangeRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	err = r.services.User.ConfirmUserEmailChange(c, service.ConfirmUserEmailChangeOptions{
+		Token: body.Token,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+		logger.Error("failed to confirm email change", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to confirm email change", Details: err}
+	}
+
+	logger.Info("successfully confirmed email change")
+	return confirmEmailChangeResponse{}, nil
+}
+
+type undoEmailChangeRequestBody struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type undoEmailChangeResponse struct {
+}
+
+func (r *userRoutes) undoEmailChange(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("undoEmailChange").WithContext(c)
+
+	var body undoEmailChangeRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	err = r.services.User.UndoUserEmailChange(c, service.UndoUserEmailChangeOptions{
+		Token: body.Token,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+		logger.Error("failed to undo email change", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to undo email change", Details: err}
+	}
+
+	logger.Info("successfully undone email change")
+	return undoEmailChangeResponse{}, nil
+}
+
+type changePasswordRequestBody struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+}
+
+type changePasswordResponse struct {
+}
+
+func (r *userRoutes) changePassword(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("changePassword").WithContext(c)
+
+	var body changePasswordRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	err = r.services.User.ChangeUserPassword(c, service.ChangeUserPasswordOptions{
+		UserID:          c.GetString("userID"),
+		SessionID:       c.GetString("sessionID"),
+		CurrentPassword: body.CurrentPassword,
+		NewPassword:     body.NewPassword,
+		IPAddress:       c.ClientIP(),
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err), Details: errs.GetDetails(err)}
+		}
+
+		logger.Error("failed to change password", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to change password", Details: err}
+	}
+
+	logger.Info("successfully changed password")
+	return changePasswordResponse{}, nil
+}
+
+type forgotPasswordRequestBody struct {
+	EmailAddress string `json:"email" binding:"required"`
+}
+
+type forgotPasswordResponse struct {
+}
+
+func (r *userRoutes) forgotPassword(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("forgotPassword").WithContext(c)
+
+	var body forgotPasswordRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	err = r.services.User.ForgotUserPassword(c, service.ForgotUserPasswordOptions{
+		EmailAddress: body.EmailAddress,
+	})
+	if err != nil {
+		logger.Error("failed to process forgot password", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to process forgot password", Details: err}
+	}
+
+	logger.Info("successfully processed forgot password")
+	return forgotPasswordResponse{}, nil
+}
+
+type resetPasswordRequestBody struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
+type resetPasswordResponse struct {
+}
+
+func (r *userRoutes) resetPassword(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("resetPassword").WithContext(c)
+
+	var body resetPasswordRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	err = r.services.User.ResetUserPassword(c, service.ResetUserPasswordOptions{
+		Token:       body.Token,
+		NewPassword: body.NewPassword,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err), Details: errs.GetDetails(err)}
+		}
+
+		logger.Error("failed to reset password", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to reset password", Details: err}
+	}
+
+	logger.Info("successfully reset password")
+	return resetPasswordResponse{}, nil
+}
+
+type sendPhoneVerificationCodeResponse struct {
+}
+
+func (r *userRoutes) sendPhoneVerificationCode(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("sendPhoneVerificationCode").WithContext(c)
+
+	err := r.services.User.SendUserPhoneVerificationCode(c, c.GetString("userID"))
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to send phone verification code", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to send phone verification code", Details: err}
+	}
+
+	logger.Info("successfully sent phone verification code")
+	return sendPhoneVerificationCodeResponse{}, nil
+}
+
+type verifyPhoneRequestBody struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type verifyPhoneResponse struct {
+}
+
+func (r *userRoutes) verifyPhone(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("verifyPhone").WithContext(c)
+
+	var body verifyPhoneRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	err = r.services.User.VerifyUserPhone(c, service.VerifyUserPhoneOptions{
+		UserID: c.GetString("userID"),
+		Code:   body.Code,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to verify phone", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to verify phone", Details: err}
+	}
+
+	logger.Info("successfully verified phone")
+	return verifyPhoneResponse{}, nil
+}
+
+type enrollTOTPResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	// QRCode is base64 encoded PNG image.
+	QRCode []byte `json:"qrCode"`
+}
+
+func (r *userRoutes) enrollTOTP(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("enrollTOTP").WithContext(c)
+
+	output, err := r.services.User.EnrollUserTOTP(c, c.GetString("userID"))
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to enroll totp", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to enroll totp", Details: err}
+	}
+
+	logger.Info("successfully enrolled totp")
+	return enrollTOTPResponse{
+		Secret: output.Secret,
+		URI:    output.URI,
+		QRCode: output.QRCode,
+	}, nil
+}
+
+type confirmTOTPRequestBody struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type confirmTOTPResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+func (r *userRoutes) confirmTOTP(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("confirmTOTP").WithContext(c)
+
+	var body confirmTOTPRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	output, err := r.services.User.ConfirmUserTOTP(c, service.ConfirmUserTOTPOptions{
+		UserID: c.GetString("userID"),
+		Code:   body.Code,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to confirm totp", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to confirm totp", Details: err}
+	}
+
+	logger.Info("successfully confirmed totp")
+	return confirmTOTPResponse{
+		RecoveryCodes: output.RecoveryCodes,
+	}, nil
+}
+
+type regenerateRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+func (r *userRoutes) regenerateRecoveryCodes(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("regenerateRecoveryCodes").WithContext(c)
+
+	output, err := r.services.User.RegenerateUserMFARecoveryCodes(c, c.GetString("userID"))
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to regenerate recovery codes", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to regenerate recovery codes", Details: err}
+	}
+
+	logger.Info("successfully regenerated recovery codes")
+	return regenerateRecoveryCodesResponse{
+		RecoveryCodes: output.RecoveryCodes,
+	}, nil
+}
+
+type beginWebAuthnRegistrationResponse struct {
+	PublicKey     *webauthn.CreationOptions `json:"publicKey"`
+	CeremonyToken string                    `json:"ceremonyToken"`
+}
+
+func (r *userRoutes) beginWebAuthnRegistration(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("beginWebAuthnRegistration").WithContext(c)
+
+	output, err := r.services.User.BeginUserWebAuthnRegistration(c, c.GetString("userID"))
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to begin webauthn registration", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to begin webauthn registration", Details: err}
+	}
+
+	logger.Info("successfully began webauthn registration")
+	return beginWebAuthnRegistrationResponse{
+		PublicKey:     output.Options,
+		CeremonyToken: output.CeremonyToken,
+	}, nil
+}
+
+type finishWebAuthnRegistrationRequestBody struct {
+	CeremonyToken string `json:"ceremonyToken" binding:"required"`
+	// Name is the user given name of the passkey.
+	Name       string                        `json:"name"`
+	Credential *webauthn.AttestationResponse `json:"credential" binding:"required"`
+}
+
+func (r *userRoutes) finishWebAuthnRegistration(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("finishWebAuthnRegistration").WithContext(c)
+
+	var body finishWebAuthnRegistrationRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	credential, err := r.services.User.FinishUserWebAuthnRegistration(c, service.FinishUserWebAuthnRegistrationOptions{
+		UserID:        c.GetString("userID"),
+		CeremonyToken: body.CeremonyToken,
+		Name:          body.Name,
+		Response:      body.Credential,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to finish webauthn registration", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to finish webauthn registration", Details: err}
+	}
+
+	logger.Info("successfully finished webauthn registration")
+	return credential, nil
+}
+
+type beginWebAuthnLoginRequestBody struct {
+	// Email is optional, discoverable credential is requested without it.
+	Email string `json:"email"`
+}
+
+type beginWebAuthnLoginResponse struct {
+	PublicKey     *webauthn.RequestOptions `json:"publicKey"`
+	CeremonyToken string                   `json:"ceremonyToken"`
+}
+
+func (r *userRoutes) beginWebAuthnLogin(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("beginWebAuthnLogin").WithContext(c)
+
+	var body beginWebAuthnLoginRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("body", body)
+	logger.Debug("parsed request body")
+
+	output, err := r.services.User.BeginUserWebAuthnLogin(c, service.BeginUserWebAuthnLoginOptions{
+		EmailAddress: body.Email,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to begin webauthn login", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to begin webauthn login", Details: err}
+	}
+
+	logger.Info("successfully began webauthn login")
+	return beginWebAuthnLoginResponse{
+		PublicKey:     output.Options,
+		CeremonyToken: output.CeremonyToken,
+	}, nil
+}
+
+type finishWebAuthnLoginRequestBody struct {
+	CeremonyToken string                      `json:"ceremonyToken" binding:"required"`
+	Credential    *webauthn.AssertionResponse `json:"credential" binding:"required"`
+}
+
+func (r *userRoutes) finishWebAuthnLogin(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("finishWebAuthnLogin").WithContext(c)
+
+	var body finishWebAuthnLoginRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Info("failed to parse body", "err", err)
+		return nil, &httpErr{Type: httpErrTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger.Debug("parsed request body")
+
+	output, err := r.services.User.FinishUserWebAuthnLogin(c, service.FinishUserWebAuthnLoginOptions{
+		CeremonyToken: body.CeremonyToken,
+		Response:      body.Credential,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: httpErrTypeClient, Message: err.Error(), Code: errs.GetCode(err)}
+		}
+
+		logger.Error("failed to login user with webauthn", "err", err)
+		return nil, &httpErr{Type: httpErrTypeServer, Message: "failed to login user with webauthn", Details: err}
+	}
+
+	logger.Info("successfully logged in user with webauthn")
+	return loginUserResponse{
+		AccessToken:  output.AccessToken,
+		RefreshToken: output.RefreshToken,
+		UserID:       output.UserID,
 	}, nil
 }
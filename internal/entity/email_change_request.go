@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// EmailChangeRequest represents the pending or completed change of the user email address.
+// The change is confirmed with the token sent to the new address and can be undone with the token sent to the old one.
+// Only hashes of the tokens are stored.
+type EmailChangeRequest struct {
+	ID string `json:"id,omitempty" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+
+	UserID string `json:"userId,omitempty" gorm:"type:uuid;index"`
+	// TenantID is the ID of the organization of the user, the email is changed within it.
+	TenantID        string `json:"tenantId,omitempty" gorm:"type:uuid;not null;default:'00000000-0000-0000-0000-000000000001'"`
+	OldEmailAddress string `json:"oldEmailAddress,omitempty"`
+	NewEmailAddress string `json:"newEmailAddress,omitempty"`
+
+	ConfirmTokenHash string    `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt        time.Time `json:"expiresAt,omitempty"`
+	UndoTokenHash    string    `json:"-" gorm:"uniqueIndex"`
+	UndoExpiresAt    time.Time `json:"undoExpiresAt,omitempty"`
+
+	ConfirmedAt *time.Time `json:"confirmedAt,omitempty"`
+	CancelledAt *time.Time `json:"cancelledAt,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+} // @name EmailChangeRequest
+
+// IsConfirmable returns true if request is neither confirmed, cancelled nor expired.
+func (r *EmailChangeRequest) IsConfirmable(now time.Time) bool {
+	return r.ConfirmedAt == nil && r.CancelledAt == nil && now.Before(r.ExpiresAt)
+}
+
+// IsUndoable returns true if request is not cancelled and undo link is not expired.
+func (r *EmailChangeRequest) IsUndoable(now time.Time) bool {
+	return r.CancelledAt == nil && now.Before(r.UndoExpiresAt)
+}
@@ -0,0 +1,45 @@
+package entity
+
+import "time"
+
+// Group represents the named set of users and other groups within the organization. Roles granted to the group
+// apply to its effective members, i.e. direct members and members of its subgroups at any depth.
+type Group struct {
+	ID string `json:"id,omitempty" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+
+	OrganizationID string `json:"organizationId,omitempty" gorm:"type:uuid;not null;uniqueIndex:idx_groups_organization_name"`
+	Name           string `json:"name,omitempty" gorm:"uniqueIndex:idx_groups_organization_name"`
+	Description    string `json:"description,omitempty"`
+
+	// UserIDs, SubgroupIDs and RoleIDs are direct members and granted roles, they are set when group is got by ID.
+	UserIDs     []string `json:"userIds,omitempty" gorm:"-"`
+	SubgroupIDs []string `json:"subgroupIds,omitempty" gorm:"-"`
+	RoleIDs     []string `json:"roleIds,omitempty" gorm:"-"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+} // @name Group
+
+// GroupMember represents the user being a direct member of the group.
+type GroupMember struct {
+	GroupID string `json:"groupId,omitempty" gorm:"type:uuid;primaryKey"`
+	UserID  string `json:"userId,omitempty" gorm:"type:uuid;primaryKey;index"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+} // @name GroupMember
+
+// GroupSubgroup represents the group nested into another one, members of the subgroup are members of the parent.
+type GroupSubgroup struct {
+	GroupID    string `json:"groupId,omitempty" gorm:"type:uuid;primaryKey"`
+	SubgroupID string `json:"subgroupId,omitempty" gorm:"type:uuid;primaryKey;index"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+} // @name GroupSubgroup
+
+// GroupRole represents the role granted to all effective members of the group.
+type GroupRole struct {
+	GroupID string `json:"groupId,omitempty" gorm:"type:uuid;primaryKey"`
+	RoleID  string `json:"roleId,omitempty" gorm:"type:uuid;primaryKey;index"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+} // @name GroupRole
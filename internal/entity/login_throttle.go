@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// LoginThrottle represents the counter of failed login attempts for a key, e.g. user or IP address.
+type LoginThrottle struct {
+	// Key identifies the counter subject, e.g. "user:<id>" or "ip:<address>".
+	Key            string    `json:"key,omitempty" gorm:"primaryKey"`
+	FailedAttempts int       `json:"failedAttempts,omitempty"`
+	LastFailedAt   time.Time `json:"lastFailedAt,omitempty"`
+
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+} // @name LoginThrottle
@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// MFARecoveryCode represents the single-use code used to complete MFA login without authenticator device.
+// Only the hash of the code is stored.
+type MFARecoveryCode struct {
+	ID string `json:"id,omitempty" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+
+	UserID   string     `json:"userId,omitempty" gorm:"type:uuid;index"`
+	CodeHash string     `json:"-"`
+	UsedAt   *time.Time `json:"usedAt,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+} // @name MFARecoveryCode
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// OneTimeCode represents the short numeric code sent to the user phone. Only the hash of the code is stored.
+type OneTimeCode struct {
+	ID string `json:"id,omitempty" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+
+	UserID string `json:"userId,omitempty" gorm:"type:uuid;index"`
+	// Purpose is the action code was issued for (e.g. phone verification or login).
+	Purpose string `json:"purpose,omitempty"`
+	// Phone is the phone number code was sent to.
+	Phone    string `json:"phone,omitempty"`
+	CodeHash string `json:"-"`
+	// Attempts is the number of verification attempts made with the code.
+	Attempts  int        `json:"attempts"`
+	ExpiresAt time.Time  `json:"expiresAt,omitempty" gorm:"index"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+} // @name OneTimeCode
+
+// IsUsable returns true if code is neither used nor expired and has attempts left.
+func (c *OneTimeCode) IsUsable(now time.Time, maxAttempts int) bool {
+	return c.UsedAt == nil && now.Before(c.ExpiresAt) && c.Attempts < maxAttempts
+}
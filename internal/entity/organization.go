@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// DefaultOrganizationID is the ID of the organization users belong to unless request is scoped to another one.
+const DefaultOrganizationID = "00000000-0000-0000-0000-000000000001"
+
+// Organization represents the tenant, users of different organizations are fully separated.
+type Organization struct {
+	ID string `json:"id,omitempty" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+
+	Name string `json:"name,omitempty"`
+	// Slug is the subdomain the organization is resolved from.
+	Slug string `json:"slug,omitempty" gorm:"uniqueIndex"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+} // @name Organization
+
+// Membership represents the user belonging to the organization other than the one user registered in,
+// or the role user has in the organization.
+type Membership struct {
+	OrganizationID string `json:"organizationId,omitempty" gorm:"type:uuid;primaryKey"`
+	UserID         string `json:"userId,omitempty" gorm:"type:uuid;primaryKey;index"`
+	// RoleID is the role granting permissions within the organization only.
+	RoleID *string `json:"roleId,omitempty" gorm:"type:uuid"`
+
+	// User is set when members are listed.
+	User *User `json:"user,omitempty" gorm:"-"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+} // @name Membership
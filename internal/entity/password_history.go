@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// PasswordHistory represents the hash of a password previously set by the user.
+type PasswordHistory struct {
+	ID string `json:"id,omitempty" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+
+	UserID   string `json:"userId,omitempty" gorm:"type:uuid;index"`
+	Password string `json:"-"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty" gorm:"index"`
+} // @name PasswordHistory
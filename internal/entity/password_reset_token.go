@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// PasswordResetToken represents the single-use token used to reset forgotten user password.
+// Only the hash of the token is stored.
+type PasswordResetToken struct {
+	ID string `json:"id,omitempty" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+
+	UserID string `json:"userId,omitempty" gorm:"type:uuid;index"`
+	// TenantID is the ID of the organization of the user, the password is reset within it.
+	TenantID  string     `json:"tenantId,omitempty" gorm:"type:uuid;not null;default:'00000000-0000-0000-0000-000000000001'"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt time.Time  `json:"expiresAt,omitempty" gorm:"index"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+} // @name PasswordResetToken
+
+// IsUsable returns true if token is neither used nor expired.
+func (t *PasswordResetToken) IsUsable(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
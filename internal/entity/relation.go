@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// RelationTuple is the stored fact that the subject has the relation to the object within the organization.
+// Tuples are not updated: deletion sets DeletedRevision, so reads at earlier revisions still see the tuple.
+type RelationTuple struct {
+	ID string `json:"id,omitempty" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+
+	OrganizationID string `json:"organizationId,omitempty" gorm:"type:uuid;not null;index:idx_relation_tuples_object;uniqueIndex:idx_relation_tuples_live,where:deleted_revision IS NULL"`
+	Namespace      string `json:"namespace,omitempty" gorm:"index:idx_relation_tuples_object;uniqueIndex:idx_relation_tuples_live"`
+	ObjectID       string `json:"objectId,omitempty" gorm:"index:idx_relation_tuples_object;uniqueIndex:idx_relation_tuples_live"`
+	Relation       string `json:"relation,omitempty" gorm:"index:idx_relation_tuples_object;uniqueIndex:idx_relation_tuples_live"`
+	// SubjectRelation is empty for direct subjects.
+	SubjectNamespace string `json:"subjectNamespace,omitempty" gorm:"uniqueIndex:idx_relation_tuples_live"`
+	SubjectID        string `json:"subjectId,omitempty" gorm:"uniqueIndex:idx_relation_tuples_live"`
+	SubjectRelation  string `json:"subjectRelation,omitempty" gorm:"uniqueIndex:idx_relation_tuples_live"`
+
+	// CreatedRevision and DeletedRevision bound revisions the tuple exists at.
+	CreatedRevision int64  `json:"createdRevision,omitempty" gorm:"not null"`
+	DeletedRevision *int64 `json:"deletedRevision,omitempty" gorm:"index"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+} // @name RelationTuple
+
+// RelationTupleRevision is created by every write of relation tuples of the organization.
+// Revisions grow in the order writes are committed, consistency tokens refer to them.
+type RelationTupleRevision struct {
+	Revision       int64  `json:"revision,omitempty" gorm:"primaryKey;autoIncrement"`
+	OrganizationID string `json:"organizationId,omitempty" gorm:"type:uuid;not null;index"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+} // @name RelationTupleRevision
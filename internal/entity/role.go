@@ -0,0 +1,50 @@
+package entity
+
+import "time"
+
+// Permissions checked by the service and HTTP middleware.
+const (
+	PermissionUsersRead   = "users:read"
+	PermissionUsersUpdate = "users:update"
+	PermissionUsersDelete = "users:delete"
+	PermissionUsersUnlock = "users:unlock"
+	PermissionRolesManage = "roles:manage"
+	// PermissionPoliciesEvaluate allows to view access policies and dry-run requests against them.
+	PermissionPoliciesEvaluate = "policies:evaluate"
+	// PermissionOrganizationsManage allows to create organizations.
+	PermissionOrganizationsManage = "organizations:manage"
+	// PermissionMembersManage allows to invite, list and remove organization members.
+	PermissionMembersManage = "members:manage"
+	// PermissionGroupsManage allows to create groups and manage their members.
+	PermissionGroupsManage = "groups:manage"
+	// PermissionRelationsRead allows to check and expand relations of any subject.
+	PermissionRelationsRead = "relations:read"
+	// PermissionRelationsManage allows to write relation tuples.
+	PermissionRelationsManage = "relations:manage"
+)
+
+// Permission represents the right to perform a kind of operation.
+type Permission struct {
+	Name        string `json:"name,omitempty" gorm:"primaryKey"`
+	Description string `json:"description,omitempty"`
+} // @name Permission
+
+// Role represents the named set of permissions assigned to users.
+type Role struct {
+	ID string `json:"id,omitempty" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+
+	Name        string       `json:"name,omitempty" gorm:"uniqueIndex"`
+	Description string       `json:"description,omitempty"`
+	Permissions []Permission `json:"permissions,omitempty" gorm:"many2many:role_permissions"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+} // @name Role
+
+// UserRole represents the role assigned to the user.
+type UserRole struct {
+	UserID string `json:"userId,omitempty" gorm:"type:uuid;primaryKey"`
+	RoleID string `json:"roleId,omitempty" gorm:"type:uuid;primaryKey;index"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+} // @name UserRole
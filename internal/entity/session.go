@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// Session represents the user authentication session. Every pair of access and refresh tokens
+// is issued within a session, so revoking the session invalidates its tokens.
+type Session struct {
+	ID string `json:"id,omitempty" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+
+	UserID    string     `json:"userId,omitempty" gorm:"type:uuid;index"`
+	ExpiresAt time.Time  `json:"expiresAt,omitempty" gorm:"index"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty" gorm:"index"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+} // @name Session
+
+// IsActive returns true if session is neither revoked nor expired.
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// UsedToken represents the single-use token which has been already used. Tokens are stateless,
+// so the record is kept until token expiration to reject its reuse.
+type UsedToken struct {
+	// ID is the token ID from the token claims.
+	ID string `json:"id,omitempty" gorm:"primaryKey"`
+	// UserID is the ID of the token owner, empty for tokens issued before the user is known.
+	UserID    string    `json:"userId,omitempty" gorm:"index"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty" gorm:"index"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+} // @name UsedToken
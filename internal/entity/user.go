@@ -9,6 +9,8 @@ import (
 // User represents the user model stored in the database.
 type User struct {
 	ID string `json:"id,omitempty" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" binding:"required"`
+	// TenantID is the ID of the organization user belongs to. Email addresses are unique within the organization.
+	TenantID string `json:"tenantId,omitempty" gorm:"type:uuid;not null;default:'00000000-0000-0000-0000-000000000001';index"`
 
 	Name         string `json:"name,omitempty"`
 	Surname      string `json:"surname,omitempty"`
@@ -16,7 +18,57 @@ type User struct {
 	EmailAddress string `json:"emailAddress,omitempty"`
 	Password     string `json:"-"`
 
+	// IsAdmin allows the user to manage other users.
+	IsAdmin bool `json:"isAdmin,omitempty"`
+
+	// EmailVerifiedAt is set when user follows the verification link sent to the email. It is reset on email change.
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt,omitempty"`
+	// EmailVerificationSentAt is the time the last verification email was sent, used to limit resends.
+	EmailVerificationSentAt *time.Time `json:"-"`
+	// MagicLinkSentAt is the time the last magic link was sent, used to limit resends.
+	MagicLinkSentAt *time.Time `json:"-"`
+	// RegistrationNoticeSentAt is the time the user was last notified about registration with the user email,
+	// used to limit the notices.
+	RegistrationNoticeSentAt *time.Time `json:"-"`
+	// PhoneVerifiedAt is set when user confirms the phone with the code sent to it. It is reset on phone change.
+	PhoneVerifiedAt *time.Time `json:"phoneVerifiedAt,omitempty"`
+
+	// TOTPSecret is the encrypted TOTP secret generated on MFA enrollment.
+	TOTPSecret string `json:"-"`
+	// TOTPLastUsedStep is the time step of the last accepted TOTP code, used to reject replayed codes.
+	TOTPLastUsedStep int64      `json:"-"`
+	MFAEnabledAt     *time.Time `json:"mfaEnabledAt,omitempty"`
+
 	CreatedAt time.Time      `json:"createdAt,omitempty" gorm:"index"`
 	UpdatedAt time.Time      `json:"updatedAt,omitempty"`
 	DeletedAt gorm.DeletedAt `json:"deletedAt,omitempty" gorm:"index" swaggerignore:"true"`
+	// DeletedBy is the ID of the user who deleted the account, the user itself or an administrator.
+	DeletedBy *string `json:"deletedBy,omitempty" gorm:"type:uuid"`
 } // @name User
+
+// UserStatus is the state of the user account users can be filtered by.
+type UserStatus string
+
+const (
+	// UserStatusActive is the user with verified email who is not locked out.
+	UserStatusActive UserStatus = "active"
+	// UserStatusUnverified is the user who has not verified the email yet.
+	UserStatusUnverified UserStatus = "unverified"
+	// UserStatusLocked is the user locked out after too many failed logins.
+	UserStatusLocked UserStatus = "locked"
+)
+
+// IsDeletedBySelf returns true if the user deleted the account.
+func (u *User) IsDeletedBySelf() bool {
+	return u.DeletedBy != nil && *u.DeletedBy == u.ID
+}
+
+// IsEmailVerified returns true if user has verified the email address.
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsMFAEnabled returns true if user has confirmed MFA enrollment.
+func (u *User) IsMFAEnabled() bool {
+	return u.MFAEnabledAt != nil
+}
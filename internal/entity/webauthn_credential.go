@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// WebAuthnCredential represents the public key credential (passkey or security key) registered by user.
+type WebAuthnCredential struct {
+	ID string `json:"id,omitempty" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+
+	UserID string `json:"userId,omitempty" gorm:"type:uuid;index"`
+	// Name is the user given name of the credential (e.g. "Work laptop").
+	Name string `json:"name,omitempty"`
+	// CredentialID is the ID generated by authenticator.
+	CredentialID []byte `json:"credentialId,omitempty" gorm:"uniqueIndex"`
+	// PublicKey is the COSE encoded credential public key.
+	PublicKey []byte `json:"-"`
+	// SignCount is the last signature counter reported by authenticator.
+	SignCount  uint32     `json:"-"`
+	AAGUID     []byte     `json:"aaguid,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+} // @name WebAuthnCredential
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func (s *userService) PurgeExpiredAuthData(ctx context.Context) error {
+	logger := s.logger.
+		Named("PurgeExpiredAuthData").
+		WithContext(ctx)
+
+	now := time.Now()
+
+	sessionsCount, err := s.storages.Session.DeleteExpiredSessions(ctx, now)
+	if err != nil {
+		logger.Error("failed to delete expired sessions", "err", err)
+		return fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	passwordResetTokensCount, err := s.storages.PasswordReset.DeleteExpiredPasswordResetTokens(ctx, now)
+	if err != nil {
+		logger.Error("failed to delete expired password reset tokens", "err", err)
+		return fmt.Errorf("failed to delete expired password reset tokens: %w", err)
+	}
+
+	usedTokensCount, err := s.storages.UsedToken.DeleteExpiredUsedTokens(ctx, now)
+	if err != nil {
+		logger.Error("failed to delete expired used tokens", "err", err)
+		return fmt.Errorf("failed to delete expired used tokens: %w", err)
+	}
+
+	oneTimeCodesCount, err := s.storages.OneTimeCode.DeleteExpiredOneTimeCodes(ctx, now)
+	if err != nil {
+		logger.Error("failed to delete expired one-time codes", "err", err)
+		return fmt.Errorf("failed to delete expired one-time codes: %w", err)
+	}
+
+	emailChangeRequestsCount, err := s.storages.EmailChangeRequest.DeleteExpiredEmailChangeRequests(ctx, now)
+	if err != nil {
+		logger.Error("failed to delete expired email change requests", "err", err)
+		return fmt.Errorf("failed to delete expired email change requests: %w", err)
+	}
+
+	// Counters older than the attempts window are ignored by getLoginWait anyway
+	loginThrottlesCount, err := s.storages.LoginThrottle.DeleteStaleLoginThrottles(ctx, now.Add(-s.cfg.Lockout.AttemptsWindow))
+	if err != nil {
+		logger.Error("failed to delete stale login throttles", "err", err)
+		return fmt.Errorf("failed to delete stale login throttles: %w", err)
+	}
+
+	logger.Info("successfully purged expired auth data",
+		"sessions", sessionsCount,
+		"passwordResetTokens", passwordResetTokensCount,
+		"usedTokens", usedTokensCount,
+		"oneTimeCodes", oneTimeCodesCount,
+		"emailChangeRequests", emailChangeRequestsCount,
+		"loginThrottles", loginThrottlesCount,
+	)
+	return nil
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/taraslis453/solid-software-test/pkg/errs"
+	"github.com/taraslis453/solid-software-test/pkg/mailer"
+	"github.com/taraslis453/solid-software-test/pkg/token"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+)
+
+func (s *userService) RequestUserEmailChange(ctx context.Context, opts RequestUserEmailChangeOptions) error {
+	logger := s.logger.
+		Named("RequestUserEmailChange").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := s.authorizeUserAccess(ctx, opts.UserID, entity.PermissionUsersUpdate)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("access is forbidden")
+			return err
+		}
+		logger.Error("failed to authorize user access", "err", err)
+		return fmt.Errorf("failed to authorize user access: %w", err)
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &opts.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return ErrRequestUserEmailChangeUserNotFound
+	}
+	logger.Debug("got user")
+
+	newEmailAddress := strings.TrimSpace(opts.NewEmailAddress)
+	if strings.EqualFold(newEmailAddress, user.EmailAddress) {
+		logger.Info("email is not changed")
+		return ErrRequestUserEmailChangeSameEmail
+	}
+
+	// Uniqueness is checked again on confirmation, this check only prevents sending useless emails
+	emailOwner, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		EmailAddress: &newEmailAddress,
+	})
+	if err != nil {
+		logger.Error("failed to get email owner", "err", err)
+		return fmt.Errorf("failed to get email owner: %w", err)
+	}
+	if emailOwner != nil {
+		logger.Info("email is used by another user")
+		return ErrRequestUserEmailChangeEmailAlreadyUsed
+	}
+
+	// Only the latest requested change can be confirmed
+	err = s.storages.EmailChangeRequest.CancelPendingEmailChangeRequests(ctx, user.ID)
+	if err != nil {
+		logger.Error("failed to cancel pending email change requests", "err", err)
+		return fmt.Errorf("failed to cancel pending email change requests: %w", err)
+	}
+
+	confirmToken, err := token.GenerateRandomToken(emailChangeTokenLength)
+	if err != nil {
+		logger.Error("failed to generate confirm token", "err", err)
+		return fmt.Errorf("failed to generate confirm token: %w", err)
+	}
+	undoToken, err := token.GenerateRandomToken(emailChangeTokenLength)
+	if err != nil {
+		logger.Error("failed to generate undo token", "err", err)
+		return fmt.Errorf("failed to generate undo token: %w", err)
+	}
+
+	now := time.Now()
+	_, err = s.storages.EmailChangeRequest.CreateEmailChangeRequest(ctx, &entity.EmailChangeRequest{
+		UserID:           user.ID,
+		TenantID:         user.TenantID,
+		OldEmailAddress:  user.EmailAddress,
+		NewEmailAddress:  newEmailAddress,
+		ConfirmTokenHash: token.HashToken(confirmToken),
+		ExpiresAt:        now.Add(s.cfg.Auth.EmailChangeTokenLifetime),
+		UndoTokenHash:    token.HashToken(undoToken),
+		UndoExpiresAt:    now.Add(s.cfg.Auth.EmailChangeUndoLifetime),
+	})
+	if err != nil {
+		logger.Error("failed to create email change request", "err", err)
+		return fmt.Errorf("failed to create email change request: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, &mailer.Message{
+		To:      newEmailAddress,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf(
+			"To confirm the change of your account email address follow the link: %s/email/change/confirm?token=%s\nThe link expires in %s. If you did not request the change, ignore this email.",
+			s.cfg.App.FrontendURL, url.QueryEscape(confirmToken), s.cfg.Auth.EmailChangeTokenLifetime,
+		),
+	})
+	if err != nil {
+		logger.Error("failed to send email change confirmation", "err", err)
+		return fmt.Errorf("failed to send email change confirmation: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, &mailer.Message{
+		To:      user.EmailAddress,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf(
+			"The change of your account email address to %s has been requested.\nIf it was not you, follow the link to cancel the change and sign out of all devices: %s/email/change/undo?token=%s\nThe link expires in %s.",
+			newEmailAddress, s.cfg.App.FrontendURL, url.QueryEscape(undoToken), s.cfg.Auth.EmailChangeUndoLifetime,
+		),
+	})
+	if err != nil {
+		logger.Error("failed to send email change notification", "err", err)
+		return fmt.Errorf("failed to send email change notification: %w", err)
+	}
+
+	logger.Info("successfully requested email change")
+	return nil
+}
+
+func (s *userService) ConfirmUserEmailChange(ctx context.Context, opts ConfirmUserEmailChangeOptions) error {
+	logger := s.logger.
+		Named("ConfirmUserEmailChange").
+		WithContext(ctx)
+
+	tokenHash := token.HashToken(opts.Token)
+	request, err := s.storages.EmailChangeRequest.GetEmailChangeRequest(ctx, GetEmailChangeRequestFilter{
+		ConfirmTokenHash: &tokenHash,
+	})
+	if err != nil {
+		logger.Error("failed to get email change request", "err", err)
+		return fmt.Errorf("failed to get email change request: %w", err)
+	}
+	if request == nil || !request.IsConfirmable(time.Now()) {
+		logger.Info("email change request is not confirmable", "request", request)
+		return ErrConfirmUserEmailChangeInvalidToken
+	}
+	logger = logger.With("userID", request.UserID, "requestID", request.ID)
+	ctx = contextWithTokenTenant(ctx, request.TenantID)
+
+	// Mark request as confirmed before the change, so concurrent requests can not use it twice
+	isConfirmed, err := s.storages.EmailChangeRequest.ConfirmEmailChangeRequest(ctx, request.ID)
+	if err != nil {
+		logger.Error("failed to confirm email change request", "err", err)
+		return fmt.Errorf("failed to confirm email change request: %w", err)
+	}
+	if !isConfirmed {
+		logger.Info("email change request has been already confirmed or cancelled")
+		return ErrConfirmUserEmailChangeInvalidToken
+	}
+
+	isChanged, err := s.storages.User.ChangeUserEmail(ctx, request.UserID, request.OldEmailAddress, request.NewEmailAddress)
+	if err != nil {
+		logger.Error("failed to change user email", "err", err)
+		return fmt.Errorf("failed to change user email: %w", err)
+	}
+	if !isChanged {
+		// Request can not be undone, since the email has not been changed
+		_, err = s.storages.EmailChangeRequest.CancelEmailChangeRequest(ctx, request.ID)
+		if err != nil {
+			logger.Error("failed to cancel email change request", "err", err)
+			return fmt.Errorf("failed to cancel email change request: %w", err)
+		}
+
+		logger.Info("email is used by another user or user email has been changed")
+		return ErrConfirmUserEmailChangeEmailAlreadyUsed
+	}
+
+	logger.Info("successfully changed user email")
+	return nil
+}
+
+func (s *userService) UndoUserEmailChange(ctx context.Context, opts UndoUserEmailChangeOptions) error {
+	logger := s.logger.
+		Named("UndoUserEmailChange").
+		WithContext(ctx)
+
+	tokenHash := token.HashToken(opts.Token)
+	request, err := s.storages.EmailChangeRequest.GetEmailChangeRequest(ctx, GetEmailChangeRequestFilter{
+		UndoTokenHash: &tokenHash,
+	})
+	if err != nil {
+		logger.Error("failed to get email change request", "err", err)
+		return fmt.Errorf("failed to get email change request: %w", err)
+	}
+	if request == nil || !request.IsUndoable(time.Now()) {
+		logger.Info("email change request is not undoable", "request", request)
+		return ErrUndoUserEmailChangeInvalidToken
+	}
+	logger = logger.With("userID", request.UserID, "requestID", request.ID)
+	ctx = contextWithTokenTenant(ctx, request.TenantID)
+
+	isCancelled, err := s.storages.EmailChangeRequest.CancelEmailChangeRequest(ctx, request.ID)
+	if err != nil {
+		logger.Error("failed to cancel email change request", "err", err)
+		return fmt.Errorf("failed to cancel email change request: %w", err)
+	}
+	if !isCancelled {
+		logger.Info("email change request has been already cancelled")
+		return ErrUndoUserEmailChangeInvalidToken
+	}
+
+	// Confirmed change is reverted, pending one is just cancelled above
+	if request.ConfirmedAt != nil {
+		isChanged, err := s.storages.User.ChangeUserEmail(ctx, request.UserID, request.NewEmailAddress, request.OldEmailAddress)
+		if err != nil {
+			logger.Error("failed to change user email", "err", err)
+			return fmt.Errorf("failed to change user email: %w", err)
+		}
+		if !isChanged {
+			logger.Info("old email is used by another user or user email has been changed")
+			return ErrUndoUserEmailChangeEmailAlreadyUsed
+		}
+	}
+
+	// Whoever requested the change may have access to the account
+	err = s.storages.Session.RevokeSessions(ctx, RevokeSessionsFilter{
+		UserID: request.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to revoke sessions", "err", err)
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	logger.Info("successfully undone email change")
+	return nil
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/taraslis453/solid-software-test/config"
+	"github.com/taraslis453/solid-software-test/pkg/mailer"
+	"github.com/taraslis453/solid-software-test/pkg/token"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+)
+
+func (s *userService) VerifyUserEmail(ctx context.Context, opts VerifyUserEmailOptions) error {
+	logger := s.logger.
+		Named("VerifyUserEmail").
+		WithContext(ctx)
+
+	claims, err := s.verifyPurposeToken(opts.Token, emailVerificationTokenPurpose)
+	if err != nil {
+		logger.Info("invalid email verification token", "err", err)
+		return ErrVerifyUserEmailInvalidToken
+	}
+	logger = logger.With("userID", claims.UserID)
+	ctx = contextWithTokenTenant(ctx, claims.TenantID)
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &claims.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	// Token sent to the previous email address does not verify the current one
+	if user == nil || user.EmailAddress != claims.Email {
+		logger.Info("user not found or email has been changed")
+		return ErrVerifyUserEmailInvalidToken
+	}
+	logger.Debug("got user")
+
+	if user.IsEmailVerified() {
+		logger.Info("email is already verified")
+		return nil
+	}
+
+	err = s.markUserEmailVerified(ctx, user)
+	if err != nil {
+		logger.Error("failed to mark user email verified", "err", err)
+		return fmt.Errorf("failed to mark user email verified: %w", err)
+	}
+
+	logger.Info("successfully verified user email")
+	return nil
+}
+
+func (s *userService) ResendUserEmailVerification(ctx context.Context, opts ResendUserEmailVerificationOptions) error {
+	logger := s.logger.
+		Named("ResendUserEmailVerification").
+		WithContext(ctx).
+		With("opts", opts)
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		EmailAddress: &opts.EmailAddress,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	// Neither missing user, nor verified email, nor cooldown is reported to prevent email enumeration
+	if user == nil {
+		logger.Info("user not found")
+		return nil
+	}
+	logger = logger.With("userID", user.ID)
+	if user.IsEmailVerified() {
+		logger.Info("email is already verified")
+		return nil
+	}
+	if user.EmailVerificationSentAt != nil && time.Since(*user.EmailVerificationSentAt) < s.cfg.EmailVerification.ResendCooldown {
+		logger.Info("verification email has been sent recently")
+		return nil
+	}
+
+	err = s.sendEmailVerification(ctx, user)
+	if err != nil {
+		logger.Error("failed to send verification email", "err", err)
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	logger.Info("successfully resent verification email")
+	return nil
+}
+
+// sendEmailVerification emails the verification link to the user and remembers when it was sent.
+func (s *userService) sendEmailVerification(ctx context.Context, user *entity.User) error {
+	verificationToken, err := s.signPurposeToken(token.PurposeClaims{
+		Purpose:  emailVerificationTokenPurpose,
+		UserID:   user.ID,
+		Email:    user.EmailAddress,
+		TenantID: user.TenantID,
+	}, s.cfg.EmailVerification.TokenLifetime)
+	if err != nil {
+		return fmt.Errorf("failed to sign email verification token: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, &mailer.Message{
+		To:      user.EmailAddress,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"To verify your email address submit the verification token: %s\nThe token expires in %s. If you did not register, ignore this email.",
+			verificationToken, s.cfg.EmailVerification.TokenLifetime,
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	now := time.Now()
+	_, err = s.storages.User.UpdateUser(ctx, user.ID, &entity.User{
+		EmailVerificationSentAt: &now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
+
+// markUserEmailVerified sets the user email verification time in storage and in the passed user.
+func (s *userService) markUserEmailVerified(ctx context.Context, user *entity.User) error {
+	now := time.Now()
+	_, err := s.storages.User.UpdateUser(ctx, user.ID, &entity.User{
+		EmailVerifiedAt: &now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	user.EmailVerifiedAt = &now
+	return nil
+}
+
+// isLoginBlockedByEmailVerification returns true if user can not login until the email is verified.
+func (s *userService) isLoginBlockedByEmailVerification(user *entity.User) bool {
+	return s.cfg.EmailVerification.Enforcement == config.EmailVerificationEnforcementLogin && !user.IsEmailVerified()
+}
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/pkg/errs"
+)
+
+var _ GroupService = (*groupService)(nil)
+
+type groupService struct {
+	serviceContext
+}
+
+func NewGroupService(options Options) *groupService {
+	return &groupService{
+		serviceContext: serviceContext{
+			storages:     options.Storages,
+			cfg:          options.Config,
+			logger:       options.Logger.Named("groupService"),
+			policyEngine: options.PolicyEngine,
+		},
+	}
+}
+
+func (s *groupService) ListGroups(ctx context.Context) ([]entity.Group, error) {
+	logger := s.logger.
+		Named("ListGroups").
+		WithContext(ctx)
+
+	err := authorizePermission(ctx, entity.PermissionGroupsManage)
+	if err != nil {
+		logger.Info("principal is not allowed to list groups")
+		return nil, err
+	}
+
+	groups, err := s.storages.Group.ListGroups(ctx)
+	if err != nil {
+		logger.Error("failed to list groups", "err", err)
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	logger.Info("successfully listed groups", "count", len(groups))
+	return groups, nil
+}
+
+func (s *groupService) GetGroup(ctx context.Context, id string) (*entity.Group, error) {
+	logger := s.logger.
+		Named("GetGroup").
+		WithContext(ctx).
+		With("id", id)
+
+	err := authorizePermission(ctx, entity.PermissionGroupsManage)
+	if err != nil {
+		logger.Info("principal is not allowed to get groups")
+		return nil, err
+	}
+
+	group, err := s.getGroup(ctx, id)
+	if err != nil {
+		logger.Error("failed to get group", "err", err)
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		logger.Info("group not found")
+		return nil, ErrGetGroupGroupNotFound
+	}
+
+	logger.Info("successfully got group")
+	return group, nil
+}
+
+func (s *groupService) CreateGroup(ctx context.Context, opts CreateGroupOptions) (*entity.Group, error) {
+	logger := s.logger.
+		Named("CreateGroup").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionGroupsManage)
+	if err != nil {
+		logger.Info("principal is not allowed to create groups")
+		return nil, err
+	}
+
+	existingGroup, err := s.storages.Group.GetGroup(ctx, GetGroupFilter{
+		Name: &opts.Name,
+	})
+	if err != nil {
+		logger.Error("failed to get group", "err", err)
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	if existingGroup != nil {
+		logger.Info("group already exists")
+		return nil, ErrCreateGroupGroupAlreadyExists
+	}
+
+	group, err := s.storages.Group.CreateGroup(ctx, &entity.Group{
+		Name:        opts.Name,
+		Description: opts.Description,
+	})
+	if err != nil {
+		logger.Error("failed to create group", "err", err)
+		return nil, fmt.Errorf("failed to create group: %w", err)
+	}
+
+	logger.Info("successfully created group", "groupID", group.ID)
+	return group, nil
+}
+
+func (s *groupService) DeleteGroup(ctx context.Context, id string) error {
+	logger := s.logger.
+		Named("DeleteGroup").
+		WithContext(ctx).
+		With("id", id)
+
+	err := authorizePermission(ctx, entity.PermissionGroupsManage)
+	if err != nil {
+		logger.Info("principal is not allowed to delete groups")
+		return err
+	}
+
+	group, err := s.getGroup(ctx, id)
+	if err != nil {
+		logger.Error("failed to get group", "err", err)
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		logger.Info("group not found")
+		return ErrDeleteGroupGroupNotFound
+	}
+
+	err = s.storages.Group.DeleteGroup(ctx, group.ID)
+	if err != nil {
+		logger.Error("failed to delete group", "err", err)
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+
+	logger.Info("successfully deleted group")
+	return nil
+}
+
+func (s *groupService) AddGroupMember(ctx context.Context, opts AddGroupMemberOptions) error {
+	logger := s.logger.
+		Named("AddGroupMember").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionGroupsManage)
+	if err != nil {
+		logger.Info("principal is not allowed to add group members")
+		return err
+	}
+
+	group, err := s.getGroup(ctx, opts.GroupID)
+	if err != nil {
+		logger.Error("failed to get group", "err", err)
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		logger.Info("group not found")
+		return ErrAddGroupMemberGroupNotFound
+	}
+
+	// Users storage is scoped to the organization, so only its members can be added
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &opts.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return ErrAddGroupMemberUserNotFound
+	}
+
+	err = s.storages.Group.AddGroupMember(ctx, group.ID, user.ID)
+	if err != nil {
+		logger.Error("failed to add group member", "err", err)
+		return fmt.Errorf("failed to add group member: %w", err)
+	}
+
+	logger.Info("successfully added group member")
+	return nil
+}
+
+func (s *groupService) RemoveGroupMember(ctx context.Context, opts RemoveGroupMemberOptions) error {
+	logger := s.logger.
+		Named("RemoveGroupMember").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionGroupsManage)
+	if err != nil {
+		logger.Info("principal is not allowed to remove group members")
+		return err
+	}
+
+	group, err := s.getGroup(ctx, opts.GroupID)
+	if err != nil {
+		logger.Error("failed to get group", "err", err)
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		logger.Info("group not found")
+		return ErrRemoveGroupMemberGroupNotFound
+	}
+
+	err = s.storages.Group.RemoveGroupMember(ctx, group.ID, opts.UserID)
+	if err != nil {
+		logger.Error("failed to remove group member", "err", err)
+		return fmt.Errorf("failed to remove group member: %w", err)
+	}
+
+	logger.Info("successfully removed group member")
+	return nil
+}
+
+func (s *groupService) AddGroupSubgroup(ctx context.Context, opts AddGroupSubgroupOptions) error {
+	logger := s.logger.
+		Named("AddGroupSubgroup").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionGroupsManage)
+	if err != nil {
+		logger.Info("principal is not allowed to nest groups")
+		return err
+	}
+
+	group, err := s.getGroup(ctx, opts.GroupID)
+	if err != nil {
+		logger.Error("failed to get group", "err", err)
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		logger.Info("group not found")
+		return ErrAddGroupSubgroupGroupNotFound
+	}
+
+	subgroup, err := s.getGroup(ctx, opts.SubgroupID)
+	if err != nil {
+		logger.Error("failed to get subgroup", "err", err)
+		return fmt.Errorf("failed to get subgroup: %w", err)
+	}
+	if subgroup == nil {
+		logger.Info("subgroup not found")
+		return ErrAddGroupSubgroupSubgroupNotFound
+	}
+
+	isAdded, err := s.storages.Group.AddGroupSubgroup(ctx, group.ID, subgroup.ID)
+	if err != nil {
+		logger.Error("failed to add group subgroup", "err", err)
+		return fmt.Errorf("failed to add group subgroup: %w", err)
+	}
+	if !isAdded {
+		logger.Info("nesting would form a cycle")
+		return ErrAddGroupSubgroupCycle
+	}
+
+	logger.Info("successfully added group subgroup")
+	return nil
+}
+
+func (s *groupService) RemoveGroupSubgroup(ctx context.Context, opts RemoveGroupSubgroupOptions) error {
+	logger := s.logger.
+		Named("RemoveGroupSubgroup").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionGroupsManage)
+	if err != nil {
+		logger.Info("principal is not allowed to nest groups")
+		return err
+	}
+
+	group, err := s.getGroup(ctx, opts.GroupID)
+	if err != nil {
+		logger.Error("failed to get group", "err", err)
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		logger.Info("group not found")
+		return ErrRemoveGroupSubgroupGroupNotFound
+	}
+
+	err = s.storages.Group.RemoveGroupSubgroup(ctx, group.ID, opts.SubgroupID)
+	if err != nil {
+		logger.Error("failed to remove group subgroup", "err", err)
+		return fmt.Errorf("failed to remove group subgroup: %w", err)
+	}
+
+	logger.Info("successfully removed group subgroup")
+	return nil
+}
+
+func (s *groupService) AssignGroupRole(ctx context.Context, opts AssignGroupRoleOptions) error {
+	logger := s.logger.
+		Named("AssignGroupRole").
+		WithContext(ctx).
+		With("opts", opts)
+
+	// Granting roles is as sensitive for groups as it is for users
+	err := authorizePermission(ctx, entity.PermissionRolesManage)
+	if err != nil {
+		logger.Info("principal is not allowed to assign roles")
+		return err
+	}
+
+	group, err := s.getGroup(ctx, opts.GroupID)
+	if err != nil {
+		logger.Error("failed to get group", "err", err)
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		logger.Info("group not found")
+		return ErrAssignGroupRoleGroupNotFound
+	}
+
+	role, err := s.storages.Role.GetRole(ctx, GetRoleFilter{
+		ID: &opts.RoleID,
+	})
+	if err != nil {
+		logger.Error("failed to get role", "err", err)
+		return fmt.Errorf("failed to get role: %w", err)
+	}
+	if role == nil {
+		logger.Info("role not found")
+		return ErrAssignGroupRoleRoleNotFound
+	}
+
+	err = s.storages.Group.AssignGroupRole(ctx, group.ID, role.ID)
+	if err != nil {
+		logger.Error("failed to assign group role", "err", err)
+		return fmt.Errorf("failed to assign group role: %w", err)
+	}
+
+	logger.Info("successfully assigned group role")
+	return nil
+}
+
+func (s *groupService) UnassignGroupRole(ctx context.Context, opts UnassignGroupRoleOptions) error {
+	logger := s.logger.
+		Named("UnassignGroupRole").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionRolesManage)
+	if err != nil {
+		logger.Info("principal is not allowed to unassign roles")
+		return err
+	}
+
+	group, err := s.getGroup(ctx, opts.GroupID)
+	if err != nil {
+		logger.Error("failed to get group", "err", err)
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		logger.Info("group not found")
+		return ErrUnassignGroupRoleGroupNotFound
+	}
+
+	err = s.storages.Group.UnassignGroupRole(ctx, group.ID, opts.RoleID)
+	if err != nil {
+		logger.Error("failed to unassign group role", "err", err)
+		return fmt.Errorf("failed to unassign group role: %w", err)
+	}
+
+	logger.Info("successfully unassigned group role")
+	return nil
+}
+
+func (s *groupService) ListUserGroups(ctx context.Context, userID string) ([]entity.Group, error) {
+	logger := s.logger.
+		Named("ListUserGroups").
+		WithContext(ctx).
+		With("userID", userID)
+
+	err := s.authorizeUserAccess(ctx, userID, entity.PermissionUsersRead)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("access is forbidden")
+			return nil, err
+		}
+		logger.Error("failed to authorize user access", "err", err)
+		return nil, fmt.Errorf("failed to authorize user access: %w", err)
+	}
+
+	groups, err := s.storages.Group.ListUserGroups(ctx, userID)
+	if err != nil {
+		logger.Error("failed to list user groups", "err", err)
+		return nil, fmt.Errorf("failed to list user groups: %w", err)
+	}
+
+	logger.Info("successfully listed user groups", "count", len(groups))
+	return groups, nil
+}
+
+// getGroup returns the group of the organization from context or nil if it does not exist.
+func (s *groupService) getGroup(ctx context.Context, id string) (*entity.Group, error) {
+	// Malformed ID can not match any group, and would fail the query
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, nil
+	}
+
+	return s.storages.Group.GetGroup(ctx, GetGroupFilter{
+		ID: &id,
+	})
+}
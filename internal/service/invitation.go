@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/taraslis453/solid-software-test/pkg/errs"
+	"github.com/taraslis453/solid-software-test/pkg/mailer"
+	"github.com/taraslis453/solid-software-test/pkg/password"
+	"github.com/taraslis453/solid-software-test/pkg/token"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+)
+
+func (s *userService) InviteUserToOrganization(ctx context.Context, opts InviteUserToOrganizationOptions) error {
+	logger := s.logger.
+		Named("InviteUserToOrganization").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionMembersManage)
+	if err != nil {
+		logger.Info("principal is not allowed to invite members")
+		return err
+	}
+
+	organizationID := TenantFromContext(ctx)
+	organization, err := s.storages.Organization.GetOrganization(ctx, GetOrganizationFilter{
+		ID: &organizationID,
+	})
+	if err != nil {
+		logger.Error("failed to get organization", "err", err)
+		return fmt.Errorf("failed to get organization: %w", err)
+	}
+	if organization == nil {
+		logger.Error("organization request is scoped to does not exist", "organizationID", organizationID)
+		return fmt.Errorf("organization %s does not exist", organizationID)
+	}
+
+	if opts.RoleID != "" {
+		// Role grants permissions, so granting it requires the same permission as assigning roles
+		err = authorizePermission(ctx, entity.PermissionRolesManage)
+		if err != nil {
+			logger.Info("principal is not allowed to assign roles")
+			return err
+		}
+
+		role, err := s.storages.Role.GetRole(ctx, GetRoleFilter{
+			ID: &opts.RoleID,
+		})
+		if err != nil {
+			logger.Error("failed to get role", "err", err)
+			return fmt.Errorf("failed to get role: %w", err)
+		}
+		if role == nil {
+			logger.Info("role not found")
+			return ErrInviteUserToOrganizationRoleNotFound
+		}
+	}
+
+	invitationToken, err := s.signPurposeToken(token.PurposeClaims{
+		Purpose:  organizationInvitationTokenPurpose,
+		Email:    opts.EmailAddress,
+		TokenID:  uuid.NewString(),
+		TenantID: organization.ID,
+		RoleID:   opts.RoleID,
+	}, s.cfg.Tenancy.InvitationLifetime)
+	if err != nil {
+		logger.Error("failed to sign invitation token", "err", err)
+		return fmt.Errorf("failed to sign invitation token: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, &mailer.Message{
+		To:      opts.EmailAddress,
+		Subject: fmt.Sprintf("Invitation to join %s", organization.Name),
+		Body: fmt.Sprintf(
+			"You are invited to join %s. To accept the invitation follow the link: %s/organizations/invitations/accept?token=%s\nThe invitation expires in %s and can be accepted or declined only once.",
+			organization.Name, s.cfg.App.PublicURL, url.QueryEscape(invitationToken), s.cfg.Tenancy.InvitationLifetime,
+		),
+	})
+	if err != nil {
+		logger.Error("failed to send invitation email", "err", err)
+		return fmt.Errorf("failed to send invitation email: %w", err)
+	}
+
+	logger.Info("successfully sent invitation", "organizationID", organization.ID)
+	return nil
+}
+
+func (s *userService) AcceptOrganizationInvitation(ctx context.Context, opts AcceptOrganizationInvitationOptions) (*entity.Membership, error) {
+	logger := s.logger.
+		Named("AcceptOrganizationInvitation").
+		WithContext(ctx)
+
+	claims, err := s.verifyPurposeToken(opts.Token, organizationInvitationTokenPurpose)
+	if err != nil {
+		logger.Info("invalid invitation token", "err", err)
+		return nil, ErrAcceptOrganizationInvitationInvalidToken
+	}
+	logger = logger.With("organizationID", claims.TenantID, "email", claims.Email)
+
+	principal := PrincipalFromContext(ctx)
+	if principal == nil && opts.Registration == nil {
+		logger.Info("request is not authenticated and has no registration data")
+		return nil, ErrAcceptOrganizationInvitationRegistrationRequired
+	}
+	// Invitation grants access, so it can be accepted only by the owner of the invited email
+	if principal != nil && !strings.EqualFold(principal.EmailAddress, claims.Email) {
+		logger.Info("invitation is sent to another email", "userID", principal.UserID)
+		return nil, ErrAcceptOrganizationInvitationEmailMismatch
+	}
+
+	organizationCtx := ContextWithTenant(ctx, claims.TenantID)
+	existingUser, err := s.storages.User.GetUser(organizationCtx, GetUserFilter{
+		EmailAddress: &claims.Email,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if principal != nil && existingUser != nil && existingUser.ID != principal.UserID {
+		logger.Info("email is already used in organization")
+		return nil, ErrAcceptOrganizationInvitationEmailAlreadyUsed
+	}
+	if principal == nil && existingUser != nil {
+		logger.Info("user already exists")
+		return nil, ErrAcceptOrganizationInvitationUserAlreadyExists
+	}
+
+	// Password is validated before the token is used, so the invitation is not lost because of a weak password
+	if principal == nil {
+		violations, err := s.validatePassword(&password.ValidateOptions{
+			Password:   opts.Registration.Password,
+			UserInputs: []string{opts.Registration.Name, opts.Registration.Surname, getEmailLocalPart(claims.Email)},
+		})
+		if err != nil {
+			logger.Error("failed to validate password", "err", err)
+			return nil, fmt.Errorf("failed to validate password: %w", err)
+		}
+		if len(violations) > 0 {
+			logger.Info("password does not satisfy password policy", "violations", violations)
+			return nil, errs.WithDetails(ErrRegisterUserWeakPassword, violations)
+		}
+	}
+
+	// Token is used before any change, so a replayed or declined invitation does not register the user
+	isFirstUse, err := s.useSingleUseToken(ctx, claims, s.cfg.Tenancy.InvitationLifetime)
+	if err != nil {
+		logger.Error("failed to use invitation token", "err", err)
+		return nil, fmt.Errorf("failed to use invitation token: %w", err)
+	}
+	if !isFirstUse {
+		logger.Info("invitation has been already used")
+		return nil, ErrAcceptOrganizationInvitationInvalidToken
+	}
+
+	var userID string
+	if principal != nil {
+		userID = principal.UserID
+	} else {
+		registration := *opts.Registration
+		registration.EmailAddress = claims.Email
+		err = s.RegisterUser(organizationCtx, registration)
+		if err != nil {
+			if errs.IsExpected(err) {
+				logger.Info("failed to register user", "err", err)
+				return nil, err
+			}
+			logger.Error("failed to register user", "err", err)
+			return nil, fmt.Errorf("failed to register user: %w", err)
+		}
+
+		user, err := s.storages.User.GetUser(organizationCtx, GetUserFilter{
+			EmailAddress: &claims.Email,
+		})
+		if err != nil {
+			logger.Error("failed to get registered user", "err", err)
+			return nil, fmt.Errorf("failed to get registered user: %w", err)
+		}
+		if user == nil {
+			logger.Error("registered user not found")
+			return nil, fmt.Errorf("registered user not found")
+		}
+
+		// Invitation has been delivered to the email, which proves its ownership
+		err = s.markUserEmailVerified(organizationCtx, user)
+		if err != nil {
+			logger.Error("failed to mark user email verified", "err", err)
+			return nil, fmt.Errorf("failed to mark user email verified: %w", err)
+		}
+		userID = user.ID
+	}
+	logger = logger.With("userID", userID)
+
+	membership := &entity.Membership{
+		OrganizationID: claims.TenantID,
+		UserID:         userID,
+	}
+	if claims.RoleID != "" {
+		membership.RoleID = &claims.RoleID
+	}
+	membership, err = s.storages.Membership.UpsertMembership(ctx, membership)
+	if err != nil {
+		logger.Error("failed to upsert membership", "err", err)
+		return nil, fmt.Errorf("failed to upsert membership: %w", err)
+	}
+
+	logger.Info("successfully accepted invitation")
+	return membership, nil
+}
+
+func (s *userService) DeclineOrganizationInvitation(ctx context.Context, opts DeclineOrganizationInvitationOptions) error {
+	logger := s.logger.
+		Named("DeclineOrganizationInvitation").
+		WithContext(ctx)
+
+	claims, err := s.verifyPurposeToken(opts.Token, organizationInvitationTokenPurpose)
+	if err != nil {
+		logger.Info("invalid invitation token", "err", err)
+		return ErrDeclineOrganizationInvitationInvalidToken
+	}
+	logger = logger.With("organizationID", claims.TenantID, "email", claims.Email)
+
+	isFirstUse, err := s.useSingleUseToken(ctx, claims, s.cfg.Tenancy.InvitationLifetime)
+	if err != nil {
+		logger.Error("failed to use invitation token", "err", err)
+		return fmt.Errorf("failed to use invitation token: %w", err)
+	}
+	if !isFirstUse {
+		logger.Info("invitation has been already used")
+		return ErrDeclineOrganizationInvitationInvalidToken
+	}
+
+	logger.Info("successfully declined invitation")
+	return nil
+}
+
+func (s *userService) SwitchUserOrganization(ctx context.Context, opts SwitchUserOrganizationOptions) (*UserTokenOutput, error) {
+	logger := s.logger.
+		Named("SwitchUserOrganization").
+		WithContext(ctx).
+		With("opts", opts)
+
+	// Tokens are issued only for the user itself
+	err := authorizeSelf(ctx, opts.UserID)
+	if err != nil {
+		logger.Info("access is forbidden")
+		return nil, err
+	}
+	principal := PrincipalFromContext(ctx)
+
+	if _, err := uuid.Parse(opts.OrganizationID); err != nil {
+		logger.Info("invalid organization id")
+		return nil, ErrSwitchUserOrganizationNotMember
+	}
+
+	organizationCtx := ContextWithTenant(ctx, opts.OrganizationID)
+	user, err := s.storages.User.GetUser(organizationCtx, GetUserFilter{
+		ID: &opts.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user is not a member of organization")
+		return nil, ErrSwitchUserOrganizationNotMember
+	}
+
+	// Switching organization is not an authentication, so the original authentication is kept
+	output, err := s.GenerateUserToken(organizationCtx, GenerateUserTokenOptions{
+		User:        user,
+		AuthMethods: principal.AuthMethods,
+		AuthTime:    principal.AuthTime,
+	})
+	if err != nil {
+		logger.Error("failed to generate user token", "err", err)
+		return nil, fmt.Errorf("failed to generate user token: %w", err)
+	}
+
+	logger.Info("successfully switched user organization")
+	return output, nil
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/taraslis453/solid-software-test/pkg/lockout"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+)
+
+// loginThrottleSubject is the key failed login attempts are counted by with its attempts limit.
+type loginThrottleSubject struct {
+	key         string
+	maxAttempts int
+}
+
+func (s *userService) UnlockUser(ctx context.Context, userID string) error {
+	logger := s.logger.
+		Named("UnlockUser").
+		WithContext(ctx).
+		With("userID", userID)
+
+	err := authorizePermission(ctx, entity.PermissionUsersUnlock)
+	if err != nil {
+		logger.Info("access is forbidden")
+		return err
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &userID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	// Users who only joined the organization are managed by their own organization
+	if user == nil || !isUserManageable(ctx, user) {
+		logger.Info("user not found")
+		return ErrUnlockUserUserNotFound
+	}
+
+	err = s.storages.LoginThrottle.DeleteLoginThrottles(ctx, []string{userLoginThrottleKey(user.TenantID, user.EmailAddress)})
+	if err != nil {
+		logger.Error("failed to delete login throttles", "err", err)
+		return fmt.Errorf("failed to delete login throttles: %w", err)
+	}
+
+	logger.Info("successfully unlocked user")
+	return nil
+}
+
+// loginAttempt is the result of acquiring the login attempt.
+type loginAttempt struct {
+	// retryAfter is the time to wait before the next attempt, set if the attempt is not allowed.
+	retryAfter time.Duration
+	// isLocked is true if attempts limit of any subject is reached, otherwise only the progressive delay is applied.
+	isLocked bool
+	// failedAttempts is the number of failed attempts of each subject key counted before this one.
+	failedAttempts map[string]int
+}
+
+// acquireLoginAttempt registers the login attempt for the subjects in advance, so concurrent attempts can not exceed the
+// limits. The attempt is counted as failed until it is released with releaseLoginAttempt.
+func (s *userService) acquireLoginAttempt(ctx context.Context, subjects []loginThrottleSubject) (loginAttempt, error) {
+	attempt := loginAttempt{
+		failedAttempts: make(map[string]int, len(subjects)),
+	}
+	_, err := s.storages.LoginThrottle.IncrementLoginThrottles(ctx, getLoginThrottleKeys(subjects), s.cfg.Lockout.AttemptsWindow,
+		func(throttles []entity.LoginThrottle) bool {
+			now := time.Now()
+			attempt.retryAfter, attempt.isLocked = s.getLoginWait(throttles, subjects, now)
+			for _, throttle := range throttles {
+				// Stale counter is started over by this attempt
+				if !s.getLockoutPolicy().IsStale(newLockoutCounter(throttle), now) {
+					attempt.failedAttempts[throttle.Key] = throttle.FailedAttempts
+				}
+			}
+			return attempt.retryAfter <= 0
+		},
+	)
+	if err != nil {
+		return loginAttempt{}, fmt.Errorf("failed to increment login throttles: %w", err)
+	}
+
+	return attempt, nil
+}
+
+// releaseLoginAttempt takes back the attempt acquired for the subjects after successful login. Counter of the user is
+// reset, while IP counter keeps earlier failures, so logging into own account does not unlock guessing of others.
+func (s *userService) releaseLoginAttempt(ctx context.Context, subjects []loginThrottleSubject) error {
+	userKeys, otherKeys := lockout.SplitRelease(getLoginThrottleKeys(subjects), userLoginThrottleKeyPrefix)
+
+	if len(userKeys) > 0 {
+		err := s.storages.LoginThrottle.DeleteLoginThrottles(ctx, userKeys)
+		if err != nil {
+			return fmt.Errorf("failed to delete login throttles: %w", err)
+		}
+	}
+	if len(otherKeys) > 0 {
+		err := s.storages.LoginThrottle.DecrementLoginThrottles(ctx, otherKeys)
+		if err != nil {
+			return fmt.Errorf("failed to decrement login throttles: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// getLoginWait returns the time to wait before the next login attempt is allowed for the subjects with the throttles.
+// isLocked is true if attempts limit of any subject is reached, otherwise only the progressive delay is applied.
+func (s *userService) getLoginWait(throttles []entity.LoginThrottle, subjects []loginThrottleSubject, now time.Time) (time.Duration, bool) {
+	maxAttempts := make(map[string]int, len(subjects))
+	for _, subject := range subjects {
+		maxAttempts[subject.key] = subject.maxAttempts
+	}
+
+	counters := make([]lockout.Counter, 0, len(throttles))
+	for _, throttle := range throttles {
+		counters = append(counters, newLockoutCounter(throttle))
+	}
+	return s.getLockoutPolicy().Wait(counters, maxAttempts, now)
+}
+
+// getLockoutPolicy returns the policy failed login attempts are throttled with.
+func (s *userService) getLockoutPolicy() lockout.Policy {
+	return lockout.Policy{
+		Duration:       s.cfg.Lockout.Duration,
+		BaseDelay:      s.cfg.Lockout.BaseDelay,
+		MaxDelay:       s.cfg.Lockout.MaxDelay,
+		AttemptsWindow: s.cfg.Lockout.AttemptsWindow,
+	}
+}
+
+func newLockoutCounter(throttle entity.LoginThrottle) lockout.Counter {
+	return lockout.Counter{
+		Key:            throttle.Key,
+		FailedAttempts: throttle.FailedAttempts,
+		LastFailedAt:   throttle.LastFailedAt,
+	}
+}
+
+// getLoginThrottleSubjects returns subjects failed login attempts are counted by. emailAddress and ip are optional.
+// Account is counted by the email address within the organization, not by the user, so unknown and existing addresses
+// are locked alike.
+func (s *userService) getLoginThrottleSubjects(tenantID, emailAddress, ip string) []loginThrottleSubject {
+	var subjects []loginThrottleSubject
+	if emailAddress != "" {
+		key := userLoginThrottleKey(tenantID, emailAddress)
+		subjects = append(subjects, loginThrottleSubject{key: key, maxAttempts: s.cfg.Lockout.UserMaxAttempts})
+	}
+	if ip != "" {
+		subjects = append(subjects, loginThrottleSubject{key: "ip:" + ip, maxAttempts: s.cfg.Lockout.IPMaxAttempts})
+	}
+	return subjects
+}
+
+// getLoginThrottleKeys returns keys of the subjects.
+func getLoginThrottleKeys(subjects []loginThrottleSubject) []string {
+	keys := make([]string, 0, len(subjects))
+	for _, subject := range subjects {
+		keys = append(keys, subject.key)
+	}
+	return keys
+}
+
+// userLoginThrottleKeyPrefix is followed by organization ID and lowercased email address in the key of user login throttle.
+const userLoginThrottleKeyPrefix = "user:"
+
+func userLoginThrottleKey(tenantID, emailAddress string) string {
+	return userLoginThrottleKeyPrefix + tenantID + ":" + strings.ToLower(strings.TrimSpace(emailAddress))
+}
+
+// newRetryAfterDetails returns error details with the wait time rounded up to seconds.
+func newRetryAfterDetails(retryAfter time.Duration) RetryAfterDetails {
+	return RetryAfterDetails{
+		RetryAfter: int(math.Ceil(retryAfter.Seconds())),
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/pkg/mailer"
+	"github.com/taraslis453/solid-software-test/pkg/token"
+)
+
+func (s *userService) SendUserMagicLink(ctx context.Context, opts SendUserMagicLinkOptions) error {
+	logger := s.logger.
+		Named("SendUserMagicLink").
+		WithContext(ctx).
+		With("opts", opts)
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		EmailAddress: &opts.EmailAddress,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	// Neither missing user nor cooldown is reported to prevent email enumeration
+	if user == nil {
+		logger.Info("user not found")
+		return nil
+	}
+	logger = logger.With("userID", user.ID)
+	if user.MagicLinkSentAt != nil && time.Since(*user.MagicLinkSentAt) < s.cfg.Auth.MagicLinkResendCooldown {
+		logger.Info("magic link has been sent recently")
+		return nil
+	}
+	logger.Debug("got user")
+
+	magicLinkToken, err := s.signPurposeToken(token.PurposeClaims{
+		Purpose:  magicLinkTokenPurpose,
+		UserID:   user.ID,
+		Email:    user.EmailAddress,
+		TokenID:  uuid.NewString(),
+		TenantID: user.TenantID,
+	}, s.cfg.Auth.MagicLinkTokenLifetime)
+	if err != nil {
+		logger.Error("failed to sign magic link token", "err", err)
+		return fmt.Errorf("failed to sign magic link token: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, &mailer.Message{
+		To:      user.EmailAddress,
+		Subject: "Your login link",
+		Body: fmt.Sprintf(
+			"To log in follow the link: %s/users/login/magic-link/verify?token=%s\nThe link expires in %s and can be used only once. If you did not request it, ignore this email.",
+			s.cfg.App.PublicURL, url.QueryEscape(magicLinkToken), s.cfg.Auth.MagicLinkTokenLifetime,
+		),
+	})
+	if err != nil {
+		logger.Error("failed to send magic link email", "err", err)
+		return fmt.Errorf("failed to send magic link email: %w", err)
+	}
+
+	now := time.Now()
+	_, err = s.storages.User.UpdateUser(ctx, user.ID, &entity.User{
+		MagicLinkSentAt: &now,
+	})
+	if err != nil {
+		logger.Error("failed to update user", "err", err)
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	logger.Info("successfully sent magic link email")
+	return nil
+}
+
+func (s *userService) LoginUserMagicLink(ctx context.Context, opts LoginUserMagicLinkOptions) (LoginUserOutput, error) {
+	logger := s.logger.
+		Named("LoginUserMagicLink").
+		WithContext(ctx)
+
+	claims, err := s.verifyPurposeToken(opts.Token, magicLinkTokenPurpose)
+	if err != nil {
+		logger.Info("invalid magic link token", "err", err)
+		return LoginUserOutput{}, ErrLoginUserMagicLinkInvalidToken
+	}
+	logger = logger.With("userID", claims.UserID)
+	ctx = contextWithTokenTenant(ctx, claims.TenantID)
+
+	isFirstUse, err := s.useSingleUseToken(ctx, claims, s.cfg.Auth.MagicLinkTokenLifetime)
+	if err != nil {
+		logger.Error("failed to use magic link token", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to use magic link token: %w", err)
+	}
+	if !isFirstUse {
+		logger.Info("magic link token has been already used")
+		return LoginUserOutput{}, ErrLoginUserMagicLinkInvalidToken
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &claims.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return LoginUserOutput{}, ErrLoginUserMagicLinkInvalidToken
+	}
+	logger.Debug("got user")
+
+	// Following the link proves the email ownership, unless email has been changed after the link was sent
+	if !user.IsEmailVerified() && claims.Email == user.EmailAddress {
+		err = s.markUserEmailVerified(ctx, user)
+		if err != nil {
+			logger.Error("failed to mark user email verified", "err", err)
+			return LoginUserOutput{}, fmt.Errorf("failed to mark user email verified: %w", err)
+		}
+	}
+
+	output, err := s.completeFirstFactorLogin(ctx, user, AuthMethodEmail)
+	if err != nil {
+		logger.Error("failed to complete login", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to complete login: %w", err)
+	}
+
+	logger.Info("user logged in with magic link", "mfaRequired", output.MFARequired)
+	return output, nil
+}
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/taraslis453/solid-software-test/pkg/errs"
+	"github.com/taraslis453/solid-software-test/pkg/password"
+	"github.com/taraslis453/solid-software-test/pkg/token"
+	"github.com/taraslis453/solid-software-test/pkg/totp"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+)
+
+const (
+	// totpSkew is the number of time steps the code can drift in both directions.
+	totpSkew = 1
+	// totpQRCodeSize is the size of QR code image in pixels.
+	totpQRCodeSize = 256
+
+	// recoveryCodesCount is the number of recovery codes generated for a user.
+	recoveryCodesCount = 10
+	// recoveryCodeLength is the number of characters in a recovery code.
+	recoveryCodeLength = 10
+	// recoveryCodeAlphabet excludes similar looking characters.
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+func (s *userService) EnrollUserTOTP(ctx context.Context, userID string) (*EnrollUserTOTPOutput, error) {
+	logger := s.logger.
+		Named("EnrollUserTOTP").
+		WithContext(ctx).
+		With("userID", userID)
+
+	err := s.authorizeUserAccess(ctx, userID, entity.PermissionUsersUpdate)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("access is forbidden")
+			return nil, err
+		}
+		logger.Error("failed to authorize user access", "err", err)
+		return nil, fmt.Errorf("failed to authorize user access: %w", err)
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &userID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return nil, ErrEnrollUserTOTPUserNotFound
+	}
+	if user.IsMFAEnabled() {
+		logger.Info("mfa is already enabled")
+		return nil, ErrEnrollUserTOTPMFAAlreadyEnabled
+	}
+	logger.Debug("got user")
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		logger.Error("failed to generate totp secret", "err", err)
+		return nil, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+
+	encryptedSecret, err := s.secretEncryptor.Encrypt(secret)
+	if err != nil {
+		logger.Error("failed to encrypt totp secret", "err", err)
+		return nil, fmt.Errorf("failed to encrypt totp secret: %w", err)
+	}
+
+	// Secret is overwritten on every enrollment attempt until it is confirmed
+	_, err = s.storages.User.UpdateUser(ctx, user.ID, &entity.User{TOTPSecret: encryptedSecret})
+	if err != nil {
+		logger.Error("failed to update user", "err", err)
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	uri := totp.URI(&totp.URIOptions{
+		Issuer:      s.cfg.MFA.Issuer,
+		AccountName: user.EmailAddress,
+		Secret:      secret,
+	})
+	qrCode, err := totp.QRCodePNG(uri, totpQRCodeSize)
+	if err != nil {
+		logger.Error("failed to generate qr code", "err", err)
+		return nil, fmt.Errorf("failed to generate qr code: %w", err)
+	}
+
+	logger.Info("successfully enrolled user totp")
+	return &EnrollUserTOTPOutput{
+		Secret: secret,
+		URI:    uri,
+		QRCode: qrCode,
+	}, nil
+}
+
+func (s *userService) ConfirmUserTOTP(ctx context.Context, opts ConfirmUserTOTPOptions) (*ConfirmUserTOTPOutput, error) {
+	logger := s.logger.
+		Named("ConfirmUserTOTP").
+		WithContext(ctx).
+		With("userID", opts.UserID)
+
+	err := s.authorizeUserAccess(ctx, opts.UserID, entity.PermissionUsersUpdate)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("access is forbidden")
+			return nil, err
+		}
+		logger.Error("failed to authorize user access", "err", err)
+		return nil, fmt.Errorf("failed to authorize user access: %w", err)
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &opts.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return nil, ErrConfirmUserTOTPUserNotFound
+	}
+	if user.IsMFAEnabled() {
+		logger.Info("mfa is already enabled")
+		return nil, ErrConfirmUserTOTPMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		logger.Info("mfa enrollment is not started")
+		return nil, ErrConfirmUserTOTPMFANotEnrolled
+	}
+	logger.Debug("got user")
+
+	step, isCodeValid, err := s.validateUserTOTPCode(user, opts.Code)
+	if err != nil {
+		logger.Error("failed to validate totp code", "err", err)
+		return nil, fmt.Errorf("failed to validate totp code: %w", err)
+	}
+	if !isCodeValid {
+		logger.Info("invalid totp code")
+		return nil, ErrConfirmUserTOTPInvalidCode
+	}
+
+	now := time.Now()
+	_, err = s.storages.User.UpdateUser(ctx, user.ID, &entity.User{
+		MFAEnabledAt:     &now,
+		TOTPLastUsedStep: step,
+	})
+	if err != nil {
+		logger.Error("failed to update user", "err", err)
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	recoveryCodes, err := s.generateMFARecoveryCodes(ctx, user.ID)
+	if err != nil {
+		logger.Error("failed to generate mfa recovery codes", "err", err)
+		return nil, fmt.Errorf("failed to generate mfa recovery codes: %w", err)
+	}
+
+	logger.Info("successfully enabled user mfa")
+	return &ConfirmUserTOTPOutput{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+func (s *userService) RegenerateUserMFARecoveryCodes(ctx context.Context, userID string) (*RegenerateUserMFARecoveryCodesOutput, error) {
+	logger := s.logger.
+		Named("RegenerateUserMFARecoveryCodes").
+		WithContext(ctx).
+		With("userID", userID)
+
+	err := s.authorizeUserAccess(ctx, userID, entity.PermissionUsersUpdate)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("access is forbidden")
+			return nil, err
+		}
+		logger.Error("failed to authorize user access", "err", err)
+		return nil, fmt.Errorf("failed to authorize user access: %w", err)
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &userID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return nil, ErrRegenerateUserMFARecoveryCodesUserNotFound
+	}
+	if !user.IsMFAEnabled() {
+		logger.Info("mfa is not enabled")
+		return nil, ErrRegenerateUserMFARecoveryCodesMFANotEnabled
+	}
+	logger.Debug("got user")
+
+	recoveryCodes, err := s.generateMFARecoveryCodes(ctx, user.ID)
+	if err != nil {
+		logger.Error("failed to generate mfa recovery codes", "err", err)
+		return nil, fmt.Errorf("failed to generate mfa recovery codes: %w", err)
+	}
+
+	logger.Info("successfully regenerated mfa recovery codes")
+	return &RegenerateUserMFARecoveryCodesOutput{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+func (s *userService) LoginUserMFA(ctx context.Context, opts LoginUserMFAOptions) (LoginUserOutput, error) {
+	logger := s.logger.
+		Named("LoginUserMFA").
+		WithContext(ctx)
+
+	claims, err := s.verifyPurposeToken(opts.ChallengeToken, mfaChallengeTokenPurpose)
+	if err != nil {
+		logger.Info("invalid mfa challenge token", "err", err)
+		return LoginUserOutput{}, ErrLoginUserMFAInvalidToken
+	}
+	logger = logger.With("userID", claims.UserID)
+	ctx = contextWithTokenTenant(ctx, claims.TenantID)
+
+	// Deleted user completing login is restored within the grace period
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID:          &claims.UserID,
+		WithDeleted: true,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil || !s.isUserRestorable(user) || !user.IsMFAEnabled() {
+		logger.Info("user not found or mfa is not enabled")
+		return LoginUserOutput{}, ErrLoginUserMFAInvalidToken
+	}
+	logger.Debug("got user")
+
+	// Codes are guessed within the login limits of the user, and a single challenge allows only a few guesses
+	challengeThrottleKey := mfaChallengeLoginThrottleKeyPrefix + claims.TokenID
+	throttleSubjects := append(
+		s.getLoginThrottleSubjects(user.TenantID, user.EmailAddress, opts.IPAddress),
+		loginThrottleSubject{key: challengeThrottleKey, maxAttempts: s.cfg.MFA.ChallengeMaxAttempts},
+	)
+	attempt, err := s.acquireLoginAttempt(ctx, throttleSubjects)
+	if err != nil {
+		logger.Error("failed to acquire login attempt", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to acquire login attempt: %w", err)
+	}
+	if attempt.isLocked {
+		logger.Info("login is locked", "retryAfter", attempt.retryAfter)
+		return LoginUserOutput{}, errs.WithDetails(ErrLoginUserMFAAccountLocked, newRetryAfterDetails(attempt.retryAfter))
+	}
+	if attempt.retryAfter > 0 {
+		logger.Info("login is delayed", "retryAfter", attempt.retryAfter)
+		return LoginUserOutput{}, errs.WithDetails(ErrLoginUserMFATooManyAttempts, newRetryAfterDetails(attempt.retryAfter))
+	}
+
+	// The last allowed guess uses the challenge up front, so it is burned even if the code is wrong
+	isLastAttempt := attempt.failedAttempts[challengeThrottleKey]+1 >= s.cfg.MFA.ChallengeMaxAttempts
+	if isLastAttempt {
+		err = s.useMFAChallengeToken(ctx, claims)
+		if err != nil {
+			logger.Info("mfa challenge token has been already used", "err", err)
+			return LoginUserOutput{}, err
+		}
+	}
+
+	authMethod, isCodeValid, err := s.useUserMFACode(ctx, user, opts.Code, opts.RecoveryCode)
+	if err != nil {
+		logger.Error("failed to use mfa code", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to use mfa code: %w", err)
+	}
+	if !isCodeValid {
+		logger.Info("invalid mfa code")
+		return LoginUserOutput{}, ErrLoginUserMFAInvalidCode
+	}
+
+	if !isLastAttempt {
+		err = s.useMFAChallengeToken(ctx, claims)
+		if err != nil {
+			logger.Info("mfa challenge token has been already used", "err", err)
+			return LoginUserOutput{}, err
+		}
+	}
+
+	err = s.releaseLoginAttempt(ctx, throttleSubjects)
+	if err != nil {
+		logger.Error("failed to release login attempt", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to release login attempt: %w", err)
+	}
+
+	recoveryCodes, err := s.storages.MFARecoveryCode.ListMFARecoveryCodes(ctx, ListMFARecoveryCodesFilter{
+		UserID:     user.ID,
+		OnlyUnused: true,
+	})
+	if err != nil {
+		logger.Error("failed to list mfa recovery codes", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to list mfa recovery codes: %w", err)
+	}
+	var recoveryCodesWarning string
+	if len(recoveryCodes) <= s.cfg.MFA.RecoveryCodesWarningThreshold {
+		recoveryCodesWarning = fmt.Sprintf("only %d recovery codes left, regenerate them to not lose access to your account", len(recoveryCodes))
+	}
+
+	err = s.restoreUser(ctx, user)
+	if err != nil {
+		logger.Error("failed to restore user", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to restore user: %w", err)
+	}
+
+	tokens, err := s.GenerateUserToken(ctx, GenerateUserTokenOptions{
+		User:        user,
+		AuthMethods: append(claims.AMR, authMethod, AuthMethodMFA),
+	})
+	if err != nil {
+		logger.Error("failed to generate tokens", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to generate tokens: %w", err)
+	}
+
+	logger.Info("user logged in with mfa")
+	return LoginUserOutput{
+		AccessToken:            tokens.AccessToken,
+		RefreshToken:           tokens.RefreshToken,
+		UserID:                 user.ID,
+		RecoveryCodesWarning:   recoveryCodesWarning,
+		RecoveryCodesRemaining: len(recoveryCodes),
+	}, nil
+}
+
+// mfaChallengeLoginThrottleKeyPrefix is followed by the token ID in the key of MFA challenge login throttle.
+const mfaChallengeLoginThrottleKeyPrefix = "mfa:"
+
+// useMFAChallengeToken marks the MFA challenge token as used, so it can not be used again.
+// Returns ErrLoginUserMFAInvalidToken if it has been already used.
+func (s *userService) useMFAChallengeToken(ctx context.Context, claims *token.PurposeClaims) error {
+	isFirstUse, err := s.useSingleUseToken(ctx, claims, s.cfg.MFA.ChallengeTokenLifetime)
+	if err != nil {
+		return fmt.Errorf("failed to use mfa challenge token: %w", err)
+	}
+	if !isFirstUse {
+		return ErrLoginUserMFAInvalidToken
+	}
+
+	return nil
+}
+
+// useUserMFACode validates the recovery code if it is passed or the TOTP code otherwise and marks it as used.
+// Returns the authentication method of the code.
+func (s *userService) useUserMFACode(ctx context.Context, user *entity.User, code, recoveryCode string) (string, bool, error) {
+	if recoveryCode != "" {
+		isCodeValid, err := s.useMFARecoveryCode(ctx, user.ID, recoveryCode)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to use mfa recovery code: %w", err)
+		}
+		return AuthMethodRecoveryCode, isCodeValid, nil
+	}
+
+	step, isCodeValid, err := s.validateUserTOTPCode(user, code)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to validate totp code: %w", err)
+	}
+	if !isCodeValid {
+		return "", false, nil
+	}
+
+	// Code of already used time step is rejected to prevent replay, even by concurrent requests
+	isStepUsed, err := s.storages.User.UseUserTOTPStep(ctx, user.ID, step)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to use user totp step: %w", err)
+	}
+	if !isStepUsed {
+		return "", false, nil
+	}
+	return AuthMethodOTP, true, nil
+}
+
+// validateUserTOTPCode decrypts user TOTP secret and validates the code against it.
+// Returns the time step of matched code.
+func (s *userService) validateUserTOTPCode(user *entity.User, code string) (int64, bool, error) {
+	secret, err := s.secretEncryptor.Decrypt(user.TOTPSecret)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	return totp.Validate(secret, code, time.Now(), totpSkew)
+}
+
+// generateMFARecoveryCodes replaces user recovery codes with new ones and returns them in plain text.
+func (s *userService) generateMFARecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashedCodes := make([]entity.MFARecoveryCode, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		hashedCode, err := s.passwordHasher.GenerateHashFromPassword(normalizeRecoveryCode(code))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+
+		codes = append(codes, code)
+		hashedCodes = append(hashedCodes, entity.MFARecoveryCode{
+			UserID:   userID,
+			CodeHash: hashedCode,
+		})
+	}
+
+	err := s.storages.MFARecoveryCode.ReplaceMFARecoveryCodes(ctx, userID, hashedCodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replace recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+// useMFARecoveryCode finds unused user recovery code matching passed one and marks it as used.
+func (s *userService) useMFARecoveryCode(ctx context.Context, userID, code string) (bool, error) {
+	recoveryCodes, err := s.storages.MFARecoveryCode.ListMFARecoveryCodes(ctx, ListMFARecoveryCodesFilter{
+		UserID:     userID,
+		OnlyUnused: true,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to list recovery codes: %w", err)
+	}
+
+	code = normalizeRecoveryCode(code)
+	for _, recoveryCode := range recoveryCodes {
+		isCodeEqual, err := s.passwordHasher.CompareHashAndPassword(&password.CompareHashAndPasswordOptions{
+			Hashed:   recoveryCode.CodeHash,
+			Password: code,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to compare recovery code: %w", err)
+		}
+		if isCodeEqual {
+			return s.storages.MFARecoveryCode.UseMFARecoveryCode(ctx, recoveryCode.ID)
+		}
+	}
+
+	return false, nil
+}
+
+// generateRecoveryCode generates a random recovery code in "xxxxx-xxxxx" format.
+func generateRecoveryCode() (string, error) {
+	var code strings.Builder
+	for i := 0; i < recoveryCodeLength; i++ {
+		if i == recoveryCodeLength/2 {
+			code.WriteByte('-')
+		}
+
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryCodeAlphabet))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random number: %w", err)
+		}
+		code.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+
+	return code.String(), nil
+}
+
+// normalizeRecoveryCode removes separators and whitespaces users may add or omit while typing the code.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+)
+
+// defaultOrganizationSlug is the slug of the organization users belong to by default.
+const defaultOrganizationSlug = "default"
+
+// organizationSlugRegexp matches slugs which are valid DNS labels, so organization can be resolved from subdomain.
+var organizationSlugRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+var _ OrganizationService = (*organizationService)(nil)
+
+type organizationService struct {
+	serviceContext
+}
+
+func NewOrganizationService(options Options) *organizationService {
+	return &organizationService{
+		serviceContext: serviceContext{
+			storages:     options.Storages,
+			cfg:          options.Config,
+			logger:       options.Logger.Named("organizationService"),
+			policyEngine: options.PolicyEngine,
+		},
+	}
+}
+
+func (s *organizationService) SeedDefaultOrganization(ctx context.Context) error {
+	logger := s.logger.
+		Named("SeedDefaultOrganization").
+		WithContext(ctx)
+
+	err := s.storages.Organization.SeedOrganization(ctx, &entity.Organization{
+		ID:   entity.DefaultOrganizationID,
+		Name: "Default",
+		Slug: defaultOrganizationSlug,
+	})
+	if err != nil {
+		logger.Error("failed to seed organization", "err", err)
+		return fmt.Errorf("failed to seed organization: %w", err)
+	}
+
+	logger.Info("successfully seeded default organization")
+	return nil
+}
+
+func (s *organizationService) GetOrganization(ctx context.Context, opts GetOrganizationOptions) (*entity.Organization, error) {
+	logger := s.logger.
+		Named("GetOrganization").
+		WithContext(ctx).
+		With("opts", opts)
+
+	var filter GetOrganizationFilter
+	if opts.ID != "" {
+		// Malformed ID can not match any organization, and would fail the query
+		if _, err := uuid.Parse(opts.ID); err != nil {
+			logger.Info("invalid organization id")
+			return nil, ErrGetOrganizationOrganizationNotFound
+		}
+		filter.ID = &opts.ID
+	}
+	if opts.Slug != "" {
+		filter.Slug = &opts.Slug
+	}
+
+	organization, err := s.storages.Organization.GetOrganization(ctx, filter)
+	if err != nil {
+		logger.Error("failed to get organization", "err", err)
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	if organization == nil {
+		logger.Info("organization not found")
+		return nil, ErrGetOrganizationOrganizationNotFound
+	}
+
+	logger.Info("successfully got organization", "organizationID", organization.ID)
+	return organization, nil
+}
+
+func (s *organizationService) CreateOrganization(ctx context.Context, opts CreateOrganizationOptions) (*entity.Organization, error) {
+	logger := s.logger.
+		Named("CreateOrganization").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionOrganizationsManage)
+	if err != nil {
+		logger.Info("principal is not allowed to create organizations")
+		return nil, err
+	}
+
+	if !organizationSlugRegexp.MatchString(opts.Slug) {
+		logger.Info("invalid slug")
+		return nil, ErrCreateOrganizationInvalidSlug
+	}
+
+	existingOrganization, err := s.storages.Organization.GetOrganization(ctx, GetOrganizationFilter{
+		Slug: &opts.Slug,
+	})
+	if err != nil {
+		logger.Error("failed to get organization", "err", err)
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	if existingOrganization != nil {
+		logger.Info("organization already exists")
+		return nil, ErrCreateOrganizationOrganizationAlreadyExists
+	}
+
+	organization, err := s.storages.Organization.CreateOrganization(ctx, &entity.Organization{
+		Name: opts.Name,
+		Slug: opts.Slug,
+	})
+	if err != nil {
+		logger.Error("failed to create organization", "err", err)
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	logger.Info("successfully created organization", "organizationID", organization.ID)
+	return organization, nil
+}
+
+func (s *organizationService) ListOrganizationMembers(ctx context.Context) ([]entity.Membership, error) {
+	logger := s.logger.
+		Named("ListOrganizationMembers").
+		WithContext(ctx)
+
+	err := authorizePermission(ctx, entity.PermissionMembersManage)
+	if err != nil {
+		logger.Info("principal is not allowed to list members")
+		return nil, err
+	}
+
+	organizationID := TenantFromContext(ctx)
+	logger = logger.With("organizationID", organizationID)
+
+	members, err := s.storages.Membership.ListMembers(ctx, organizationID)
+	if err != nil {
+		logger.Error("failed to list members", "err", err)
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+
+	logger.Info("successfully listed members", "count", len(members))
+	return members, nil
+}
+
+func (s *organizationService) UpdateOrganizationMember(ctx context.Context, opts UpdateOrganizationMemberOptions) (*entity.Membership, error) {
+	logger := s.logger.
+		Named("UpdateOrganizationMember").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionMembersManage)
+	if err != nil {
+		logger.Info("principal is not allowed to update members")
+		return nil, err
+	}
+
+	organizationID := TenantFromContext(ctx)
+	logger = logger.With("organizationID", organizationID)
+
+	// Users storage is scoped to the organization, so only its members are found
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &opts.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user is not a member of organization")
+		return nil, ErrUpdateOrganizationMemberNotMember
+	}
+
+	membership := &entity.Membership{
+		OrganizationID: organizationID,
+		UserID:         user.ID,
+	}
+	if opts.RoleID != "" {
+		// Role grants permissions, so granting it requires the same permission as assigning roles
+		err = authorizePermission(ctx, entity.PermissionRolesManage)
+		if err != nil {
+			logger.Info("principal is not allowed to assign roles")
+			return nil, err
+		}
+
+		role, err := s.storages.Role.GetRole(ctx, GetRoleFilter{
+			ID: &opts.RoleID,
+		})
+		if err != nil {
+			logger.Error("failed to get role", "err", err)
+			return nil, fmt.Errorf("failed to get role: %w", err)
+		}
+		if role == nil {
+			logger.Info("role not found")
+			return nil, ErrUpdateOrganizationMemberRoleNotFound
+		}
+		membership.RoleID = &role.ID
+	}
+
+	membership, err = s.storages.Membership.UpsertMembership(ctx, membership)
+	if err != nil {
+		logger.Error("failed to upsert membership", "err", err)
+		return nil, fmt.Errorf("failed to upsert membership: %w", err)
+	}
+
+	logger.Info("successfully updated member")
+	return membership, nil
+}
+
+func (s *organizationService) RemoveOrganizationMember(ctx context.Context, userID string) error {
+	logger := s.logger.
+		Named("RemoveOrganizationMember").
+		WithContext(ctx).
+		With("userID", userID)
+
+	err := authorizePermission(ctx, entity.PermissionMembersManage)
+	if err != nil {
+		logger.Info("principal is not allowed to remove members")
+		return err
+	}
+
+	organizationID := TenantFromContext(ctx)
+	logger = logger.With("organizationID", organizationID)
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &userID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user is not a member of organization")
+		return ErrRemoveOrganizationMemberNotMember
+	}
+	if user.TenantID == organizationID {
+		logger.Info("user is registered in organization")
+		return ErrRemoveOrganizationMemberHomeOrganization
+	}
+
+	// Tokens scoped to the organization are rejected once user is not found within it
+	err = s.storages.Membership.DeleteMembership(ctx, organizationID, user.ID)
+	if err != nil {
+		logger.Error("failed to delete membership", "err", err)
+		return fmt.Errorf("failed to delete membership: %w", err)
+	}
+
+	logger.Info("successfully removed member")
+	return nil
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/taraslis453/solid-software-test/pkg/errs"
+	"github.com/taraslis453/solid-software-test/pkg/password"
+	"github.com/taraslis453/solid-software-test/pkg/sms"
+	"github.com/taraslis453/solid-software-test/pkg/token"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+)
+
+// Purposes of one-time codes.
+const (
+	phoneVerificationCodePurpose = "phone_verification"
+	phoneLoginCodePurpose        = "phone_login"
+)
+
+// phoneRegexp matches phone numbers in E.164 format.
+var phoneRegexp = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// phoneSeparatorsReplacer removes characters commonly used to format phone numbers.
+var phoneSeparatorsReplacer = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+
+// errOneTimeCodeCooldown is returned when a new code is requested before resend cooldown passes.
+var errOneTimeCodeCooldown = errors.New("one-time code has been sent recently")
+
+func (s *userService) SendUserPhoneVerificationCode(ctx context.Context, userID string) error {
+	logger := s.logger.
+		Named("SendUserPhoneVerificationCode").
+		WithContext(ctx).
+		With("userID", userID)
+
+	err := s.authorizeUserAccess(ctx, userID, entity.PermissionUsersUpdate)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("access is forbidden")
+			return err
+		}
+		logger.Error("failed to authorize user access", "err", err)
+		return fmt.Errorf("failed to authorize user access: %w", err)
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &userID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return ErrSendUserPhoneVerificationCodeUserNotFound
+	}
+	if user.Phone == "" {
+		logger.Info("phone is not set")
+		return ErrSendUserPhoneVerificationCodePhoneNotSet
+	}
+	if user.PhoneVerifiedAt != nil {
+		logger.Info("phone is already verified")
+		return ErrSendUserPhoneVerificationCodePhoneAlreadyVerified
+	}
+	phone, ok := normalizePhone(user.Phone)
+	if !ok {
+		logger.Info("invalid phone format", "phone", user.Phone)
+		return ErrSendUserPhoneVerificationCodeInvalidPhone
+	}
+	logger.Debug("got user")
+
+	err = s.sendOneTimeCode(ctx, user.ID, phone, phoneVerificationCodePurpose)
+	if errors.Is(err, errOneTimeCodeCooldown) {
+		logger.Info("code has been sent recently")
+		return ErrSendUserPhoneVerificationCodeTooManyRequests
+	}
+	if err != nil {
+		logger.Error("failed to send one-time code", "err", err)
+		return fmt.Errorf("failed to send one-time code: %w", err)
+	}
+
+	logger.Info("successfully sent phone verification code")
+	return nil
+}
+
+func (s *userService) VerifyUserPhone(ctx context.Context, opts VerifyUserPhoneOptions) error {
+	logger := s.logger.
+		Named("VerifyUserPhone").
+		WithContext(ctx).
+		With("userID", opts.UserID)
+
+	err := s.authorizeUserAccess(ctx, opts.UserID, entity.PermissionUsersUpdate)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("access is forbidden")
+			return err
+		}
+		logger.Error("failed to authorize user access", "err", err)
+		return fmt.Errorf("failed to authorize user access: %w", err)
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &opts.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return ErrVerifyUserPhoneUserNotFound
+	}
+	logger.Debug("got user")
+
+	phone, _ := normalizePhone(user.Phone)
+	isCodeValid, err := s.verifyOneTimeCode(ctx, user.ID, phone, phoneVerificationCodePurpose, opts.Code)
+	if err != nil {
+		logger.Error("failed to verify one-time code", "err", err)
+		return fmt.Errorf("failed to verify one-time code: %w", err)
+	}
+	if !isCodeValid {
+		logger.Info("invalid one-time code")
+		return ErrVerifyUserPhoneInvalidCode
+	}
+
+	// Verified phone is used to login, so it can belong to a single user only, which is ensured by the storage
+	isPhoneSet, err := s.storages.User.SetUserVerifiedPhone(ctx, user.ID, phone)
+	if err != nil {
+		logger.Error("failed to set user verified phone", "err", err)
+		return fmt.Errorf("failed to set user verified phone: %w", err)
+	}
+	if !isPhoneSet {
+		logger.Info("phone is verified by another user")
+		return ErrVerifyUserPhonePhoneAlreadyUsed
+	}
+
+	logger.Info("successfully verified user phone")
+	return nil
+}
+
+func (s *userService) SendUserLoginCode(ctx context.Context, opts SendUserLoginCodeOptions) error {
+	logger := s.logger.
+		Named("SendUserLoginCode").
+		WithContext(ctx).
+		With("opts", opts)
+
+	phone, ok := normalizePhone(opts.Phone)
+	if !ok {
+		logger.Info("invalid phone format")
+		return ErrSendUserLoginCodeInvalidPhone
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		VerifiedPhone: &phone,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		// Do not report that user does not exist to prevent phone enumeration
+		logger.Info("user not found")
+		return nil
+	}
+	logger = logger.With("userID", user.ID)
+
+	err = s.sendOneTimeCode(ctx, user.ID, phone, phoneLoginCodePurpose)
+	if errors.Is(err, errOneTimeCodeCooldown) {
+		// Cooldown is not reported either, since it is reported only for existing users
+		logger.Info("code has been sent recently")
+		return nil
+	}
+	if err != nil {
+		logger.Error("failed to send one-time code", "err", err)
+		return fmt.Errorf("failed to send one-time code: %w", err)
+	}
+
+	logger.Info("successfully sent login code")
+	return nil
+}
+
+func (s *userService) LoginUserPhone(ctx context.Context, opts LoginUserPhoneOptions) (LoginUserOutput, error) {
+	logger := s.logger.
+		Named("LoginUserPhone").
+		WithContext(ctx).
+		With("phone", opts.Phone)
+
+	phone, ok := normalizePhone(opts.Phone)
+	if !ok {
+		logger.Info("invalid phone format")
+		return LoginUserOutput{}, ErrLoginUserPhoneInvalidCode
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		VerifiedPhone: &phone,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return LoginUserOutput{}, ErrLoginUserPhoneInvalidCode
+	}
+	logger = logger.With("userID", user.ID)
+
+	isCodeValid, err := s.verifyOneTimeCode(ctx, user.ID, phone, phoneLoginCodePurpose, opts.Code)
+	if err != nil {
+		logger.Error("failed to verify one-time code", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to verify one-time code: %w", err)
+	}
+	if !isCodeValid {
+		logger.Info("invalid one-time code")
+		return LoginUserOutput{}, ErrLoginUserPhoneInvalidCode
+	}
+	if s.isLoginBlockedByEmailVerification(user) {
+		logger.Info("email is not verified")
+		return LoginUserOutput{}, ErrLoginUserPhoneEmailNotVerified
+	}
+
+	output, err := s.completeFirstFactorLogin(ctx, user, AuthMethodSMS)
+	if err != nil {
+		logger.Error("failed to complete login", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to complete login: %w", err)
+	}
+
+	logger.Info("user logged in with phone", "mfaRequired", output.MFARequired)
+	return output, nil
+}
+
+// sendOneTimeCode replaces the previous user code issued for the purpose with a new one and sends it to the phone.
+func (s *userService) sendOneTimeCode(ctx context.Context, userID, phone, purpose string) error {
+	lastCode, err := s.storages.OneTimeCode.GetOneTimeCode(ctx, GetOneTimeCodeFilter{
+		UserID:  userID,
+		Purpose: purpose,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get last code: %w", err)
+	}
+	if lastCode != nil && time.Since(lastCode.CreatedAt) < s.cfg.OTP.ResendCooldown {
+		return errOneTimeCodeCooldown
+	}
+
+	err = s.storages.OneTimeCode.InvalidateOneTimeCodes(ctx, userID, purpose)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate codes: %w", err)
+	}
+
+	code, err := token.GenerateNumericCode(s.cfg.OTP.CodeLength)
+	if err != nil {
+		return fmt.Errorf("failed to generate code: %w", err)
+	}
+	codeHash, err := s.passwordHasher.GenerateHashFromPassword(code)
+	if err != nil {
+		return fmt.Errorf("failed to hash code: %w", err)
+	}
+
+	_, err = s.storages.OneTimeCode.CreateOneTimeCode(ctx, &entity.OneTimeCode{
+		UserID:    userID,
+		Purpose:   purpose,
+		Phone:     phone,
+		CodeHash:  codeHash,
+		ExpiresAt: time.Now().Add(s.cfg.OTP.CodeLifetime),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create code: %w", err)
+	}
+
+	err = s.smsSender.Send(ctx, &sms.Message{
+		To:   phone,
+		Body: fmt.Sprintf("Your code is %s. It expires in %s. Do not share it with anyone.", code, s.cfg.OTP.CodeLifetime),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+
+	return nil
+}
+
+// verifyOneTimeCode checks the code against the latest user code issued for the purpose and the phone
+// and marks it as used. Every check counts as an attempt, so the code is blocked after too many wrong guesses.
+func (s *userService) verifyOneTimeCode(ctx context.Context, userID, phone, purpose, code string) (bool, error) {
+	oneTimeCode, err := s.storages.OneTimeCode.GetOneTimeCode(ctx, GetOneTimeCodeFilter{
+		UserID:  userID,
+		Purpose: purpose,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get code: %w", err)
+	}
+	if oneTimeCode == nil || oneTimeCode.Phone != phone || !oneTimeCode.IsUsable(time.Now(), s.cfg.OTP.MaxAttempts) {
+		return false, nil
+	}
+
+	hasAttemptsLeft, err := s.storages.OneTimeCode.IncrementOneTimeCodeAttempts(ctx, oneTimeCode.ID, s.cfg.OTP.MaxAttempts)
+	if err != nil {
+		return false, fmt.Errorf("failed to increment code attempts: %w", err)
+	}
+	if !hasAttemptsLeft {
+		return false, nil
+	}
+
+	isCodeEqual, err := s.passwordHasher.CompareHashAndPassword(&password.CompareHashAndPasswordOptions{
+		Hashed:   oneTimeCode.CodeHash,
+		Password: strings.TrimSpace(code),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to compare code: %w", err)
+	}
+	if !isCodeEqual {
+		return false, nil
+	}
+
+	return s.storages.OneTimeCode.UseOneTimeCode(ctx, oneTimeCode.ID)
+}
+
+// normalizePhone removes formatting characters from the phone number and checks if it is in E.164 format.
+func normalizePhone(phone string) (string, bool) {
+	phone = phoneSeparatorsReplacer.Replace(strings.TrimSpace(phone))
+	return phone, phoneRegexp.MatchString(phone)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/pkg/policy"
+)
+
+var _ PolicyService = (*policyService)(nil)
+
+type policyService struct {
+	serviceContext
+}
+
+func NewPolicyService(options Options) *policyService {
+	return &policyService{
+		serviceContext: serviceContext{
+			storages:     options.Storages,
+			cfg:          options.Config,
+			logger:       options.Logger.Named("policyService"),
+			policyEngine: options.PolicyEngine,
+		},
+	}
+}
+
+func (s *policyService) ListPolicies(ctx context.Context) ([]policy.Policy, error) {
+	logger := s.logger.
+		Named("ListPolicies").
+		WithContext(ctx)
+
+	err := authorizePermission(ctx, entity.PermissionPoliciesEvaluate)
+	if err != nil {
+		logger.Info("principal is not allowed to list policies")
+		return nil, err
+	}
+
+	policies := s.policyEngine.Policies()
+
+	logger.Info("successfully listed policies", "count", len(policies))
+	return policies, nil
+}
+
+func (s *policyService) EvaluatePolicies(ctx context.Context, opts EvaluatePoliciesOptions) (*policy.Decision, error) {
+	logger := s.logger.
+		Named("EvaluatePolicies").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionPoliciesEvaluate)
+	if err != nil {
+		logger.Info("principal is not allowed to evaluate policies")
+		return nil, err
+	}
+
+	engine := s.policyEngine
+	if opts.Policies != nil {
+		engine, err = policy.New(opts.Policies)
+		if err != nil {
+			logger.Info("invalid policies", "err", err)
+			return nil, ErrEvaluatePoliciesInvalidPolicy
+		}
+	}
+
+	decision := engine.Evaluate(opts.Request)
+
+	logger.Info("successfully evaluated policies", "decision", decision)
+	return &decision, nil
+}
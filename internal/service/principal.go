@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/pkg/policy"
+)
+
+// PrincipalContextKey is the context key the authenticated Principal is stored under.
+// It is a string, so gin.Context passed as context.Context resolves it from the values set with c.Set.
+const PrincipalContextKey = "principal"
+
+// Principal is the authenticated user performing the operation.
+type Principal struct {
+	UserID string
+	// IsAdmin grants all permissions.
+	IsAdmin bool
+	// Permissions are granted by the user roles.
+	Permissions []string
+	// Groups are names of groups user is an effective member of.
+	Groups []string
+	// EmailAddress and AuthMethods are exposed to policies as subject attributes.
+	EmailAddress string
+	AuthMethods  []string
+	// AuthTime is the time the user authenticated within the session last time.
+	AuthTime time.Time
+}
+
+// HasPermission returns true if the principal is an admin or has the permission granted by a role.
+func (p *Principal) HasPermission(permission string) bool {
+	if p.IsAdmin {
+		return true
+	}
+	for _, granted := range p.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// policyAttributes returns the principal attributes policies are evaluated with.
+func (p *Principal) policyAttributes() policy.Attributes {
+	return policy.Attributes{
+		"id":          p.UserID,
+		"isAdmin":     p.IsAdmin,
+		"permissions": p.Permissions,
+		"groups":      p.Groups,
+		"authMethods": p.AuthMethods,
+		"emailDomain": emailDomain(p.EmailAddress),
+	}
+}
+
+// userPolicyAttributes returns the user attributes policies are evaluated with. Only id is set if user does not exist.
+func userPolicyAttributes(userID string, user *entity.User) policy.Attributes {
+	if user == nil {
+		return policy.Attributes{"id": userID}
+	}
+	return policy.Attributes{
+		"id":            user.ID,
+		"emailAddress":  user.EmailAddress,
+		"emailDomain":   emailDomain(user.EmailAddress),
+		"isAdmin":       user.IsAdmin,
+		"emailVerified": user.IsEmailVerified(),
+		"phoneVerified": user.PhoneVerifiedAt != nil,
+		"mfaEnabled":    user.IsMFAEnabled(),
+	}
+}
+
+// emailDomain returns the lowercased part of email after "@".
+func emailDomain(emailAddress string) string {
+	at := strings.LastIndex(emailAddress, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(emailAddress[at+1:])
+}
+
+// ContextWithPrincipal returns the copy of context with the principal.
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, PrincipalContextKey, principal)
+}
+
+// PrincipalFromContext returns the principal from context or nil if the operation is not authenticated.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(PrincipalContextKey).(*Principal)
+	return principal
+}
+
+// authorizeUserAccess returns ErrForbidden unless the principal is the user itself or has the permission
+// (the action). Policies, if any, are evaluated against the user and override this rule when they apply.
+func (s *serviceContext) authorizeUserAccess(ctx context.Context, userID, action string) error {
+	principal := PrincipalFromContext(ctx)
+	if principal == nil {
+		return ErrForbidden
+	}
+	isAllowed := principal.UserID == userID || principal.HasPermission(action)
+	if len(s.policyEngine.Policies()) == 0 {
+		if !isAllowed {
+			return ErrForbidden
+		}
+		return nil
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	decision := s.policyEngine.Evaluate(policy.Request{
+		Subject:  principal.policyAttributes(),
+		Resource: userPolicyAttributes(userID, user),
+		Action:   action,
+	})
+	switch decision.Effect {
+	case policy.EffectAllow:
+		return nil
+	case policy.EffectDeny:
+		return ErrForbidden
+	}
+	if !isAllowed {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// authorizeSelf returns ErrForbidden unless the principal is the user itself.
+func authorizeSelf(ctx context.Context, userID string) error {
+	principal := PrincipalFromContext(ctx)
+	if principal == nil || principal.UserID != userID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// authorizePermission returns ErrForbidden unless the principal has the permission.
+func authorizePermission(ctx context.Context, permission string) error {
+	principal := PrincipalFromContext(ctx)
+	if principal == nil || !principal.HasPermission(permission) {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/pkg/errs"
+	"github.com/taraslis453/solid-software-test/pkg/rebac"
+)
+
+// userRelationNamespace is the namespace users are referenced in as subjects, e.g. "user:<id>".
+const userRelationNamespace = "user"
+
+// consistencyTokenPrefix versions the consistency token format.
+const consistencyTokenPrefix = "r1:"
+
+var _ RelationService = (*relationService)(nil)
+
+type relationService struct {
+	serviceContext
+	engine *rebac.Engine
+}
+
+func NewRelationService(options Options) *relationService {
+	return &relationService{
+		serviceContext: serviceContext{
+			storages:     options.Storages,
+			cfg:          options.Config,
+			logger:       options.Logger.Named("relationService"),
+			policyEngine: options.PolicyEngine,
+		},
+		engine: options.RelationEngine,
+	}
+}
+
+func (s *relationService) WriteRelationTuples(ctx context.Context, opts WriteRelationTuplesOptions) (*WriteRelationTuplesOutput, error) {
+	logger := s.logger.
+		Named("WriteRelationTuples").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionRelationsManage)
+	if err != nil {
+		logger.Info("principal is not allowed to write relation tuples")
+		return nil, err
+	}
+
+	// Deleted tuples are not validated, so tuples of relations removed from namespaces can be cleaned up
+	for _, tuple := range opts.Writes {
+		err = s.engine.ValidateTuple(tuple)
+		if err != nil {
+			logger.Info("invalid tuple", "tuple", tuple, "err", err)
+			return nil, errs.WithDetails(ErrWriteRelationTuplesInvalidTuple, fmt.Sprintf("%s: %s", tuple, err))
+		}
+	}
+
+	revision, err := s.storages.RelationTuple.WriteRelationTuples(ctx, toRelationTuples(opts.Writes), toRelationTuples(opts.Deletes))
+	if err != nil {
+		logger.Error("failed to write relation tuples", "err", err)
+		return nil, fmt.Errorf("failed to write relation tuples: %w", err)
+	}
+
+	logger.Info("successfully wrote relation tuples", "revision", revision)
+	return &WriteRelationTuplesOutput{
+		ConsistencyToken: encodeConsistencyToken(revision),
+	}, nil
+}
+
+func (s *relationService) CheckRelation(ctx context.Context, opts CheckRelationOptions) (*CheckRelationOutput, error) {
+	logger := s.logger.
+		Named("CheckRelation").
+		WithContext(ctx).
+		With("opts", opts)
+
+	// Users can check their own access, checking others reveals who has access to what
+	err := authorizeRelationSubject(ctx, opts.Subject)
+	if err != nil {
+		logger.Info("principal is not allowed to check relations of the subject")
+		return nil, err
+	}
+
+	err = s.engine.ValidateObjectRelation(opts.Object, opts.Relation)
+	if err == nil {
+		err = s.engine.ValidateSubject(opts.Subject)
+	}
+	if err != nil {
+		logger.Info("invalid relation", "err", err)
+		return nil, errs.WithDetails(ErrCheckRelationInvalidRelation, err.Error())
+	}
+
+	reader, err := s.snapshotReader(ctx, opts.ConsistencyToken)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("invalid consistency token", "err", err)
+			return nil, ErrCheckRelationInvalidConsistencyToken
+		}
+		logger.Error("failed to get snapshot reader", "err", err)
+		return nil, fmt.Errorf("failed to get snapshot reader: %w", err)
+	}
+
+	isAllowed, err := s.engine.Check(ctx, reader, opts.Object, opts.Relation, opts.Subject)
+	if err != nil {
+		logger.Error("failed to check relation", "err", err)
+		return nil, fmt.Errorf("failed to check relation: %w", err)
+	}
+
+	logger.Info("successfully checked relation", "allowed", isAllowed, "revision", reader.revision)
+	return &CheckRelationOutput{
+		Allowed:          isAllowed,
+		ConsistencyToken: encodeConsistencyToken(reader.revision),
+	}, nil
+}
+
+func (s *relationService) ExpandRelation(ctx context.Context, opts ExpandRelationOptions) (*ExpandRelationOutput, error) {
+	logger := s.logger.
+		Named("ExpandRelation").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionRelationsRead)
+	if err != nil {
+		logger.Info("principal is not allowed to expand relations")
+		return nil, err
+	}
+
+	err = s.engine.ValidateObjectRelation(opts.Object, opts.Relation)
+	if err != nil {
+		logger.Info("invalid relation", "err", err)
+		return nil, errs.WithDetails(ErrExpandRelationInvalidRelation, err.Error())
+	}
+
+	reader, err := s.snapshotReader(ctx, opts.ConsistencyToken)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("invalid consistency token", "err", err)
+			return nil, ErrExpandRelationInvalidConsistencyToken
+		}
+		logger.Error("failed to get snapshot reader", "err", err)
+		return nil, fmt.Errorf("failed to get snapshot reader: %w", err)
+	}
+
+	tree, err := s.engine.Expand(ctx, reader, opts.Object, opts.Relation)
+	if err != nil {
+		logger.Error("failed to expand relation", "err", err)
+		return nil, fmt.Errorf("failed to expand relation: %w", err)
+	}
+
+	logger.Info("successfully expanded relation", "revision", reader.revision)
+	return &ExpandRelationOutput{
+		Tree:             tree,
+		ConsistencyToken: encodeConsistencyToken(reader.revision),
+	}, nil
+}
+
+func (s *relationService) ListRelationObjects(ctx context.Context, opts ListRelationObjectsOptions) (*ListRelationObjectsOutput, error) {
+	logger := s.logger.
+		Named("ListRelationObjects").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizeRelationSubject(ctx, opts.Subject)
+	if err != nil {
+		logger.Info("principal is not allowed to list objects of the subject")
+		return nil, err
+	}
+
+	err = s.engine.ValidateObjectRelation(rebac.Object{Namespace: opts.Namespace}, opts.Relation)
+	if err == nil {
+		err = s.engine.ValidateSubject(opts.Subject)
+	}
+	if err != nil {
+		logger.Info("invalid relation", "err", err)
+		return nil, errs.WithDetails(ErrListRelationObjectsInvalidRelation, err.Error())
+	}
+
+	reader, err := s.snapshotReader(ctx, opts.ConsistencyToken)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("invalid consistency token", "err", err)
+			return nil, ErrListRelationObjectsInvalidConsistencyToken
+		}
+		logger.Error("failed to get snapshot reader", "err", err)
+		return nil, fmt.Errorf("failed to get snapshot reader: %w", err)
+	}
+
+	objectIDs, err := s.engine.ListObjects(ctx, reader, opts.Namespace, opts.Relation, opts.Subject)
+	if err != nil {
+		logger.Error("failed to list objects", "err", err)
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	logger.Info("successfully listed objects", "count", len(objectIDs), "revision", reader.revision)
+	return &ListRelationObjectsOutput{
+		ObjectIDs:        objectIDs,
+		ConsistencyToken: encodeConsistencyToken(reader.revision),
+	}, nil
+}
+
+// snapshotReader returns the reader of tuples at the latest revision. errInvalidConsistencyToken is returned
+// if the token is malformed or refers to a revision which does not exist yet.
+func (s *relationService) snapshotReader(ctx context.Context, consistencyToken string) (*relationTupleReader, error) {
+	var minRevision int64
+	if consistencyToken != "" {
+		var err error
+		minRevision, err = decodeConsistencyToken(consistencyToken)
+		if err != nil {
+			return nil, errInvalidConsistencyToken
+		}
+	}
+
+	// All tuples of the request are read at the same revision, so concurrent writes do not affect the result
+	revision, err := s.storages.RelationTuple.GetRelationTupleRevision(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relation tuple revision: %w", err)
+	}
+	if minRevision > revision {
+		return nil, errInvalidConsistencyToken
+	}
+
+	return &relationTupleReader{storage: s.storages.RelationTuple, revision: revision}, nil
+}
+
+// errInvalidConsistencyToken is replaced with the error of the method by callers.
+var errInvalidConsistencyToken = errs.New("invalid consistency token", invalidTokenErrCode)
+
+// relationTupleReader reads relation tuples existing at the revision.
+type relationTupleReader struct {
+	storage  RelationTupleStorage
+	revision int64
+}
+
+func (r *relationTupleReader) ReadTuples(ctx context.Context, object rebac.Object, relation string) ([]rebac.Tuple, error) {
+	relationTuples, err := r.storage.ListRelationTuples(ctx, ListRelationTuplesFilter{
+		Namespace: object.Namespace,
+		ObjectID:  object.ID,
+		Relation:  relation,
+		Revision:  r.revision,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list relation tuples: %w", err)
+	}
+
+	tuples := make([]rebac.Tuple, 0, len(relationTuples))
+	for _, tuple := range relationTuples {
+		tuples = append(tuples, rebac.Tuple{
+			Object:   rebac.Object{Namespace: tuple.Namespace, ID: tuple.ObjectID},
+			Relation: tuple.Relation,
+			Subject: rebac.Subject{
+				Namespace: tuple.SubjectNamespace,
+				ID:        tuple.SubjectID,
+				Relation:  tuple.SubjectRelation,
+			},
+		})
+	}
+
+	return tuples, nil
+}
+
+func (r *relationTupleReader) ListObjectIDs(ctx context.Context, namespace string) ([]string, error) {
+	return r.storage.ListRelationObjectIDs(ctx, namespace, r.revision)
+}
+
+// authorizeRelationSubject returns ErrForbidden unless the subject is the principal itself
+// or the principal has the permission to read relations of any subject.
+func authorizeRelationSubject(ctx context.Context, subject rebac.Subject) error {
+	principal := PrincipalFromContext(ctx)
+	if principal == nil {
+		return ErrForbidden
+	}
+	isSelf := subject == rebac.Subject{Namespace: userRelationNamespace, ID: principal.UserID}
+	if !isSelf && !principal.HasPermission(entity.PermissionRelationsRead) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// toRelationTuples converts tuples to the stored form.
+func toRelationTuples(tuples []rebac.Tuple) []entity.RelationTuple {
+	relationTuples := make([]entity.RelationTuple, 0, len(tuples))
+	for _, tuple := range tuples {
+		relationTuples = append(relationTuples, entity.RelationTuple{
+			Namespace:        tuple.Object.Namespace,
+			ObjectID:         tuple.Object.ID,
+			Relation:         tuple.Relation,
+			SubjectNamespace: tuple.Subject.Namespace,
+			SubjectID:        tuple.Subject.ID,
+			SubjectRelation:  tuple.Subject.Relation,
+		})
+	}
+	return relationTuples
+}
+
+// encodeConsistencyToken returns the opaque token referring to the revision.
+func encodeConsistencyToken(revision int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(consistencyTokenPrefix + strconv.FormatInt(revision, 10)))
+}
+
+// decodeConsistencyToken returns the revision the token refers to.
+func decodeConsistencyToken(token string) (int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode token: %w", err)
+	}
+	if !strings.HasPrefix(string(data), consistencyTokenPrefix) {
+		return 0, fmt.Errorf("token has unknown format")
+	}
+	revision, err := strconv.ParseInt(strings.TrimPrefix(string(data), consistencyTokenPrefix), 10, 64)
+	if err != nil || revision < 0 {
+		return 0, fmt.Errorf("token has invalid revision")
+	}
+
+	return revision, nil
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+)
+
+const (
+	// AdminRoleName is the default role granting all permissions.
+	AdminRoleName = "admin"
+	// SupportRoleName is the default role allowed to look up and unlock users.
+	SupportRoleName = "support"
+)
+
+// defaultPermissions are all permissions roles can grant.
+var defaultPermissions = []entity.Permission{
+	{Name: entity.PermissionUsersRead, Description: "Read any user"},
+	{Name: entity.PermissionUsersUpdate, Description: "Update any user"},
+	{Name: entity.PermissionUsersDelete, Description: "Delete any user"},
+	{Name: entity.PermissionUsersUnlock, Description: "Unlock users locked out after failed logins"},
+	{Name: entity.PermissionRolesManage, Description: "Create roles and assign them to users"},
+	{Name: entity.PermissionPoliciesEvaluate, Description: "View access policies and test requests against them"},
+	{Name: entity.PermissionOrganizationsManage, Description: "Create organizations"},
+	{Name: entity.PermissionMembersManage, Description: "Invite, list and remove organization members"},
+	{Name: entity.PermissionGroupsManage, Description: "Create groups and manage their members"},
+	{Name: entity.PermissionRelationsRead, Description: "Check and expand relations of any subject"},
+	{Name: entity.PermissionRelationsManage, Description: "Write relation tuples"},
+}
+
+var _ RoleService = (*roleService)(nil)
+
+type roleService struct {
+	serviceContext
+}
+
+func NewRoleService(options Options) *roleService {
+	return &roleService{
+		serviceContext: serviceContext{
+			storages:     options.Storages,
+			cfg:          options.Config,
+			logger:       options.Logger.Named("roleService"),
+			policyEngine: options.PolicyEngine,
+		},
+	}
+}
+
+func (s *roleService) SeedDefaultRoles(ctx context.Context) error {
+	logger := s.logger.
+		Named("SeedDefaultRoles").
+		WithContext(ctx)
+
+	roles := []entity.Role{
+		{
+			Name:        AdminRoleName,
+			Description: "Full access",
+			Permissions: defaultPermissions,
+		},
+		{
+			Name:        SupportRoleName,
+			Description: "Customer support",
+			Permissions: []entity.Permission{
+				{Name: entity.PermissionUsersRead},
+				{Name: entity.PermissionUsersUnlock},
+			},
+		},
+	}
+	err := s.storages.Role.SeedRoles(ctx, defaultPermissions, roles)
+	if err != nil {
+		logger.Error("failed to seed roles", "err", err)
+		return fmt.Errorf("failed to seed roles: %w", err)
+	}
+
+	logger.Info("successfully seeded default roles")
+	return nil
+}
+
+func (s *roleService) ListPermissions(ctx context.Context) ([]entity.Permission, error) {
+	logger := s.logger.
+		Named("ListPermissions").
+		WithContext(ctx)
+
+	err := authorizePermission(ctx, entity.PermissionRolesManage)
+	if err != nil {
+		logger.Info("principal is not allowed to list permissions")
+		return nil, err
+	}
+
+	permissions, err := s.storages.Role.ListPermissions(ctx)
+	if err != nil {
+		logger.Error("failed to list permissions", "err", err)
+		return nil, fmt.Errorf("failed to list permissions: %w", err)
+	}
+
+	logger.Info("successfully listed permissions", "count", len(permissions))
+	return permissions, nil
+}
+
+func (s *roleService) ListRoles(ctx context.Context) ([]entity.Role, error) {
+	logger := s.logger.
+		Named("ListRoles").
+		WithContext(ctx)
+
+	err := authorizePermission(ctx, entity.PermissionRolesManage)
+	if err != nil {
+		logger.Info("principal is not allowed to list roles")
+		return nil, err
+	}
+
+	roles, err := s.storages.Role.ListRoles(ctx)
+	if err != nil {
+		logger.Error("failed to list roles", "err", err)
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	logger.Info("successfully listed roles", "count", len(roles))
+	return roles, nil
+}
+
+func (s *roleService) CreateRole(ctx context.Context, opts CreateRoleOptions) (*entity.Role, error) {
+	logger := s.logger.
+		Named("CreateRole").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionRolesManage)
+	if err != nil {
+		logger.Info("principal is not allowed to create roles")
+		return nil, err
+	}
+
+	existingRole, err := s.storages.Role.GetRole(ctx, GetRoleFilter{
+		Name: &opts.Name,
+	})
+	if err != nil {
+		logger.Error("failed to get role", "err", err)
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	if existingRole != nil {
+		logger.Info("role already exists")
+		return nil, ErrCreateRoleRoleAlreadyExists
+	}
+
+	knownPermissions, err := s.storages.Role.ListPermissions(ctx)
+	if err != nil {
+		logger.Error("failed to list permissions", "err", err)
+		return nil, fmt.Errorf("failed to list permissions: %w", err)
+	}
+	known := make(map[string]bool, len(knownPermissions))
+	for _, permission := range knownPermissions {
+		known[permission.Name] = true
+	}
+
+	permissions := make([]entity.Permission, 0, len(opts.Permissions))
+	for _, name := range opts.Permissions {
+		if !known[name] {
+			logger.Info("unknown permission", "permission", name)
+			return nil, ErrCreateRoleUnknownPermission
+		}
+		permissions = append(permissions, entity.Permission{Name: name})
+	}
+
+	role, err := s.storages.Role.CreateRole(ctx, &entity.Role{
+		Name:        opts.Name,
+		Description: opts.Description,
+		Permissions: permissions,
+	})
+	if err != nil {
+		logger.Error("failed to create role", "err", err)
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	logger.Info("successfully created role", "roleID", role.ID)
+	return role, nil
+}
+
+func (s *roleService) AssignUserRole(ctx context.Context, opts AssignUserRoleOptions) error {
+	logger := s.logger.
+		Named("AssignUserRole").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionRolesManage)
+	if err != nil {
+		logger.Info("principal is not allowed to assign roles")
+		return err
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &opts.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return ErrAssignUserRoleUserNotFound
+	}
+
+	role, err := s.storages.Role.GetRole(ctx, GetRoleFilter{
+		ID: &opts.RoleID,
+	})
+	if err != nil {
+		logger.Error("failed to get role", "err", err)
+		return fmt.Errorf("failed to get role: %w", err)
+	}
+	if role == nil {
+		logger.Info("role not found")
+		return ErrAssignUserRoleRoleNotFound
+	}
+
+	err = s.storages.Role.AssignUserRole(ctx, user.ID, role.ID)
+	if err != nil {
+		logger.Error("failed to assign user role", "err", err)
+		return fmt.Errorf("failed to assign user role: %w", err)
+	}
+
+	logger.Info("successfully assigned user role")
+	return nil
+}
+
+func (s *roleService) UnassignUserRole(ctx context.Context, opts UnassignUserRoleOptions) error {
+	logger := s.logger.
+		Named("UnassignUserRole").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionRolesManage)
+	if err != nil {
+		logger.Info("principal is not allowed to unassign roles")
+		return err
+	}
+
+	role, err := s.storages.Role.GetRole(ctx, GetRoleFilter{
+		ID: &opts.RoleID,
+	})
+	if err != nil {
+		logger.Error("failed to get role", "err", err)
+		return fmt.Errorf("failed to get role: %w", err)
+	}
+	if role == nil {
+		logger.Info("role not found")
+		return ErrUnassignUserRoleRoleNotFound
+	}
+
+	err = s.storages.Role.UnassignUserRole(ctx, opts.UserID, role.ID)
+	if err != nil {
+		logger.Error("failed to unassign user role", "err", err)
+		return fmt.Errorf("failed to unassign user role: %w", err)
+	}
+
+	logger.Info("successfully unassigned user role")
+	return nil
+}
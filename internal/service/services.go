@@ -2,17 +2,29 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/taraslis453/solid-software-test/config"
+	"github.com/taraslis453/solid-software-test/pkg/encryption"
 	"github.com/taraslis453/solid-software-test/pkg/errs"
 	"github.com/taraslis453/solid-software-test/pkg/logging"
+	"github.com/taraslis453/solid-software-test/pkg/mailer"
 	"github.com/taraslis453/solid-software-test/pkg/password"
+	"github.com/taraslis453/solid-software-test/pkg/policy"
+	"github.com/taraslis453/solid-software-test/pkg/rebac"
+	"github.com/taraslis453/solid-software-test/pkg/sms"
+	"github.com/taraslis453/solid-software-test/pkg/webauthn"
 
 	"github.com/taraslis453/solid-software-test/internal/entity"
 )
 
 type Services struct {
-	User UserService
+	User         UserService
+	Role         RoleService
+	Policy       PolicyService
+	Organization OrganizationService
+	Group        GroupService
+	Relation     RelationService
 }
 
 // serviceContext provides a shared context for all services
@@ -20,21 +32,83 @@ type serviceContext struct {
 	storages Storages
 	cfg      *config.Config
 	logger   logging.Logger
+	// policyEngine evaluates access policies on top of roles.
+	policyEngine *policy.Engine
 }
 
 // Options is used to parameterize service
 type Options struct {
-	Storages       Storages
-	Config         *config.Config
-	Logger         logging.Logger
-	PasswordHasher password.Hasher
+	Storages          Storages
+	Config            *config.Config
+	Logger            logging.Logger
+	PasswordHasher    password.Hasher
+	PasswordValidator password.Validator
+	// PasswordBreachChecker is optional, passwords are not checked against breaches if it is nil.
+	PasswordBreachChecker password.BreachChecker
+	Mailer                mailer.Mailer
+	SMSSender             sms.Sender
+	// SecretEncryptor is used to encrypt user secrets (e.g. TOTP secret) stored in database.
+	SecretEncryptor encryption.Encryptor
+	// WebAuthn is the relying party used for passkey registration and login ceremonies.
+	WebAuthn *webauthn.RelyingParty
+	// PolicyEngine evaluates access policies on top of roles, it may have no policies.
+	PolicyEngine *policy.Engine
+	// RelationEngine evaluates relation tuples according to namespaces, it may have no namespaces.
+	RelationEngine *rebac.Engine
 }
 
 const (
 	userNotFoundErrCode      = "user_not_found"
 	userAlreadyExistsErrCode = "user_already_exists"
+	invalidFilterErrCode     = "invalid_filter"
+	invalidCursorErrCode     = "invalid_cursor"
+	reauthRequiredErrCode    = "reauth_required"
 
-	invalidPasswordErrCode = "invalid_password"
+	invalidPasswordErrCode    = "invalid_password"
+	invalidCredentialsErrCode = "invalid_credentials"
+	weakPasswordErrCode       = "weak_password"
+	passwordReusedErrCode     = "password_reused"
+
+	mfaAlreadyEnabledErrCode = "mfa_already_enabled"
+	mfaNotEnrolledErrCode    = "mfa_not_enrolled"
+	mfaNotEnabledErrCode     = "mfa_not_enabled"
+	invalidMFACodeErrCode    = "invalid_mfa_code"
+
+	invalidWebAuthnResponseErrCode  = "invalid_webauthn_response"
+	webAuthnCredentialExistsErrCode = "webauthn_credential_already_exists"
+
+	phoneNotSetErrCode          = "phone_not_set"
+	invalidPhoneErrCode         = "invalid_phone"
+	phoneAlreadyVerifiedErrCode = "phone_already_verified"
+	phoneAlreadyUsedErrCode     = "phone_already_used"
+	invalidCodeErrCode          = "invalid_code"
+	tooManyRequestsErrCode      = "too_many_requests"
+	accountLockedErrCode        = "account_locked"
+
+	emailNotVerifiedErrCode = "email_not_verified"
+	emailNotChangedErrCode  = "email_not_changed"
+	emailAlreadyUsedErrCode = "email_already_used"
+
+	forbiddenErrCode = "forbidden"
+
+	roleNotFoundErrCode      = "role_not_found"
+	roleAlreadyExistsErrCode = "role_already_exists"
+	unknownPermissionErrCode = "unknown_permission"
+
+	invalidPolicyErrCode = "invalid_policy"
+
+	organizationNotFoundErrCode      = "organization_not_found"
+	organizationAlreadyExistsErrCode = "organization_already_exists"
+	invalidSlugErrCode               = "invalid_slug"
+	notMemberErrCode                 = "not_member"
+	homeOrganizationErrCode          = "home_organization"
+	registrationRequiredErrCode      = "registration_required"
+
+	groupNotFoundErrCode      = "group_not_found"
+	groupAlreadyExistsErrCode = "group_already_exists"
+	groupCycleErrCode         = "group_cycle"
+
+	invalidRelationErrCode = "invalid_relation"
 
 	invalidTokenErrCode = "invalid_token"
 	tokenExpiredErrCode = "token_expired"
@@ -44,36 +118,355 @@ type UserService interface {
 	// RegisterUser is used to register a new user.
 	RegisterUser(ctx context.Context, opt RegisterUserOptions) error
 	// LoginUser is used to login a user.
+	// If user has MFA enabled, returns MFA challenge token instead of access and refresh tokens.
 	LoginUser(ctx context.Context, opt LoginUserOptions) (LoginUserOutput, error)
+	// UnlockUser is used to reset failed login attempts of a user locked out after too many of them.
+	UnlockUser(ctx context.Context, userID string) error
+	// LoginUserMFA is used to complete login of a user with enabled MFA by challenge token and TOTP code.
+	LoginUserMFA(ctx context.Context, opts LoginUserMFAOptions) (LoginUserOutput, error)
+	// SendUserMagicLink is used to email the single-use passwordless login link to the user.
+	// It does not report whether the user exists or the link has been sent recently.
+	SendUserMagicLink(ctx context.Context, opts SendUserMagicLinkOptions) error
+	// LoginUserMagicLink is used to login a user by the magic link token.
+	// If user has MFA enabled, returns MFA challenge token instead of access and refresh tokens.
+	LoginUserMagicLink(ctx context.Context, opts LoginUserMagicLinkOptions) (LoginUserOutput, error)
+	// VerifyUserEmail is used to mark the user email as verified by the token sent to it.
+	VerifyUserEmail(ctx context.Context, opts VerifyUserEmailOptions) error
+	// ResendUserEmailVerification is used to send the verification email again, not more often than cooldown allows.
+	// It does not report whether the user exists.
+	ResendUserEmailVerification(ctx context.Context, opts ResendUserEmailVerificationOptions) error
+	// RequestUserEmailChange is used to send the confirmation link to the new user email
+	// and the notification with undo link to the current one. Email is changed only after confirmation.
+	RequestUserEmailChange(ctx context.Context, opts RequestUserEmailChangeOptions) error
+	// ConfirmUserEmailChange is used to change the user email by the confirmation token if it is not used by another user.
+	ConfirmUserEmailChange(ctx context.Context, opts ConfirmUserEmailChangeOptions) error
+	// UndoUserEmailChange is used to cancel the pending email change or revert the confirmed one by the undo token
+	// and revoke all user sessions.
+	UndoUserEmailChange(ctx context.Context, opts UndoUserEmailChangeOptions) error
+	// SendUserPhoneVerificationCode is used to send the one-time code to the user phone to verify it.
+	SendUserPhoneVerificationCode(ctx context.Context, userID string) error
+	// VerifyUserPhone is used to mark the user phone as verified by the one-time code.
+	VerifyUserPhone(ctx context.Context, opts VerifyUserPhoneOptions) error
+	// SendUserLoginCode is used to send the one-time login code to the verified user phone.
+	// It does not report whether the user exists.
+	SendUserLoginCode(ctx context.Context, opts SendUserLoginCodeOptions) error
+	// LoginUserPhone is used to login a user by the verified phone and one-time code.
+	// If user has MFA enabled, returns MFA challenge token instead of access and refresh tokens.
+	LoginUserPhone(ctx context.Context, opts LoginUserPhoneOptions) (LoginUserOutput, error)
+	// EnrollUserTOTP is used to generate a new TOTP secret for a user. MFA is enabled after ConfirmUserTOTP.
+	EnrollUserTOTP(ctx context.Context, userID string) (*EnrollUserTOTPOutput, error)
+	// ConfirmUserTOTP is used to confirm TOTP enrollment with the first code, enable MFA and generate recovery codes.
+	ConfirmUserTOTP(ctx context.Context, opts ConfirmUserTOTPOptions) (*ConfirmUserTOTPOutput, error)
+	// RegenerateUserMFARecoveryCodes is used to replace all user recovery codes with new ones.
+	RegenerateUserMFARecoveryCodes(ctx context.Context, userID string) (*RegenerateUserMFARecoveryCodesOutput, error)
+	// BeginUserWebAuthnRegistration is used to start registration of a new passkey for a user.
+	BeginUserWebAuthnRegistration(ctx context.Context, userID string) (*BeginUserWebAuthnRegistrationOutput, error)
+	// FinishUserWebAuthnRegistration is used to verify authenticator response and store the new user passkey.
+	FinishUserWebAuthnRegistration(ctx context.Context, opts FinishUserWebAuthnRegistrationOptions) (*entity.WebAuthnCredential, error)
+	// BeginUserWebAuthnLogin is used to start login with a passkey.
+	BeginUserWebAuthnLogin(ctx context.Context, opts BeginUserWebAuthnLoginOptions) (*BeginUserWebAuthnLoginOutput, error)
+	// FinishUserWebAuthnLogin is used to verify the passkey assertion and generate user tokens.
+	// Passkey login does not require MFA, since passkey is already a possession factor verified with user gesture.
+	FinishUserWebAuthnLogin(ctx context.Context, opts FinishUserWebAuthnLoginOptions) (LoginUserOutput, error)
 	// VerifyUserToken is used to verify the user by given token and return verified user entity.
 	GetUser(ctx context.Context, opt GetUserOptions) (*entity.User, error)
+	// ListUsers is used to search users of the organization page by page.
+	ListUsers(ctx context.Context, opts ListUsersOptions) (*ListUsersOutput, error)
 	// UpdateUser is used to update a user.
 	UpdateUser(ctx context.Context, user *entity.User) (*entity.User, error)
-	// DeleteUser is used to delete a user.
+	// DeleteUser is used to delete a user. User can restore the account by logging in within the deletion grace period.
 	DeleteUser(ctx context.Context, id string) error
-	// VerifyUserToken is used to verify the user by given token and return verified user entity.
-	VerifyUserToken(ctx context.Context, token string) (*entity.User, error)
+	// DeleteUserAccount is used to delete the account of the user confirmed with the password or recent authentication.
+	// User can restore the account by logging in within the deletion grace period.
+	DeleteUserAccount(ctx context.Context, opts DeleteUserAccountOptions) error
+	// PurgeDeletedUsers is used to permanently delete users whose deletion grace period is over.
+	PurgeDeletedUsers(ctx context.Context) error
+	// PurgeExpiredAuthData is used to delete expired sessions, tokens, one-time codes and stale login throttles.
+	PurgeExpiredAuthData(ctx context.Context) error
+	// ChangeUserPassword is used to change the password of a user and revoke all other user sessions.
+	ChangeUserPassword(ctx context.Context, opts ChangeUserPasswordOptions) error
+	// ForgotUserPassword is used to send the password reset link to the user email.
+	// It always succeeds, so it does not report whether the user exists.
+	ForgotUserPassword(ctx context.Context, opts ForgotUserPasswordOptions) error
+	// ResetUserPassword is used to set a new user password by the password reset token.
+	ResetUserPassword(ctx context.Context, opts ResetUserPasswordOptions) error
+	// ReauthenticateUser is used to verify user credentials again within the current session
+	// and generate tokens with refreshed authentication time, required for sensitive operations.
+	ReauthenticateUser(ctx context.Context, opts ReauthenticateUserOptions) (*UserTokenOutput, error)
+	// VerifyUserToken is used to verify the user by given token and return verified user entity with token session.
+	VerifyUserToken(ctx context.Context, token string) (*VerifyUserTokenOutput, error)
 	// RefreshUserToken is used to verify refresh token and then generate a new pair of access and refresh tokens.
 	RefreshUserToken(ctx context.Context, tokenStr string) (*UserTokenOutput, error)
-	// GenerateUserToken is used to generate a pair of access and refresh tokens.
-	GenerateUserToken(ctx context.Context, user *entity.User) (*UserTokenOutput, error)
+	// GenerateUserToken is used to create a new session and generate a pair of access and refresh tokens within it.
+	GenerateUserToken(ctx context.Context, opts GenerateUserTokenOptions) (*UserTokenOutput, error)
+	// InviteUserToOrganization is used to email the invitation to join the organization request is scoped to.
+	InviteUserToOrganization(ctx context.Context, opts InviteUserToOrganizationOptions) error
+	// AcceptOrganizationInvitation is used to make the authenticated user a member of the organization,
+	// or to register a new user in it if not authenticated.
+	AcceptOrganizationInvitation(ctx context.Context, opts AcceptOrganizationInvitationOptions) (*entity.Membership, error)
+	// DeclineOrganizationInvitation is used to invalidate the invitation.
+	DeclineOrganizationInvitation(ctx context.Context, opts DeclineOrganizationInvitationOptions) error
+	// SwitchUserOrganization is used to generate tokens scoped to another organization user is a member of.
+	SwitchUserOrganization(ctx context.Context, opts SwitchUserOrganizationOptions) (*UserTokenOutput, error)
 }
 
+type RoleService interface {
+	// SeedDefaultRoles is used to create all permissions and default roles or update them to the defaults.
+	SeedDefaultRoles(ctx context.Context) error
+	// ListPermissions is used to list all permissions roles can grant.
+	ListPermissions(ctx context.Context) ([]entity.Permission, error)
+	// ListRoles is used to list all roles with their permissions.
+	ListRoles(ctx context.Context) ([]entity.Role, error)
+	// CreateRole is used to create a new role granting existing permissions.
+	CreateRole(ctx context.Context, opts CreateRoleOptions) (*entity.Role, error)
+	// AssignUserRole is used to assign the role to the user.
+	AssignUserRole(ctx context.Context, opts AssignUserRoleOptions) error
+	// UnassignUserRole is used to remove the role from the user.
+	UnassignUserRole(ctx context.Context, opts UnassignUserRoleOptions) error
+}
+
+type PolicyService interface {
+	// ListPolicies is used to list loaded access policies.
+	ListPolicies(ctx context.Context) ([]policy.Policy, error)
+	// EvaluatePolicies is used to dry-run the request against loaded or passed policies.
+	EvaluatePolicies(ctx context.Context, opts EvaluatePoliciesOptions) (*policy.Decision, error)
+}
+
+type OrganizationService interface {
+	// SeedDefaultOrganization is used to create the default organization if it does not exist.
+	SeedDefaultOrganization(ctx context.Context) error
+	// GetOrganization is used to resolve the organization request is scoped to.
+	GetOrganization(ctx context.Context, opts GetOrganizationOptions) (*entity.Organization, error)
+	// CreateOrganization is used to create a new organization.
+	CreateOrganization(ctx context.Context, opts CreateOrganizationOptions) (*entity.Organization, error)
+	// ListOrganizationMembers is used to list users of the organization request is scoped to.
+	ListOrganizationMembers(ctx context.Context) ([]entity.Membership, error)
+	// UpdateOrganizationMember is used to set the member role in the organization request is scoped to.
+	UpdateOrganizationMember(ctx context.Context, opts UpdateOrganizationMemberOptions) (*entity.Membership, error)
+	// RemoveOrganizationMember is used to remove the member from the organization request is scoped to.
+	// Users can not be removed from the organization they registered in.
+	RemoveOrganizationMember(ctx context.Context, userID string) error
+}
+
+// GroupService operates on groups of the organization request is scoped to.
+type GroupService interface {
+	// ListGroups is used to list all groups.
+	ListGroups(ctx context.Context) ([]entity.Group, error)
+	// GetGroup is used to get the group with its direct members, subgroups and roles.
+	GetGroup(ctx context.Context, id string) (*entity.Group, error)
+	// CreateGroup is used to create a new group.
+	CreateGroup(ctx context.Context, opts CreateGroupOptions) (*entity.Group, error)
+	// DeleteGroup is used to delete the group, its subgroups are kept.
+	DeleteGroup(ctx context.Context, id string) error
+	// AddGroupMember is used to add the user to the group.
+	AddGroupMember(ctx context.Context, opts AddGroupMemberOptions) error
+	// RemoveGroupMember is used to remove the user from the group.
+	RemoveGroupMember(ctx context.Context, opts RemoveGroupMemberOptions) error
+	// AddGroupSubgroup is used to nest the group into another one, so its members become members of the parent.
+	AddGroupSubgroup(ctx context.Context, opts AddGroupSubgroupOptions) error
+	// RemoveGroupSubgroup is used to remove the group nesting.
+	RemoveGroupSubgroup(ctx context.Context, opts RemoveGroupSubgroupOptions) error
+	// AssignGroupRole is used to grant the role to all effective members of the group.
+	AssignGroupRole(ctx context.Context, opts AssignGroupRoleOptions) error
+	// UnassignGroupRole is used to remove the role from the group.
+	UnassignGroupRole(ctx context.Context, opts UnassignGroupRoleOptions) error
+	// ListUserGroups is used to list groups user is an effective member of, directly or through subgroups.
+	ListUserGroups(ctx context.Context, userID string) ([]entity.Group, error)
+}
+
+// RelationService evaluates relationship-based access of the organization request is scoped to.
+// Reads are evaluated at the latest revision, which is at least as fresh as the passed consistency token,
+// and return the token of that revision.
+type RelationService interface {
+	// WriteRelationTuples is used to delete and create relation tuples atomically.
+	WriteRelationTuples(ctx context.Context, opts WriteRelationTuplesOptions) (*WriteRelationTuplesOutput, error)
+	// CheckRelation is used to check if the subject has the relation to the object.
+	CheckRelation(ctx context.Context, opts CheckRelationOptions) (*CheckRelationOutput, error)
+	// ExpandRelation is used to get the tree of subjects having the relation to the object.
+	ExpandRelation(ctx context.Context, opts ExpandRelationOptions) (*ExpandRelationOutput, error)
+	// ListRelationObjects is used to list IDs of the namespace objects the subject has the relation to.
+	ListRelationObjects(ctx context.Context, opts ListRelationObjectsOptions) (*ListRelationObjectsOutput, error)
+}
+
+// ErrForbidden is returned by any method operating on a user if the principal from context
+// is not the user itself and does not have the permission required by the operation.
+var ErrForbidden = errs.New("operation is not allowed", forbiddenErrCode)
+
 var (
 	ErrRegisterUserUserAlreadyExists = errs.New("user already exists", userAlreadyExistsErrCode)
+	ErrRegisterUserWeakPassword      = errs.New("password does not satisfy password policy", weakPasswordErrCode)
+
+	ErrLoginUserUserNotFound     = errs.New("user not found", userNotFoundErrCode)
+	ErrLoginUserInvalidPassword  = errs.New("invalid password", invalidPasswordErrCode)
+	ErrLoginUserEmailNotVerified = errs.New("email is not verified", emailNotVerifiedErrCode)
+	// ErrLoginUserInvalidCredentials replaces ErrLoginUserUserNotFound and ErrLoginUserInvalidPassword
+	// if anti-enumeration is enabled.
+	ErrLoginUserInvalidCredentials = errs.New("invalid email or password", invalidCredentialsErrCode)
+	// ErrLoginUserAccountLocked and ErrLoginUserTooManyAttempts are returned with RetryAfterDetails.
+	ErrLoginUserAccountLocked   = errs.New("too many failed login attempts, account is temporarily locked", accountLockedErrCode)
+	ErrLoginUserTooManyAttempts = errs.New("too many failed login attempts, try again later", tooManyRequestsErrCode)
 
-	ErrLoginUserUserNotFound    = errs.New("user not found", userNotFoundErrCode)
-	ErrLoginUserInvalidPassword = errs.New("invalid password", invalidPasswordErrCode)
+	ErrUnlockUserUserNotFound = errs.New("user not found", userNotFoundErrCode)
+
+	ErrLoginUserMFAInvalidToken = errs.New("invalid mfa challenge token", invalidTokenErrCode)
+	ErrLoginUserMFAInvalidCode  = errs.New("invalid mfa code", invalidMFACodeErrCode)
+	// ErrLoginUserMFAAccountLocked and ErrLoginUserMFATooManyAttempts are returned with RetryAfterDetails.
+	ErrLoginUserMFAAccountLocked   = errs.New("too many failed login attempts, account is temporarily locked", accountLockedErrCode)
+	ErrLoginUserMFATooManyAttempts = errs.New("too many failed login attempts, try again later", tooManyRequestsErrCode)
+
+	ErrLoginUserMagicLinkInvalidToken = errs.New("invalid magic link token", invalidTokenErrCode)
+
+	ErrSendUserPhoneVerificationCodeUserNotFound         = errs.New("user not found", userNotFoundErrCode)
+	ErrSendUserPhoneVerificationCodePhoneNotSet          = errs.New("phone is not set", phoneNotSetErrCode)
+	ErrSendUserPhoneVerificationCodeInvalidPhone         = errs.New("phone must be in international format", invalidPhoneErrCode)
+	ErrSendUserPhoneVerificationCodePhoneAlreadyVerified = errs.New("phone is already verified", phoneAlreadyVerifiedErrCode)
+	ErrSendUserPhoneVerificationCodeTooManyRequests      = errs.New("code has been sent recently, try again later", tooManyRequestsErrCode)
+
+	ErrVerifyUserPhoneUserNotFound     = errs.New("user not found", userNotFoundErrCode)
+	ErrVerifyUserPhoneInvalidCode      = errs.New("invalid or expired code", invalidCodeErrCode)
+	ErrVerifyUserPhonePhoneAlreadyUsed = errs.New("phone is verified by another user", phoneAlreadyUsedErrCode)
+
+	ErrSendUserLoginCodeInvalidPhone = errs.New("phone must be in international format", invalidPhoneErrCode)
+
+	ErrLoginUserPhoneInvalidCode      = errs.New("invalid or expired code", invalidCodeErrCode)
+	ErrLoginUserPhoneEmailNotVerified = errs.New("email is not verified", emailNotVerifiedErrCode)
+
+	ErrVerifyUserEmailInvalidToken = errs.New("invalid email verification token", invalidTokenErrCode)
+
+	ErrRequestUserEmailChangeUserNotFound     = errs.New("user not found", userNotFoundErrCode)
+	ErrRequestUserEmailChangeSameEmail        = errs.New("new email is the same as the current one", emailNotChangedErrCode)
+	ErrRequestUserEmailChangeEmailAlreadyUsed = errs.New("email is used by another user", emailAlreadyUsedErrCode)
+
+	ErrConfirmUserEmailChangeInvalidToken     = errs.New("invalid email change token", invalidTokenErrCode)
+	ErrConfirmUserEmailChangeEmailAlreadyUsed = errs.New("email is used by another user", emailAlreadyUsedErrCode)
+
+	ErrUndoUserEmailChangeInvalidToken     = errs.New("invalid email change undo token", invalidTokenErrCode)
+	ErrUndoUserEmailChangeEmailAlreadyUsed = errs.New("previous email is used by another user", emailAlreadyUsedErrCode)
+
+	ErrEnrollUserTOTPUserNotFound      = errs.New("user not found", userNotFoundErrCode)
+	ErrEnrollUserTOTPMFAAlreadyEnabled = errs.New("mfa is already enabled", mfaAlreadyEnabledErrCode)
+
+	ErrConfirmUserTOTPUserNotFound      = errs.New("user not found", userNotFoundErrCode)
+	ErrConfirmUserTOTPMFAAlreadyEnabled = errs.New("mfa is already enabled", mfaAlreadyEnabledErrCode)
+	ErrConfirmUserTOTPMFANotEnrolled    = errs.New("mfa enrollment is not started", mfaNotEnrolledErrCode)
+	ErrConfirmUserTOTPInvalidCode       = errs.New("invalid mfa code", invalidMFACodeErrCode)
+
+	ErrRegenerateUserMFARecoveryCodesUserNotFound  = errs.New("user not found", userNotFoundErrCode)
+	ErrRegenerateUserMFARecoveryCodesMFANotEnabled = errs.New("mfa is not enabled", mfaNotEnabledErrCode)
+
+	ErrBeginUserWebAuthnRegistrationUserNotFound = errs.New("user not found", userNotFoundErrCode)
+
+	ErrFinishUserWebAuthnRegistrationInvalidToken     = errs.New("invalid webauthn ceremony token", invalidTokenErrCode)
+	ErrFinishUserWebAuthnRegistrationInvalidResponse  = errs.New("invalid webauthn response", invalidWebAuthnResponseErrCode)
+	ErrFinishUserWebAuthnRegistrationCredentialExists = errs.New("credential is already registered", webAuthnCredentialExistsErrCode)
+
+	ErrFinishUserWebAuthnLoginInvalidToken     = errs.New("invalid webauthn ceremony token", invalidTokenErrCode)
+	ErrFinishUserWebAuthnLoginInvalidResponse  = errs.New("invalid webauthn response", invalidWebAuthnResponseErrCode)
+	ErrFinishUserWebAuthnLoginEmailNotVerified = errs.New("email is not verified", emailNotVerifiedErrCode)
 
 	ErrGetUserUserNotFound = errs.New("user not found", userNotFoundErrCode)
 
+	ErrListUsersInvalidFilter = errs.New("invalid filter", invalidFilterErrCode)
+	ErrListUsersInvalidCursor = errs.New("invalid cursor", invalidCursorErrCode)
+
 	ErrUpdateUserUserNotFound = errs.New("user not found", userNotFoundErrCode)
 
 	ErrDeleteUserUserNotFound = errs.New("user not found", userNotFoundErrCode)
 
+	ErrDeleteUserAccountUserNotFound    = errs.New("user not found", userNotFoundErrCode)
+	ErrDeleteUserAccountInvalidPassword = errs.New("invalid password", invalidPasswordErrCode)
+	ErrDeleteUserAccountInvalidCode     = errs.New("invalid mfa code", invalidMFACodeErrCode)
+	ErrDeleteUserAccountReauthRequired  = errs.New("authentication is too old, confirm with password or reauthenticate to continue", reauthRequiredErrCode)
+	// ErrDeleteUserAccountAccountLocked and ErrDeleteUserAccountTooManyAttempts are returned with RetryAfterDetails.
+	ErrDeleteUserAccountAccountLocked   = errs.New("too many failed login attempts, account is temporarily locked", accountLockedErrCode)
+	ErrDeleteUserAccountTooManyAttempts = errs.New("too many failed login attempts, try again later", tooManyRequestsErrCode)
+
+	ErrChangeUserPasswordUserNotFound    = errs.New("user not found", userNotFoundErrCode)
+	ErrChangeUserPasswordInvalidPassword = errs.New("invalid password", invalidPasswordErrCode)
+	ErrChangeUserPasswordWeakPassword    = errs.New("password does not satisfy password policy", weakPasswordErrCode)
+	ErrChangeUserPasswordPasswordReused  = errs.New("password was used recently", passwordReusedErrCode)
+	// ErrChangeUserPasswordAccountLocked and ErrChangeUserPasswordTooManyAttempts are returned with RetryAfterDetails.
+	ErrChangeUserPasswordAccountLocked   = errs.New("too many failed login attempts, account is temporarily locked", accountLockedErrCode)
+	ErrChangeUserPasswordTooManyAttempts = errs.New("too many failed login attempts, try again later", tooManyRequestsErrCode)
+
+	ErrResetUserPasswordInvalidToken   = errs.New("invalid password reset token", invalidTokenErrCode)
+	ErrResetUserPasswordWeakPassword   = errs.New("password does not satisfy password policy", weakPasswordErrCode)
+	ErrResetUserPasswordPasswordReused = errs.New("password was used recently", passwordReusedErrCode)
+
+	ErrReauthenticateUserInvalidToken    = errs.New("session is not active", invalidTokenErrCode)
+	ErrReauthenticateUserInvalidPassword = errs.New("invalid password", invalidPasswordErrCode)
+	ErrReauthenticateUserInvalidCode     = errs.New("invalid mfa code", invalidMFACodeErrCode)
+	// ErrReauthenticateUserAccountLocked and ErrReauthenticateUserTooManyAttempts are returned with RetryAfterDetails.
+	ErrReauthenticateUserAccountLocked   = errs.New("too many failed login attempts, account is temporarily locked", accountLockedErrCode)
+	ErrReauthenticateUserTooManyAttempts = errs.New("too many failed login attempts, try again later", tooManyRequestsErrCode)
+
 	ErrVerifyUserTokenInvalidToken = errs.New("invalid authenticate token.", invalidTokenErrCode)
 	ErrVerifyUserTokenUserNotFound = errs.New("user not found", userNotFoundErrCode)
 
+	ErrCreateRoleRoleAlreadyExists  = errs.New("role already exists", roleAlreadyExistsErrCode)
+	ErrCreateRoleUnknownPermission  = errs.New("unknown permission", unknownPermissionErrCode)
+	ErrAssignUserRoleUserNotFound   = errs.New("user not found", userNotFoundErrCode)
+	ErrAssignUserRoleRoleNotFound   = errs.New("role not found", roleNotFoundErrCode)
+	ErrUnassignUserRoleRoleNotFound = errs.New("role not found", roleNotFoundErrCode)
+
+	ErrEvaluatePoliciesInvalidPolicy = errs.New("invalid policy", invalidPolicyErrCode)
+
+	ErrGetOrganizationOrganizationNotFound         = errs.New("organization not found", organizationNotFoundErrCode)
+	ErrCreateOrganizationInvalidSlug               = errs.New("slug has to consist of lowercase letters, digits and hyphens", invalidSlugErrCode)
+	ErrCreateOrganizationOrganizationAlreadyExists = errs.New("organization already exists", organizationAlreadyExistsErrCode)
+
+	ErrUpdateOrganizationMemberNotMember    = errs.New("user is not a member of organization", notMemberErrCode)
+	ErrUpdateOrganizationMemberRoleNotFound = errs.New("role not found", roleNotFoundErrCode)
+
+	ErrRemoveOrganizationMemberNotMember        = errs.New("user is not a member of organization", notMemberErrCode)
+	ErrRemoveOrganizationMemberHomeOrganization = errs.New("user can not be removed from organization user registered in", homeOrganizationErrCode)
+
+	ErrInviteUserToOrganizationRoleNotFound = errs.New("role not found", roleNotFoundErrCode)
+
+	ErrAcceptOrganizationInvitationInvalidToken         = errs.New("invalid invitation token", invalidTokenErrCode)
+	ErrAcceptOrganizationInvitationRegistrationRequired = errs.New("login or provide registration data to accept invitation", registrationRequiredErrCode)
+	ErrAcceptOrganizationInvitationEmailMismatch        = errs.New("invitation is sent to another email", forbiddenErrCode)
+	ErrAcceptOrganizationInvitationUserAlreadyExists    = errs.New("user already exists, login to accept invitation", userAlreadyExistsErrCode)
+	ErrAcceptOrganizationInvitationEmailAlreadyUsed     = errs.New("email is already used in organization", emailAlreadyUsedErrCode)
+
+	ErrDeclineOrganizationInvitationInvalidToken = errs.New("invalid invitation token", invalidTokenErrCode)
+
+	ErrSwitchUserOrganizationNotMember = errs.New("user is not a member of organization", notMemberErrCode)
+
+	ErrGetGroupGroupNotFound = errs.New("group not found", groupNotFoundErrCode)
+
+	ErrCreateGroupGroupAlreadyExists = errs.New("group already exists", groupAlreadyExistsErrCode)
+
+	ErrDeleteGroupGroupNotFound = errs.New("group not found", groupNotFoundErrCode)
+
+	ErrAddGroupMemberGroupNotFound = errs.New("group not found", groupNotFoundErrCode)
+	ErrAddGroupMemberUserNotFound  = errs.New("user not found", userNotFoundErrCode)
+
+	ErrRemoveGroupMemberGroupNotFound = errs.New("group not found", groupNotFoundErrCode)
+
+	ErrAddGroupSubgroupGroupNotFound    = errs.New("group not found", groupNotFoundErrCode)
+	ErrAddGroupSubgroupSubgroupNotFound = errs.New("subgroup not found", groupNotFoundErrCode)
+	ErrAddGroupSubgroupCycle            = errs.New("group can not be nested into itself or its subgroups", groupCycleErrCode)
+
+	ErrRemoveGroupSubgroupGroupNotFound = errs.New("group not found", groupNotFoundErrCode)
+
+	ErrAssignGroupRoleGroupNotFound = errs.New("group not found", groupNotFoundErrCode)
+	ErrAssignGroupRoleRoleNotFound  = errs.New("role not found", roleNotFoundErrCode)
+
+	ErrUnassignGroupRoleGroupNotFound = errs.New("group not found", groupNotFoundErrCode)
+
+	// Errors of invalid relations are returned with details describing the problem.
+	ErrWriteRelationTuplesInvalidTuple = errs.New("tuple references unknown namespace or relation", invalidRelationErrCode)
+
+	ErrCheckRelationInvalidRelation         = errs.New("unknown namespace or relation", invalidRelationErrCode)
+	ErrCheckRelationInvalidConsistencyToken = errs.New("invalid consistency token", invalidTokenErrCode)
+
+	ErrExpandRelationInvalidRelation         = errs.New("unknown namespace or relation", invalidRelationErrCode)
+	ErrExpandRelationInvalidConsistencyToken = errs.New("invalid consistency token", invalidTokenErrCode)
+
+	ErrListRelationObjectsInvalidRelation         = errs.New("unknown namespace or relation", invalidRelationErrCode)
+	ErrListRelationObjectsInvalidConsistencyToken = errs.New("invalid consistency token", invalidTokenErrCode)
+
 	ErrRefreshUserTokenInvalidToken = errs.New("invalid refresh token", invalidTokenErrCode)
 	ErrRefreshUserTokenUserNotFound = errs.New("user not found", userNotFoundErrCode)
 )
@@ -89,12 +482,328 @@ type RegisterUserOptions struct {
 type LoginUserOptions struct {
 	EmailAddress string
 	Password     string
+	// IPAddress is the client IP address, failed attempts are counted by it as well.
+	IPAddress string
+}
+
+// RetryAfterDetails are attached to errors of throttled requests.
+type RetryAfterDetails struct {
+	// RetryAfter is the number of seconds to wait before the next attempt.
+	RetryAfter int `json:"retryAfter"`
 }
 
 type LoginUserOutput struct {
 	AccessToken  string `json:"accessToken"`
 	RefreshToken string `json:"refreshToken"`
 	UserID       string `json:"userId"`
+	// MFARequired is true if user has to complete login with LoginUserMFA, tokens are empty in this case.
+	MFARequired       bool   `json:"mfaRequired"`
+	MFAChallengeToken string `json:"mfaChallengeToken"`
+	// RecoveryCodesWarning is set if user has few unused MFA recovery codes left.
+	RecoveryCodesWarning   string `json:"recoveryCodesWarning"`
+	RecoveryCodesRemaining int    `json:"recoveryCodesRemaining"`
+}
+
+type LoginUserMFAOptions struct {
+	ChallengeToken string
+	// Code is the TOTP code. Either Code or RecoveryCode has to be passed.
+	Code         string
+	RecoveryCode string
+	// IPAddress is the client IP address, failed attempts are counted by it as well.
+	IPAddress string
+}
+
+type SendUserMagicLinkOptions struct {
+	EmailAddress string
+}
+
+type LoginUserMagicLinkOptions struct {
+	Token string
+}
+
+type VerifyUserEmailOptions struct {
+	Token string
+}
+
+type ResendUserEmailVerificationOptions struct {
+	EmailAddress string
+}
+
+type RequestUserEmailChangeOptions struct {
+	UserID          string
+	NewEmailAddress string
+}
+
+type ConfirmUserEmailChangeOptions struct {
+	Token string
+}
+
+type UndoUserEmailChangeOptions struct {
+	Token string
+}
+
+type VerifyUserPhoneOptions struct {
+	UserID string
+	Code   string
+}
+
+type SendUserLoginCodeOptions struct {
+	Phone string
+}
+
+type LoginUserPhoneOptions struct {
+	Phone string
+	Code  string
+}
+
+type EnrollUserTOTPOutput struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth:// key URI for authenticator apps.
+	URI string `json:"uri"`
+	// QRCode is the PNG image of QR code with encoded URI.
+	QRCode []byte `json:"qrCode"`
+}
+
+type ConfirmUserTOTPOptions struct {
+	UserID string
+	Code   string
+}
+
+type ConfirmUserTOTPOutput struct {
+	// RecoveryCodes are shown to the user only once.
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type RegenerateUserMFARecoveryCodesOutput struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type BeginUserWebAuthnRegistrationOutput struct {
+	// Options are passed to navigator.credentials.create() as "publicKey".
+	Options *webauthn.CreationOptions `json:"publicKey"`
+	// CeremonyToken has to be passed back with the authenticator response.
+	CeremonyToken string `json:"ceremonyToken"`
+}
+
+type FinishUserWebAuthnRegistrationOptions struct {
+	UserID        string
+	CeremonyToken string
+	// Name is the user given name of the passkey.
+	Name     string
+	Response *webauthn.AttestationResponse
+}
+
+type BeginUserWebAuthnLoginOptions struct {
+	// EmailAddress is optional. If it is empty, authenticator is asked for a discoverable credential.
+	EmailAddress string
+}
+
+type BeginUserWebAuthnLoginOutput struct {
+	// Options are passed to navigator.credentials.get() as "publicKey".
+	Options *webauthn.RequestOptions `json:"publicKey"`
+	// CeremonyToken has to be passed back with the authenticator response.
+	CeremonyToken string `json:"ceremonyToken"`
+}
+
+type FinishUserWebAuthnLoginOptions struct {
+	CeremonyToken string
+	Response      *webauthn.AssertionResponse
+}
+
+type ChangeUserPasswordOptions struct {
+	UserID string
+	// SessionID is the ID of the current session which stays active after password change.
+	SessionID       string
+	CurrentPassword string
+	NewPassword     string
+	// IPAddress is the client IP address, wrong current passwords are counted as failed logins.
+	IPAddress string
+}
+
+type ForgotUserPasswordOptions struct {
+	EmailAddress string
+}
+
+type ResetUserPasswordOptions struct {
+	Token       string
+	NewPassword string
+}
+
+type ReauthenticateUserOptions struct {
+	UserID    string
+	SessionID string
+	Password  string
+	// Code is the TOTP code, required if user has MFA enabled. RecoveryCode can be passed instead.
+	Code         string
+	RecoveryCode string
+	// IPAddress is the client IP address, failed attempts are counted by it as well.
+	IPAddress string
+}
+
+type CreateRoleOptions struct {
+	Name        string
+	Description string
+	// Permissions are names of permissions granted by the role.
+	Permissions []string
+}
+
+type AssignUserRoleOptions struct {
+	UserID string
+	RoleID string
+}
+
+type UnassignUserRoleOptions struct {
+	UserID string
+	RoleID string
+}
+
+type EvaluatePoliciesOptions struct {
+	Request policy.Request
+	// Policies are evaluated instead of loaded ones if not nil, so draft policies can be tested.
+	Policies []policy.Policy
+}
+
+type GetOrganizationOptions struct {
+	ID   string
+	Slug string
+}
+
+type CreateOrganizationOptions struct {
+	Name string
+	Slug string
+}
+
+type UpdateOrganizationMemberOptions struct {
+	UserID string
+	// RoleID is the role granted within the organization, role is removed if empty.
+	RoleID string
+}
+
+type InviteUserToOrganizationOptions struct {
+	EmailAddress string
+	// RoleID is the role granted within the organization on acceptance, optional.
+	RoleID string
+}
+
+type AcceptOrganizationInvitationOptions struct {
+	Token string
+	// Registration is used to register the user if request is not authenticated. Email is taken from invitation.
+	Registration *RegisterUserOptions
+}
+
+type DeclineOrganizationInvitationOptions struct {
+	Token string
+}
+
+type SwitchUserOrganizationOptions struct {
+	UserID         string
+	OrganizationID string
+}
+
+type CreateGroupOptions struct {
+	Name        string
+	Description string
+}
+
+type AddGroupMemberOptions struct {
+	GroupID string
+	UserID  string
+}
+
+type RemoveGroupMemberOptions struct {
+	GroupID string
+	UserID  string
+}
+
+type AddGroupSubgroupOptions struct {
+	GroupID    string
+	SubgroupID string
+}
+
+type RemoveGroupSubgroupOptions struct {
+	GroupID    string
+	SubgroupID string
+}
+
+type AssignGroupRoleOptions struct {
+	GroupID string
+	RoleID  string
+}
+
+type UnassignGroupRoleOptions struct {
+	GroupID string
+	RoleID  string
+}
+
+type WriteRelationTuplesOptions struct {
+	Writes  []rebac.Tuple
+	Deletes []rebac.Tuple
+}
+
+type WriteRelationTuplesOutput struct {
+	// ConsistencyToken refers to the revision tuples are written at.
+	ConsistencyToken string `json:"consistencyToken"`
+}
+
+type CheckRelationOptions struct {
+	Object   rebac.Object
+	Relation string
+	Subject  rebac.Subject
+	// ConsistencyToken is optional, e.g. the token of the client's last write.
+	ConsistencyToken string
+}
+
+type CheckRelationOutput struct {
+	Allowed          bool   `json:"allowed"`
+	ConsistencyToken string `json:"consistencyToken"`
+}
+
+type ExpandRelationOptions struct {
+	Object   rebac.Object
+	Relation string
+	// ConsistencyToken is optional, e.g. the token of the client's last write.
+	ConsistencyToken string
+}
+
+type ExpandRelationOutput struct {
+	Tree             *rebac.Tree `json:"tree"`
+	ConsistencyToken string      `json:"consistencyToken"`
+}
+
+type ListRelationObjectsOptions struct {
+	Namespace string
+	Relation  string
+	Subject   rebac.Subject
+	// ConsistencyToken is optional, e.g. the token of the client's last write.
+	ConsistencyToken string
+}
+
+type ListRelationObjectsOutput struct {
+	ObjectIDs        []string `json:"objectIds"`
+	ConsistencyToken string   `json:"consistencyToken"`
+}
+
+type VerifyUserTokenOutput struct {
+	User      *entity.User
+	SessionID string
+	// AuthTime is the time user authenticated within the session last time.
+	AuthTime time.Time
+	// AuthMethods are the methods user authenticated with.
+	AuthMethods []string
+	// Permissions are granted to the user by roles, including roles of user groups.
+	Permissions []string
+	// Groups are names of groups user is an effective member of.
+	Groups []string
+	// TenantID is the ID of the organization token is scoped to.
+	TenantID string
+}
+
+type GenerateUserTokenOptions struct {
+	User *entity.User
+	// AuthMethods are the methods user authenticated with.
+	AuthMethods []string
+	// AuthTime is the time user authenticated, current time if zero.
+	AuthTime time.Time
 }
 
 type VerifyTokenOptions struct {
@@ -107,6 +816,39 @@ type GetUserOptions struct {
 	EmailAddress string
 }
 
+type DeleteUserAccountOptions struct {
+	UserID string
+	// Password confirms the deletion. If empty, user has to be authenticated within the reauthentication window.
+	Password string
+	// Code is the TOTP code, required with the password if user has MFA enabled. RecoveryCode can be passed instead.
+	Code         string
+	RecoveryCode string
+	// IPAddress is the client IP address, failed password confirmations are counted as failed logins.
+	IPAddress string
+}
+
+type ListUsersOptions struct {
+	// Search matches users having the text in name, surname or email address.
+	Search        string
+	EmailDomain   string
+	Status        entity.UserStatus
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// SortBy is UserSortFieldCreatedAt by default.
+	SortBy       UserSortField
+	IsDescending bool
+	// Cursor is the NextCursor of the previous page. Sort has to be the same as for the previous page.
+	Cursor string
+	// Limit is defaultListUsersLimit by default and can not exceed maxListUsersLimit.
+	Limit int
+}
+
+type ListUsersOutput struct {
+	Users []entity.User
+	// NextCursor is empty on the last page.
+	NextCursor string
+}
+
 type UserTokenOutput struct {
 	AccessToken  string `json:"accessToken"`
 	RefreshToken string `json:"refreshToken"`
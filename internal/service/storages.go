@@ -2,22 +2,335 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/taraslis453/solid-software-test/internal/entity"
 )
 
 type Storages struct {
-	User UserStorage
+	User               UserStorage
+	PasswordHistory    PasswordHistoryStorage
+	Session            SessionStorage
+	PasswordReset      PasswordResetTokenStorage
+	MFARecoveryCode    MFARecoveryCodeStorage
+	WebAuthnCredential WebAuthnCredentialStorage
+	UsedToken          UsedTokenStorage
+	OneTimeCode        OneTimeCodeStorage
+	EmailChangeRequest EmailChangeRequestStorage
+	LoginThrottle      LoginThrottleStorage
+	Role               RoleStorage
+	Organization       OrganizationStorage
+	Membership         MembershipStorage
+	Group              GroupStorage
+	RelationTuple      RelationTupleStorage
 }
 
 type UserStorage interface {
 	GetUser(ctx context.Context, filter GetUserFilter) (*entity.User, error)
+	// ListUsers returns up to filter.Limit users matching the filter in the sort order.
+	ListUsers(ctx context.Context, filter ListUsersFilter) ([]entity.User, error)
+	// CreateUser returns nil if the email address is already taken in the organization.
 	CreateUser(ctx context.Context, user *entity.User) (*entity.User, error)
 	UpdateUser(ctx context.Context, id string, user *entity.User) (*entity.User, error)
-	DeleteUser(ctx context.Context, id string) error
+	// DeleteUser soft deletes the user on behalf of deletedByID, so it is not returned unless deleted users are requested.
+	DeleteUser(ctx context.Context, id, deletedByID string) error
+	// RestoreUser undoes soft deletion of the user.
+	RestoreUser(ctx context.Context, id string) error
+	// PurgeUsers permanently deletes users of all organizations soft deleted before the time with all their data.
+	// Returns the number of purged users.
+	PurgeUsers(ctx context.Context, filter PurgeUsersFilter) (int64, error)
+	// ClearUserPhoneVerification resets the user phone verification.
+	ClearUserPhoneVerification(ctx context.Context, id string) error
+	// SetUserVerifiedPhone sets the verified user phone if it is not verified by another user of the organization.
+	// Returns false otherwise.
+	SetUserVerifiedPhone(ctx context.Context, id, phone string) (bool, error)
+	// UseUserTOTPStep marks the TOTP time step as the last used one if it is later than the last used one.
+	// Returns false otherwise, so the code of the step can not be used twice.
+	UseUserTOTPStep(ctx context.Context, id string, step int64) (bool, error)
+	// ChangeUserEmail sets the new verified user email if it is not used by another user and user email is still
+	// the old one. Returns false otherwise.
+	ChangeUserEmail(ctx context.Context, id, oldEmailAddress, newEmailAddress string) (bool, error)
 }
 
 type GetUserFilter struct {
 	ID           *string
 	EmailAddress *string
+	// VerifiedPhone matches users with the phone number verified.
+	VerifiedPhone *string
+	// WithDeleted includes soft deleted users.
+	WithDeleted bool
+}
+
+type ListUsersFilter struct {
+	// Search matches users having the text in name, surname or email address.
+	Search        *string
+	EmailDomain   *string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	EmailVerified *bool
+	// Lockout matches users locked out or not locked out after failed logins.
+	Lockout *UserLockoutFilter
+
+	SortBy       UserSortField
+	IsDescending bool
+	// After matches users following the cursor in the sort order.
+	After *UserCursor
+	Limit int
+}
+
+// PurgeUsersFilter selects users to purge.
+type PurgeUsersFilter struct {
+	DeletedBefore time.Time
+	// ThrottleKeyPrefix is followed by organization ID and lowercased email address of the user separated by colon
+	// in the key of user login throttle.
+	ThrottleKeyPrefix string
+	// RelationNamespace is the namespace of users in relation tuples.
+	RelationNamespace string
+}
+
+// UserLockoutFilter matches users by login throttle with the key prefix followed by organization ID and lowercased
+// email address of the user separated by colon.
+type UserLockoutFilter struct {
+	IsLockedOut       bool
+	ThrottleKeyPrefix string
+	// Users are locked out if they reached max failed attempts after FailedAfter.
+	MaxAttempts int
+	FailedAfter time.Time
+}
+
+type UserSortField string
+
+const (
+	UserSortFieldCreatedAt    UserSortField = "createdAt"
+	UserSortFieldEmailAddress UserSortField = "emailAddress"
+	UserSortFieldName         UserSortField = "name"
+)
+
+// UserCursor is the position after the user in the list sorted by any of sort fields.
+type UserCursor struct {
+	ID           string    `json:"id"`
+	CreatedAt    time.Time `json:"createdAt"`
+	EmailAddress string    `json:"emailAddress"`
+	Name         string    `json:"name"`
+}
+
+type PasswordHistoryStorage interface {
+	// ListPasswordHistory returns the user password history ordered from the latest entry.
+	ListPasswordHistory(ctx context.Context, filter ListPasswordHistoryFilter) ([]entity.PasswordHistory, error)
+	CreatePasswordHistory(ctx context.Context, history *entity.PasswordHistory) (*entity.PasswordHistory, error)
+	DeletePasswordHistory(ctx context.Context, ids []string) error
+}
+
+type ListPasswordHistoryFilter struct {
+	UserID string
+	// Limit is the max number of entries, all entries are returned if it is zero.
+	Limit int
+}
+
+type SessionStorage interface {
+	GetSession(ctx context.Context, filter GetSessionFilter) (*entity.Session, error)
+	CreateSession(ctx context.Context, session *entity.Session) (*entity.Session, error)
+	UpdateSession(ctx context.Context, id string, session *entity.Session) (*entity.Session, error)
+	// RevokeSessions revokes all active user sessions except the one passed in filter.
+	RevokeSessions(ctx context.Context, filter RevokeSessionsFilter) error
+	// DeleteExpiredSessions deletes sessions expired before the time. Returns the number of deleted sessions.
+	DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error)
+}
+
+type GetSessionFilter struct {
+	ID *string
+}
+
+type RevokeSessionsFilter struct {
+	UserID   string
+	ExceptID *string
+}
+
+type PasswordResetTokenStorage interface {
+	GetPasswordResetToken(ctx context.Context, filter GetPasswordResetTokenFilter) (*entity.PasswordResetToken, error)
+	CreatePasswordResetToken(ctx context.Context, resetToken *entity.PasswordResetToken) (*entity.PasswordResetToken, error)
+	// UsePasswordResetToken marks the token as used and returns false if it has been already used.
+	UsePasswordResetToken(ctx context.Context, id string) (bool, error)
+	// InvalidatePasswordResetTokens marks all not used user tokens as used.
+	InvalidatePasswordResetTokens(ctx context.Context, userID string) error
+	// DeleteExpiredPasswordResetTokens deletes tokens expired before the time. Returns the number of deleted tokens.
+	DeleteExpiredPasswordResetTokens(ctx context.Context, before time.Time) (int64, error)
+}
+
+type GetPasswordResetTokenFilter struct {
+	TokenHash *string
+}
+
+type EmailChangeRequestStorage interface {
+	GetEmailChangeRequest(ctx context.Context, filter GetEmailChangeRequestFilter) (*entity.EmailChangeRequest, error)
+	CreateEmailChangeRequest(ctx context.Context, request *entity.EmailChangeRequest) (*entity.EmailChangeRequest, error)
+	// ConfirmEmailChangeRequest marks the request as confirmed and returns false if it has been already confirmed or cancelled.
+	ConfirmEmailChangeRequest(ctx context.Context, id string) (bool, error)
+	// CancelEmailChangeRequest marks the request as cancelled and returns false if it has been already cancelled.
+	CancelEmailChangeRequest(ctx context.Context, id string) (bool, error)
+	// CancelPendingEmailChangeRequests marks all not confirmed user requests as cancelled.
+	CancelPendingEmailChangeRequests(ctx context.Context, userID string) error
+	// DeleteExpiredEmailChangeRequests deletes requests which can be neither confirmed nor undone after the time.
+	// Returns the number of deleted requests.
+	DeleteExpiredEmailChangeRequests(ctx context.Context, before time.Time) (int64, error)
+}
+
+type GetEmailChangeRequestFilter struct {
+	ConfirmTokenHash *string
+	UndoTokenHash    *string
+}
+
+type LoginThrottleStorage interface {
+	// IncrementLoginThrottles registers the failed attempt for each key if isAllowed returns true for the current
+	// counters of the keys. Counters are locked meanwhile, so concurrent attempts are checked one by one.
+	// Counter of a key is started over if its last failed attempt is older than window.
+	// Returns false if the attempt is not allowed.
+	IncrementLoginThrottles(ctx context.Context, keys []string, window time.Duration, isAllowed func(throttles []entity.LoginThrottle) bool) (bool, error)
+	// DecrementLoginThrottles takes back the attempt registered for each key.
+	DecrementLoginThrottles(ctx context.Context, keys []string) error
+	DeleteLoginThrottles(ctx context.Context, keys []string) error
+	// DeleteStaleLoginThrottles deletes counters with the last failure before the time. Returns the number of deleted counters.
+	DeleteStaleLoginThrottles(ctx context.Context, before time.Time) (int64, error)
+}
+
+type RoleStorage interface {
+	ListPermissions(ctx context.Context) ([]entity.Permission, error)
+	ListRoles(ctx context.Context) ([]entity.Role, error)
+	GetRole(ctx context.Context, filter GetRoleFilter) (*entity.Role, error)
+	CreateRole(ctx context.Context, role *entity.Role) (*entity.Role, error)
+	// SeedRoles creates or updates passed permissions and roles by name, replacing the role permissions.
+	SeedRoles(ctx context.Context, permissions []entity.Permission, roles []entity.Role) error
+	// AssignUserRole assigns the role to the user, it does nothing if role is already assigned.
+	AssignUserRole(ctx context.Context, userID, roleID string) error
+	UnassignUserRole(ctx context.Context, userID, roleID string) error
+	// ListUserPermissions returns names of permissions granted by all user roles, by the user role
+	// in the organization from context and by roles of groups user is an effective member of.
+	ListUserPermissions(ctx context.Context, userID string) ([]string, error)
+}
+
+type GetRoleFilter struct {
+	ID   *string
+	Name *string
+}
+
+type OrganizationStorage interface {
+	GetOrganization(ctx context.Context, filter GetOrganizationFilter) (*entity.Organization, error)
+	CreateOrganization(ctx context.Context, organization *entity.Organization) (*entity.Organization, error)
+	// SeedOrganization creates the organization, it does nothing if organization with the same ID or slug exists.
+	SeedOrganization(ctx context.Context, organization *entity.Organization) error
+}
+
+type GetOrganizationFilter struct {
+	ID   *string
+	Slug *string
+}
+
+type MembershipStorage interface {
+	// ListMembers returns users registered in or being members of the organization.
+	ListMembers(ctx context.Context, organizationID string) ([]entity.Membership, error)
+	// UpsertMembership creates the membership or updates the role of existing one.
+	UpsertMembership(ctx context.Context, membership *entity.Membership) (*entity.Membership, error)
+	// DeleteMembership deletes the membership and removes the user from groups of the organization.
+	DeleteMembership(ctx context.Context, organizationID, userID string) error
+}
+
+// GroupStorage operates on groups of the organization from context.
+type GroupStorage interface {
+	ListGroups(ctx context.Context) ([]entity.Group, error)
+	// GetGroup returns the group with IDs of its direct members, subgroups and roles.
+	GetGroup(ctx context.Context, filter GetGroupFilter) (*entity.Group, error)
+	CreateGroup(ctx context.Context, group *entity.Group) (*entity.Group, error)
+	// DeleteGroup deletes the group with its members, roles and nesting into other groups.
+	DeleteGroup(ctx context.Context, id string) error
+	// AddGroupMember adds the user to the group, it does nothing if user is already a member.
+	AddGroupMember(ctx context.Context, groupID, userID string) error
+	RemoveGroupMember(ctx context.Context, groupID, userID string) error
+	// AddGroupSubgroup nests the subgroup into the group and returns false if it would form a cycle.
+	AddGroupSubgroup(ctx context.Context, groupID, subgroupID string) (bool, error)
+	RemoveGroupSubgroup(ctx context.Context, groupID, subgroupID string) error
+	// AssignGroupRole assigns the role to the group, it does nothing if role is already assigned.
+	AssignGroupRole(ctx context.Context, groupID, roleID string) error
+	UnassignGroupRole(ctx context.Context, groupID, roleID string) error
+	// ListUserGroups returns groups user is a direct member of and all groups they are nested into.
+	ListUserGroups(ctx context.Context, userID string) ([]entity.Group, error)
+}
+
+type GetGroupFilter struct {
+	ID   *string
+	Name *string
+}
+
+// RelationTupleStorage operates on relation tuples of the organization from context.
+type RelationTupleStorage interface {
+	// WriteRelationTuples deletes and creates tuples atomically within a new revision and returns it.
+	// Creating existing tuple or deleting missing one does nothing.
+	WriteRelationTuples(ctx context.Context, writes, deletes []entity.RelationTuple) (int64, error)
+	// GetRelationTupleRevision returns the latest revision, 0 if tuples have never been written.
+	GetRelationTupleRevision(ctx context.Context) (int64, error)
+	// ListRelationTuples returns tuples of the object relation existing at the revision.
+	ListRelationTuples(ctx context.Context, filter ListRelationTuplesFilter) ([]entity.RelationTuple, error)
+	// ListRelationObjectIDs returns IDs of the namespace objects having any tuples at the revision.
+	ListRelationObjectIDs(ctx context.Context, namespace string, revision int64) ([]string, error)
+}
+
+type ListRelationTuplesFilter struct {
+	Namespace string
+	ObjectID  string
+	Relation  string
+	Revision  int64
+}
+
+type MFARecoveryCodeStorage interface {
+	ListMFARecoveryCodes(ctx context.Context, filter ListMFARecoveryCodesFilter) ([]entity.MFARecoveryCode, error)
+	// ReplaceMFARecoveryCodes deletes all user recovery codes and creates passed ones.
+	ReplaceMFARecoveryCodes(ctx context.Context, userID string, codes []entity.MFARecoveryCode) error
+	// UseMFARecoveryCode marks the code as used and returns false if it has been already used.
+	UseMFARecoveryCode(ctx context.Context, id string) (bool, error)
+}
+
+type ListMFARecoveryCodesFilter struct {
+	UserID     string
+	OnlyUnused bool
+}
+
+type WebAuthnCredentialStorage interface {
+	ListWebAuthnCredentials(ctx context.Context, filter ListWebAuthnCredentialsFilter) ([]entity.WebAuthnCredential, error)
+	GetWebAuthnCredential(ctx context.Context, filter GetWebAuthnCredentialFilter) (*entity.WebAuthnCredential, error)
+	CreateWebAuthnCredential(ctx context.Context, credential *entity.WebAuthnCredential) (*entity.WebAuthnCredential, error)
+	UpdateWebAuthnCredential(ctx context.Context, id string, credential *entity.WebAuthnCredential) (*entity.WebAuthnCredential, error)
+}
+
+type ListWebAuthnCredentialsFilter struct {
+	UserID string
+}
+
+type GetWebAuthnCredentialFilter struct {
+	CredentialID []byte
+}
+
+type UsedTokenStorage interface {
+	// CreateUsedToken marks the token as used and returns false if it has been already used.
+	CreateUsedToken(ctx context.Context, usedToken *entity.UsedToken) (bool, error)
+	// DeleteExpiredUsedTokens deletes tokens expired before the time, since expired tokens are rejected anyway.
+	// Returns the number of deleted tokens.
+	DeleteExpiredUsedTokens(ctx context.Context, before time.Time) (int64, error)
+}
+
+type OneTimeCodeStorage interface {
+	// GetOneTimeCode returns the latest code matching the filter.
+	GetOneTimeCode(ctx context.Context, filter GetOneTimeCodeFilter) (*entity.OneTimeCode, error)
+	CreateOneTimeCode(ctx context.Context, code *entity.OneTimeCode) (*entity.OneTimeCode, error)
+	// IncrementOneTimeCodeAttempts counts the verification attempt and returns false if code has no attempts left.
+	IncrementOneTimeCodeAttempts(ctx context.Context, id string, maxAttempts int) (bool, error)
+	// UseOneTimeCode marks the code as used and returns false if it has been already used.
+	UseOneTimeCode(ctx context.Context, id string) (bool, error)
+	// InvalidateOneTimeCodes marks all not used user codes issued for the purpose as used.
+	InvalidateOneTimeCodes(ctx context.Context, userID, purpose string) error
+	// DeleteExpiredOneTimeCodes deletes codes expired before the time. Returns the number of deleted codes.
+	DeleteExpiredOneTimeCodes(ctx context.Context, before time.Time) (int64, error)
+}
+
+type GetOneTimeCodeFilter struct {
+	UserID  string
+	Purpose string
 }
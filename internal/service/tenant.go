@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+)
+
+// TenantContextKey is the context key the ID of the organization request is scoped to is stored under.
+// It is a string, so gin.Context passed as context.Context resolves it from the values set with c.Set.
+const TenantContextKey = "tenantID"
+
+// ContextWithTenant returns the copy of context scoped to the organization.
+func ContextWithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, TenantContextKey, tenantID)
+}
+
+// TenantFromContext returns the ID of the organization context is scoped to, or the default organization ID
+// if it is not scoped.
+func TenantFromContext(ctx context.Context) string {
+	tenantID, ok := tenantFromContext(ctx)
+	if !ok {
+		return entity.DefaultOrganizationID
+	}
+	return tenantID
+}
+
+func tenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, _ := ctx.Value(TenantContextKey).(string)
+	return tenantID, tenantID != ""
+}
+
+// contextWithTokenTenant returns the copy of context scoped to the organization the token was issued in, so the token
+// is consumed there regardless of the organization of the request. Tokens issued without organization keep the scope.
+func contextWithTokenTenant(ctx context.Context, tenantID string) context.Context {
+	if tenantID == "" {
+		return ctx
+	}
+	return ContextWithTenant(ctx, tenantID)
+}
+
+// isUserManageable returns true if the user is registered in the organization from context or is the principal itself.
+func isUserManageable(ctx context.Context, user *entity.User) bool {
+	if user.TenantID == TenantFromContext(ctx) {
+		return true
+	}
+	principal := PrincipalFromContext(ctx)
+	return principal != nil && principal.UserID == user.ID
+}
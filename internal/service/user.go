@@ -3,32 +3,85 @@ package service
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/mitchellh/mapstructure"
 
+	"github.com/taraslis453/solid-software-test/pkg/encryption"
 	"github.com/taraslis453/solid-software-test/pkg/errs"
+	"github.com/taraslis453/solid-software-test/pkg/mailer"
 	"github.com/taraslis453/solid-software-test/pkg/password"
+	"github.com/taraslis453/solid-software-test/pkg/sms"
 	"github.com/taraslis453/solid-software-test/pkg/token"
+	"github.com/taraslis453/solid-software-test/pkg/webauthn"
 
 	"github.com/taraslis453/solid-software-test/internal/entity"
 )
 
 var _ UserService = (*userService)(nil)
 
+// passwordResetTokenLength is the number of random bytes in the password reset token.
+const passwordResetTokenLength = 32
+
+// emailChangeTokenLength is the number of random bytes in the email change confirmation and undo tokens.
+const emailChangeTokenLength = 32
+
+// Purposes of single-purpose tokens.
+const (
+	mfaChallengeTokenPurpose           = "mfa_challenge"
+	webAuthnRegistrationTokenPurpose   = "webauthn_registration"
+	webAuthnLoginTokenPurpose          = "webauthn_login"
+	magicLinkTokenPurpose              = "magic_link"
+	emailVerificationTokenPurpose      = "email_verification"
+	organizationInvitationTokenPurpose = "organization_invitation"
+)
+
+// Authentication methods put into the access token "amr" claim (RFC 8176 values where possible).
+const (
+	AuthMethodPassword     = "pwd"
+	AuthMethodOTP          = "otp"
+	AuthMethodRecoveryCode = "rcode"
+	AuthMethodHardwareKey  = "hwk"
+	AuthMethodSMS          = "sms"
+	AuthMethodEmail        = "email"
+	AuthMethodMFA          = "mfa"
+)
+
 type userService struct {
 	serviceContext
-	passwordHasher password.Hasher
+	passwordHasher        password.Hasher
+	passwordValidator     password.Validator
+	passwordBreachChecker password.BreachChecker
+	mailer                mailer.Mailer
+	smsSender             sms.Sender
+	secretEncryptor       encryption.Encryptor
+	webAuthn              *webauthn.RelyingParty
+
+	// dummyPasswordHash is compared with the password of not existing users, generated on the first use.
+	dummyPasswordHash     string
+	dummyPasswordHashErr  error
+	dummyPasswordHashOnce sync.Once
 }
 
 func NewUserService(options Options) *userService {
 	return &userService{
 		serviceContext: serviceContext{
-			storages: options.Storages,
-			cfg:      options.Config,
-			logger:   options.Logger.Named("userService"),
+			storages:     options.Storages,
+			cfg:          options.Config,
+			logger:       options.Logger.Named("userService"),
+			policyEngine: options.PolicyEngine,
 		},
-		passwordHasher: options.PasswordHasher,
+		passwordHasher:        options.PasswordHasher,
+		passwordValidator:     options.PasswordValidator,
+		passwordBreachChecker: options.PasswordBreachChecker,
+		mailer:                options.Mailer,
+		smsSender:             options.SMSSender,
+		secretEncryptor:       options.SecretEncryptor,
+		webAuthn:              options.WebAuthn,
 	}
 }
 
@@ -38,28 +91,55 @@ func (s *userService) RegisterUser(ctx context.Context, opts RegisterUserOptions
 		WithContext(ctx).
 		With("opts", opts)
 
-	// Check if user with the same email already exists in storage and return error if exists
+	violations, err := s.validatePassword(&password.ValidateOptions{
+		Password:   opts.Password,
+		UserInputs: []string{opts.Name, opts.Surname, getEmailLocalPart(opts.EmailAddress)},
+	})
+	if err != nil {
+		logger.Error("failed to validate password", "err", err)
+		return fmt.Errorf("failed to validate password: %w", err)
+	}
+	if len(violations) > 0 {
+		logger.Info("password does not satisfy password policy", "violations", violations)
+		return errs.WithDetails(ErrRegisterUserWeakPassword, violations)
+	}
+
+	hashedPassword, err := s.passwordHasher.GenerateHashFromPassword(opts.Password)
+	if err != nil {
+		logger.Error("failed to hash password", "err", err)
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	// Existence is checked after the password is hashed, so registration takes the same time for existing users.
+	// Email of the deleted user is kept until it is purged, so the user can still restore the account.
 	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
 		EmailAddress: &opts.EmailAddress,
+		WithDeleted:  true,
 	})
 	if err != nil {
 		logger.Error("failed to get user through storage", "err", err)
 		return fmt.Errorf("failed to get user through storage: %w", err)
 	}
+	if user != nil && s.cfg.Auth.AntiEnumeration {
+		// Response does not differ from successful registration, the owner is notified instead
+		err = s.sendRegistrationAttemptNotice(ctx, user)
+		if err != nil {
+			logger.Error("failed to send registration attempt notice", "err", err)
+			return fmt.Errorf("failed to send registration attempt notice: %w", err)
+		}
+
+		logger.Info("user with such email already exists, notified the owner")
+		return nil
+	}
 	if user != nil {
 		logger.Info("user with such email already exists")
 		return ErrRegisterUserUserAlreadyExists
 	}
 
-	hashedPassword, err := s.passwordHasher.GenerateHashFromPassword(opts.Password)
-	if err != nil {
-		logger.Error("failed to hash password", "err", err)
-		return fmt.Errorf("failed to hash password: %w", err)
-	}
-
 	createdUser, err := s.storages.User.CreateUser(ctx, &entity.User{
 		Name:         opts.Name,
 		Surname:      opts.Surname,
+		Phone:        opts.Phone,
 		EmailAddress: opts.EmailAddress,
 		Password:     hashedPassword,
 	})
@@ -67,8 +147,46 @@ func (s *userService) RegisterUser(ctx context.Context, opts RegisterUserOptions
 		logger.Error("failed to create user in storage", "err", err)
 		return fmt.Errorf("failed to create user in storage: %w", err)
 	}
+	// User with the same email could be registered concurrently after the check above,
+	// or the email could differ from the existing one only in case
+	if createdUser == nil && s.cfg.Auth.AntiEnumeration {
+		user, err = s.storages.User.GetUser(ctx, GetUserFilter{
+			EmailAddress: &opts.EmailAddress,
+			WithDeleted:  true,
+		})
+		if err != nil {
+			logger.Error("failed to get user through storage", "err", err)
+			return fmt.Errorf("failed to get user through storage: %w", err)
+		}
+		if user != nil {
+			err = s.sendRegistrationAttemptNotice(ctx, user)
+			if err != nil {
+				logger.Error("failed to send registration attempt notice", "err", err)
+				return fmt.Errorf("failed to send registration attempt notice: %w", err)
+			}
+		}
+
+		logger.Info("user with such email already exists, notified the owner")
+		return nil
+	}
+	if createdUser == nil {
+		logger.Info("user with such email already exists")
+		return ErrRegisterUserUserAlreadyExists
+	}
 	logger.Debug("created user", "createdUser", createdUser)
 
+	err = s.savePasswordHistory(ctx, createdUser.ID, hashedPassword)
+	if err != nil {
+		logger.Error("failed to save password history", "err", err)
+		return fmt.Errorf("failed to save password history: %w", err)
+	}
+
+	// Registration is not failed if email is not sent, since user can request it again
+	err = s.sendEmailVerification(ctx, createdUser)
+	if err != nil {
+		logger.Error("failed to send verification email", "err", err)
+	}
+
 	logger.Info("registered user succesfully")
 	return nil
 }
@@ -77,17 +195,49 @@ func (s *userService) LoginUser(ctx context.Context, opts LoginUserOptions) (Log
 	logger := s.logger.
 		Named("LoginUser").
 		WithContext(ctx).
-		With("opts", opts)
+		With("email", opts.EmailAddress, "ip", opts.IPAddress)
 
+	// Deleted user can login to restore the account within the grace period
 	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
 		EmailAddress: &opts.EmailAddress,
+		WithDeleted:  true,
 	})
 	if err != nil {
 		logger.Error("failed to get user through storage", "err", err)
 		return LoginUserOutput{}, fmt.Errorf("failed to get user through storage: %w", err)
 	}
+	if user != nil && !s.isUserRestorable(user) {
+		user = nil
+	}
+
+	// Failed attempts are counted per user and per IP, so neither a single account nor many accounts can be guessed
+	throttleSubjects := s.getLoginThrottleSubjects(TenantFromContext(ctx), opts.EmailAddress, opts.IPAddress)
+	attempt, err := s.acquireLoginAttempt(ctx, throttleSubjects)
+	if err != nil {
+		logger.Error("failed to acquire login attempt", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to acquire login attempt: %w", err)
+	}
+	if attempt.isLocked {
+		logger.Info("login is locked", "retryAfter", attempt.retryAfter)
+		return LoginUserOutput{}, errs.WithDetails(ErrLoginUserAccountLocked, newRetryAfterDetails(attempt.retryAfter))
+	}
+	if attempt.retryAfter > 0 {
+		logger.Info("login is delayed", "retryAfter", attempt.retryAfter)
+		return LoginUserOutput{}, errs.WithDetails(ErrLoginUserTooManyAttempts, newRetryAfterDetails(attempt.retryAfter))
+	}
+
+	// Acquired attempt stays counted as failed unless the password is correct
 	if user == nil {
 		logger.Info("user not found")
+		if s.cfg.Auth.AntiEnumeration {
+			// Password is compared anyway, so response time does not reveal that user does not exist
+			err = s.compareDummyPassword(opts.Password)
+			if err != nil {
+				logger.Error("failed to compare dummy password", "err", err)
+				return LoginUserOutput{}, fmt.Errorf("failed to compare dummy password: %w", err)
+			}
+			return LoginUserOutput{}, ErrLoginUserInvalidCredentials
+		}
 		return LoginUserOutput{}, ErrLoginUserUserNotFound
 	}
 	logger.Debug("got user", "user", user)
@@ -103,17 +253,70 @@ func (s *userService) LoginUser(ctx context.Context, opts LoginUserOptions) (Log
 	}
 	if !isPasswordCorrect {
 		logger.Info("invalid password")
+		if s.cfg.Auth.AntiEnumeration {
+			return LoginUserOutput{}, ErrLoginUserInvalidCredentials
+		}
 		return LoginUserOutput{}, ErrLoginUserInvalidPassword
 	}
 
-	// Generate a pair of tokens
-	tokens, err := s.GenerateUserToken(ctx, user)
+	// Attempt of the user with MFA is released by LoginUserMFA, so the password alone does not reset the counter
+	if !user.IsMFAEnabled() {
+		err = s.releaseLoginAttempt(ctx, throttleSubjects)
+		if err != nil {
+			logger.Error("failed to release login attempt", "err", err)
+			return LoginUserOutput{}, fmt.Errorf("failed to release login attempt: %w", err)
+		}
+	}
+	if s.isLoginBlockedByEmailVerification(user) {
+		logger.Info("email is not verified")
+		return LoginUserOutput{}, ErrLoginUserEmailNotVerified
+	}
+
+	output, err := s.completeFirstFactorLogin(ctx, user, AuthMethodPassword)
+	if err != nil {
+		logger.Error("failed to complete login", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to complete login: %w", err)
+	}
+
+	logger.Info("user logged in", "mfaRequired", output.MFARequired)
+	return output, nil
+}
+
+// completeFirstFactorLogin generates user tokens after the first authentication factor is verified.
+// If user has MFA enabled, returns MFA challenge token instead, so the second step is required to complete login.
+// The authentication method of the first factor is kept in the challenge token to be put into the final tokens.
+func (s *userService) completeFirstFactorLogin(ctx context.Context, user *entity.User, authMethod string) (LoginUserOutput, error) {
+	if user.IsMFAEnabled() {
+		challengeToken, err := s.signPurposeToken(token.PurposeClaims{
+			Purpose:  mfaChallengeTokenPurpose,
+			UserID:   user.ID,
+			TokenID:  uuid.NewString(),
+			AMR:      []string{authMethod},
+			TenantID: user.TenantID,
+		}, s.cfg.MFA.ChallengeTokenLifetime)
+		if err != nil {
+			return LoginUserOutput{}, fmt.Errorf("failed to sign mfa challenge token: %w", err)
+		}
+
+		return LoginUserOutput{
+			MFARequired:       true,
+			MFAChallengeToken: challengeToken,
+		}, nil
+	}
+
+	err := s.restoreUser(ctx, user)
+	if err != nil {
+		return LoginUserOutput{}, fmt.Errorf("failed to restore user: %w", err)
+	}
+
+	tokens, err := s.GenerateUserToken(ctx, GenerateUserTokenOptions{
+		User:        user,
+		AuthMethods: []string{authMethod},
+	})
 	if err != nil {
-		logger.Error("failed to generate tokens", "err", err)
 		return LoginUserOutput{}, fmt.Errorf("failed to generate tokens: %w", err)
 	}
 
-	logger.Info("user logged in", "tokens", tokens)
 	return LoginUserOutput{
 		AccessToken:  tokens.AccessToken,
 		RefreshToken: tokens.RefreshToken,
@@ -126,6 +329,22 @@ func (s *userService) GetUser(ctx context.Context, opt GetUserOptions) (*entity.
 		Named("GetUser").
 		WithContext(ctx)
 
+	// Lookup by email is not bound to a user, so only the permission is checked
+	var err error
+	if opt.ID != "" {
+		err = s.authorizeUserAccess(ctx, opt.ID, entity.PermissionUsersRead)
+	} else {
+		err = authorizePermission(ctx, entity.PermissionUsersRead)
+	}
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("access is forbidden")
+			return nil, err
+		}
+		logger.Error("failed to authorize user access", "err", err)
+		return nil, fmt.Errorf("failed to authorize user access: %w", err)
+	}
+
 	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
 		ID:           &opt.ID,
 		EmailAddress: &opt.EmailAddress,
@@ -151,6 +370,16 @@ func (s *userService) UpdateUser(ctx context.Context, newUser *entity.User) (*en
 		With("newUser", newUser).
 		WithContext(ctx)
 
+	err := s.authorizeUserAccess(ctx, newUser.ID, entity.PermissionUsersUpdate)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("access is forbidden")
+			return nil, err
+		}
+		logger.Error("failed to authorize user access", "err", err)
+		return nil, fmt.Errorf("failed to authorize user access: %w", err)
+	}
+
 	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
 		ID: &newUser.ID,
 	})
@@ -158,13 +387,37 @@ func (s *userService) UpdateUser(ctx context.Context, newUser *entity.User) (*en
 		logger.Error("failed to get user", "err", err)
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
-	if user == nil {
+	// Users who only joined the organization are managed by their own organization
+	if user == nil || !isUserManageable(ctx, user) {
 		logger.Info("user not found")
 		return nil, ErrUpdateUserUserNotFound
 	}
 	logger.Debug("got user")
 
-	updatedUser, err := s.storages.User.UpdateUser(ctx, user.ID, newUser)
+	// The same number in another format is not a change, so the stored one (normalized if verified) is kept
+	newPhone, _ := normalizePhone(newUser.Phone)
+	currentPhone, _ := normalizePhone(user.Phone)
+	isPhoneChanged := newUser.Phone != "" && newPhone != currentPhone
+	phone := ""
+	if isPhoneChanged {
+		phone = newUser.Phone
+	}
+
+	// Changed phone has to be verified again. Verification is cleared first, since verified phones are unique
+	if isPhoneChanged && user.PhoneVerifiedAt != nil {
+		err = s.storages.User.ClearUserPhoneVerification(ctx, user.ID)
+		if err != nil {
+			logger.Error("failed to clear user phone verification", "err", err)
+			return nil, fmt.Errorf("failed to clear user phone verification: %w", err)
+		}
+	}
+
+	// Only profile fields can be updated, credentials and email are changed through dedicated flows
+	updatedUser, err := s.storages.User.UpdateUser(ctx, user.ID, &entity.User{
+		Name:    newUser.Name,
+		Surname: newUser.Surname,
+		Phone:   phone,
+	})
 	if err != nil {
 		logger.Error("failed to update user", "err", err)
 		return nil, fmt.Errorf("failed to update user: %w", err)
@@ -181,6 +434,16 @@ func (s *userService) DeleteUser(ctx context.Context, id string) error {
 		With("id", id).
 		WithContext(ctx)
 
+	err := s.authorizeUserAccess(ctx, id, entity.PermissionUsersDelete)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("access is forbidden")
+			return err
+		}
+		logger.Error("failed to authorize user access", "err", err)
+		return fmt.Errorf("failed to authorize user access: %w", err)
+	}
+
 	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
 		ID: &id,
 	})
@@ -188,13 +451,14 @@ func (s *userService) DeleteUser(ctx context.Context, id string) error {
 		logger.Error("failed to get user", "err", err)
 		return fmt.Errorf("failed to get user: %w", err)
 	}
-	if user == nil {
+	// Users who only joined the organization are managed by their own organization
+	if user == nil || !isUserManageable(ctx, user) {
 		logger.Info("user not found")
 		return ErrDeleteUserUserNotFound
 	}
 	logger.Debug("got user")
 
-	err = s.storages.User.DeleteUser(ctx, id)
+	err = s.deleteUser(ctx, id, PrincipalFromContext(ctx).UserID)
 	if err != nil {
 		logger.Error("failed to delete user", "err", err)
 		return fmt.Errorf("failed to delete user: %w", err)
@@ -204,13 +468,378 @@ func (s *userService) DeleteUser(ctx context.Context, id string) error {
 	return nil
 }
 
+func (s *userService) ChangeUserPassword(ctx context.Context, opts ChangeUserPasswordOptions) error {
+	logger := s.logger.
+		Named("ChangeUserPassword").
+		WithContext(ctx).
+		With("userID", opts.UserID, "sessionID", opts.SessionID)
+
+	err := s.authorizeUserAccess(ctx, opts.UserID, entity.PermissionUsersUpdate)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("access is forbidden")
+			return err
+		}
+		logger.Error("failed to authorize user access", "err", err)
+		return fmt.Errorf("failed to authorize user access: %w", err)
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &opts.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return ErrChangeUserPasswordUserNotFound
+	}
+	logger.Debug("got user")
+
+	// Current password is guessed the same way as on login, so attempts are limited by the same counters
+	throttleSubjects := s.getLoginThrottleSubjects(user.TenantID, user.EmailAddress, opts.IPAddress)
+	attempt, err := s.acquireLoginAttempt(ctx, throttleSubjects)
+	if err != nil {
+		logger.Error("failed to acquire login attempt", "err", err)
+		return fmt.Errorf("failed to acquire login attempt: %w", err)
+	}
+	if attempt.isLocked {
+		logger.Info("login is locked", "retryAfter", attempt.retryAfter)
+		return errs.WithDetails(ErrChangeUserPasswordAccountLocked, newRetryAfterDetails(attempt.retryAfter))
+	}
+	if attempt.retryAfter > 0 {
+		logger.Info("login is delayed", "retryAfter", attempt.retryAfter)
+		return errs.WithDetails(ErrChangeUserPasswordTooManyAttempts, newRetryAfterDetails(attempt.retryAfter))
+	}
+
+	isPasswordCorrect, err := s.passwordHasher.CompareHashAndPassword(&password.CompareHashAndPasswordOptions{
+		Hashed:   user.Password,
+		Password: opts.CurrentPassword,
+	})
+	if err != nil {
+		logger.Error("failed to check password correctness", "err", err)
+		return fmt.Errorf("failed to check password correctness: %w", err)
+	}
+	if !isPasswordCorrect {
+		logger.Info("invalid password")
+		return ErrChangeUserPasswordInvalidPassword
+	}
+
+	err = s.releaseLoginAttempt(ctx, throttleSubjects)
+	if err != nil {
+		logger.Error("failed to release login attempt", "err", err)
+		return fmt.Errorf("failed to release login attempt: %w", err)
+	}
+
+	violations, err := s.validatePassword(&password.ValidateOptions{
+		Password:   opts.NewPassword,
+		UserInputs: []string{user.Name, user.Surname, getEmailLocalPart(user.EmailAddress)},
+	})
+	if err != nil {
+		logger.Error("failed to validate password", "err", err)
+		return fmt.Errorf("failed to validate password: %w", err)
+	}
+	if len(violations) > 0 {
+		logger.Info("password does not satisfy password policy", "violations", violations)
+		return errs.WithDetails(ErrChangeUserPasswordWeakPassword, violations)
+	}
+
+	isPasswordReused, err := s.isPasswordReused(ctx, user, opts.NewPassword)
+	if err != nil {
+		logger.Error("failed to check password reuse", "err", err)
+		return fmt.Errorf("failed to check password reuse: %w", err)
+	}
+	if isPasswordReused {
+		logger.Info("password was used recently")
+		return ErrChangeUserPasswordPasswordReused
+	}
+
+	err = s.setUserPassword(ctx, user, opts.NewPassword)
+	if err != nil {
+		logger.Error("failed to set user password", "err", err)
+		return fmt.Errorf("failed to set user password: %w", err)
+	}
+
+	err = s.storages.Session.RevokeSessions(ctx, RevokeSessionsFilter{
+		UserID:   user.ID,
+		ExceptID: &opts.SessionID,
+	})
+	if err != nil {
+		logger.Error("failed to revoke sessions", "err", err)
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	logger.Info("successfully changed user password")
+	return nil
+}
+
+func (s *userService) ForgotUserPassword(ctx context.Context, opts ForgotUserPasswordOptions) error {
+	logger := s.logger.
+		Named("ForgotUserPassword").
+		WithContext(ctx).
+		With("opts", opts)
+
+	// Errors are not reported either, since they could reveal that the user exists
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		EmailAddress: &opts.EmailAddress,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return nil
+	}
+	if user == nil {
+		// Do not report that user does not exist to prevent email enumeration
+		logger.Info("user not found")
+		return nil
+	}
+	logger.Debug("got user")
+
+	err = s.sendPasswordResetEmail(ctx, user)
+	if err != nil {
+		logger.Error("failed to send password reset email", "err", err)
+		return nil
+	}
+
+	logger.Info("successfully sent password reset email")
+	return nil
+}
+
+// sendPasswordResetEmail emails the link to the password reset page of the frontend with the new reset token.
+// Only the latest requested token can be used.
+func (s *userService) sendPasswordResetEmail(ctx context.Context, user *entity.User) error {
+	err := s.storages.PasswordReset.InvalidatePasswordResetTokens(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+	}
+
+	resetToken, err := token.GenerateRandomToken(passwordResetTokenLength)
+	if err != nil {
+		return fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+
+	_, err = s.storages.PasswordReset.CreatePasswordResetToken(ctx, &entity.PasswordResetToken{
+		UserID:    user.ID,
+		TenantID:  user.TenantID,
+		TokenHash: token.HashToken(resetToken),
+		ExpiresAt: time.Now().Add(s.cfg.Auth.PasswordResetTokenLifetime),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, &mailer.Message{
+		To:      user.EmailAddress,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"To reset your password follow the link: %s/password/reset?token=%s\nThe link expires in %s. If you did not request a password reset, ignore this email.",
+			s.cfg.App.FrontendURL, url.QueryEscape(resetToken), s.cfg.Auth.PasswordResetTokenLifetime,
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *userService) ResetUserPassword(ctx context.Context, opts ResetUserPasswordOptions) error {
+	logger := s.logger.
+		Named("ResetUserPassword").
+		WithContext(ctx)
+
+	tokenHash := token.HashToken(opts.Token)
+	resetToken, err := s.storages.PasswordReset.GetPasswordResetToken(ctx, GetPasswordResetTokenFilter{
+		TokenHash: &tokenHash,
+	})
+	if err != nil {
+		logger.Error("failed to get password reset token", "err", err)
+		return fmt.Errorf("failed to get password reset token: %w", err)
+	}
+	if resetToken == nil || !resetToken.IsUsable(time.Now()) {
+		logger.Info("password reset token is not usable", "resetToken", resetToken)
+		return ErrResetUserPasswordInvalidToken
+	}
+	logger = logger.With("resetToken", resetToken)
+	logger.Debug("got password reset token")
+	ctx = contextWithTokenTenant(ctx, resetToken.TenantID)
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &resetToken.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return ErrResetUserPasswordInvalidToken
+	}
+	logger.Debug("got user")
+
+	violations, err := s.validatePassword(&password.ValidateOptions{
+		Password:   opts.NewPassword,
+		UserInputs: []string{user.Name, user.Surname, getEmailLocalPart(user.EmailAddress)},
+	})
+	if err != nil {
+		logger.Error("failed to validate password", "err", err)
+		return fmt.Errorf("failed to validate password: %w", err)
+	}
+	if len(violations) > 0 {
+		logger.Info("password does not satisfy password policy", "violations", violations)
+		return errs.WithDetails(ErrResetUserPasswordWeakPassword, violations)
+	}
+
+	isPasswordReused, err := s.isPasswordReused(ctx, user, opts.NewPassword)
+	if err != nil {
+		logger.Error("failed to check password reuse", "err", err)
+		return fmt.Errorf("failed to check password reuse: %w", err)
+	}
+	if isPasswordReused {
+		logger.Info("password was used recently")
+		return ErrResetUserPasswordPasswordReused
+	}
+
+	// Mark token as used right before changing the password, so concurrent requests can not use it twice
+	isUsed, err := s.storages.PasswordReset.UsePasswordResetToken(ctx, resetToken.ID)
+	if err != nil {
+		logger.Error("failed to use password reset token", "err", err)
+		return fmt.Errorf("failed to use password reset token: %w", err)
+	}
+	if !isUsed {
+		logger.Info("password reset token has been already used")
+		return ErrResetUserPasswordInvalidToken
+	}
+
+	err = s.setUserPassword(ctx, user, opts.NewPassword)
+	if err != nil {
+		logger.Error("failed to set user password", "err", err)
+		return fmt.Errorf("failed to set user password: %w", err)
+	}
+
+	err = s.storages.Session.RevokeSessions(ctx, RevokeSessionsFilter{
+		UserID: user.ID,
+	})
+	if err != nil {
+		logger.Error("failed to revoke sessions", "err", err)
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	logger.Info("successfully reset user password")
+	return nil
+}
+
+func (s *userService) ReauthenticateUser(ctx context.Context, opts ReauthenticateUserOptions) (*UserTokenOutput, error) {
+	logger := s.logger.
+		Named("ReauthenticateUser").
+		WithContext(ctx).
+		With("userID", opts.UserID, "sessionID", opts.SessionID)
+
+	err := s.authorizeUserAccess(ctx, opts.UserID, entity.PermissionUsersUpdate)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("access is forbidden")
+			return nil, err
+		}
+		logger.Error("failed to authorize user access", "err", err)
+		return nil, fmt.Errorf("failed to authorize user access: %w", err)
+	}
+
+	session, err := s.storages.Session.GetSession(ctx, GetSessionFilter{ID: &opts.SessionID})
+	if err != nil {
+		logger.Error("failed to get session", "err", err)
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session == nil || session.UserID != opts.UserID || !session.IsActive(time.Now()) {
+		logger.Info("session is not active", "session", session)
+		return nil, ErrReauthenticateUserInvalidToken
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &opts.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return nil, ErrReauthenticateUserInvalidToken
+	}
+	logger.Debug("got user")
+
+	// Password and MFA code are guessed the same way as on login, so attempts are limited by the same counters
+	throttleSubjects := s.getLoginThrottleSubjects(user.TenantID, user.EmailAddress, opts.IPAddress)
+	attempt, err := s.acquireLoginAttempt(ctx, throttleSubjects)
+	if err != nil {
+		logger.Error("failed to acquire login attempt", "err", err)
+		return nil, fmt.Errorf("failed to acquire login attempt: %w", err)
+	}
+	if attempt.isLocked {
+		logger.Info("login is locked", "retryAfter", attempt.retryAfter)
+		return nil, errs.WithDetails(ErrReauthenticateUserAccountLocked, newRetryAfterDetails(attempt.retryAfter))
+	}
+	if attempt.retryAfter > 0 {
+		logger.Info("login is delayed", "retryAfter", attempt.retryAfter)
+		return nil, errs.WithDetails(ErrReauthenticateUserTooManyAttempts, newRetryAfterDetails(attempt.retryAfter))
+	}
+
+	// Passwordless user has no password to compare with
+	if user.Password == "" {
+		logger.Info("user has no password")
+		return nil, ErrReauthenticateUserInvalidPassword
+	}
+	isPasswordCorrect, err := s.passwordHasher.CompareHashAndPassword(&password.CompareHashAndPasswordOptions{
+		Hashed:   user.Password,
+		Password: opts.Password,
+	})
+	if err != nil {
+		logger.Error("failed to check password correctness", "err", err)
+		return nil, fmt.Errorf("failed to check password correctness: %w", err)
+	}
+	if !isPasswordCorrect {
+		logger.Info("invalid password")
+		return nil, ErrReauthenticateUserInvalidPassword
+	}
+	authMethods := []string{AuthMethodPassword}
+
+	// User with enabled MFA has to pass the second factor again as well
+	if user.IsMFAEnabled() {
+		authMethod, isCodeValid, err := s.useUserMFACode(ctx, user, opts.Code, opts.RecoveryCode)
+		if err != nil {
+			logger.Error("failed to use mfa code", "err", err)
+			return nil, fmt.Errorf("failed to use mfa code: %w", err)
+		}
+		if !isCodeValid {
+			logger.Info("invalid mfa code")
+			return nil, ErrReauthenticateUserInvalidCode
+		}
+		authMethods = append(authMethods, authMethod, AuthMethodMFA)
+	}
+
+	err = s.releaseLoginAttempt(ctx, throttleSubjects)
+	if err != nil {
+		logger.Error("failed to release login attempt", "err", err)
+		return nil, fmt.Errorf("failed to release login attempt: %w", err)
+	}
+
+	tokens, err := s.generateSessionTokens(ctx, user, session, time.Now(), authMethods)
+	if err != nil {
+		logger.Error("failed to generate session tokens", "err", err)
+		return nil, fmt.Errorf("failed to generate session tokens: %w", err)
+	}
+
+	logger.Info("user reauthenticated")
+	return tokens, nil
+}
+
 func (s *userService) RefreshUserToken(ctx context.Context, refreshToken string) (*UserTokenOutput, error) {
 	logger := s.logger.
 		Named("RefreshUserToken").
 		WithContext(ctx).
 		With("refreshToken", refreshToken)
 
-	user, err := s.VerifyUserToken(ctx, refreshToken)
+	verified, err := s.VerifyUserToken(ctx, refreshToken)
 	if err != nil {
 		if errs.IsExpected(err) {
 			logger.Info(err.Error())
@@ -225,9 +854,22 @@ func (s *userService) RefreshUserToken(ctx context.Context, refreshToken string)
 			return nil, fmt.Errorf("failed to verify token: %w", err)
 		}
 	}
-	logger.Debug("got user", "user", user)
+	logger.Debug("got user", "user", verified.User)
+	// New tokens are scoped to the same organization
+	ctx = ContextWithTenant(ctx, verified.TenantID)
 
-	tokens, err := s.GenerateUserToken(ctx, user)
+	// Prolong the session, so it expires together with the new refresh token
+	session, err := s.storages.Session.UpdateSession(ctx, verified.SessionID, &entity.Session{
+		ID:        verified.SessionID,
+		ExpiresAt: time.Now().Add(s.cfg.Auth.RefreshTokenLifetime),
+	})
+	if err != nil {
+		logger.Error("failed to update session", "err", err)
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	// Refreshed tokens keep the original authentication time, so refresh can not be used instead of reauthentication
+	tokens, err := s.generateSessionTokens(ctx, verified.User, session, verified.AuthTime, verified.AuthMethods)
 	if err != nil {
 		logger.Error("failed to generate token", "err", err)
 		return nil, fmt.Errorf("failed to generate token: %w", err)
@@ -237,7 +879,7 @@ func (s *userService) RefreshUserToken(ctx context.Context, refreshToken string)
 	return tokens, nil
 }
 
-func (s *userService) VerifyUserToken(ctx context.Context, tokenStr string) (*entity.User, error) {
+func (s *userService) VerifyUserToken(ctx context.Context, tokenStr string) (*VerifyUserTokenOutput, error) {
 	logger := s.logger.
 		Named("VerifyUserToken").
 		WithContext(ctx).
@@ -252,6 +894,35 @@ func (s *userService) VerifyUserToken(ctx context.Context, tokenStr string) (*en
 	if err := mapstructure.Decode(claims.GetPayload(), &claimsData); err != nil {
 		return nil, ErrVerifyUserTokenInvalidToken
 	}
+	// Tokens without session are not issued for authentication
+	if claimsData.SessionID == "" {
+		logger.Info("token has no session")
+		return nil, ErrVerifyUserTokenInvalidToken
+	}
+
+	// Token is valid only within the organization it was issued in. Requests not scoped to an organization
+	// explicitly are scoped to the token one.
+	claimsTenantID := claimsData.TenantID
+	if claimsTenantID == "" {
+		claimsTenantID = entity.DefaultOrganizationID
+	}
+	tenantID, ok := tenantFromContext(ctx)
+	if !ok {
+		ctx = ContextWithTenant(ctx, claimsTenantID)
+	} else if tenantID != claimsTenantID {
+		logger.Info("token is issued in another organization", "tenantID", tenantID, "claimsTenantID", claimsTenantID)
+		return nil, ErrVerifyUserTokenInvalidToken
+	}
+
+	session, err := s.storages.Session.GetSession(ctx, GetSessionFilter{ID: &claimsData.SessionID})
+	if err != nil {
+		logger.Error("failed to get session", "err", err)
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session == nil || session.UserID != claimsData.UserID || !session.IsActive(time.Now()) {
+		logger.Info("session is not active", "session", session)
+		return nil, ErrVerifyUserTokenInvalidToken
+	}
 
 	// Get user from storage by token &claims.UserID
 	user, err := s.storages.User.GetUser(ctx, GetUserFilter{ID: &claimsData.UserID})
@@ -264,27 +935,98 @@ func (s *userService) VerifyUserToken(ctx context.Context, tokenStr string) (*en
 		return nil, ErrVerifyUserTokenUserNotFound
 	}
 
+	// Permissions and groups are loaded on every request, so role and group changes apply without token refresh
+	permissions, err := s.storages.Role.ListUserPermissions(ctx, user.ID)
+	if err != nil {
+		logger.Error("failed to list user permissions", "err", err)
+		return nil, fmt.Errorf("failed to list user permissions: %w", err)
+	}
+	groupNames, err := s.listUserGroupNames(ctx, user.ID)
+	if err != nil {
+		logger.Error("failed to list user groups", "err", err)
+		return nil, fmt.Errorf("failed to list user groups: %w", err)
+	}
+
 	logger.Info("verified token", "user", user)
-	return user, nil
+	return &VerifyUserTokenOutput{
+		User:        user,
+		SessionID:   session.ID,
+		AuthTime:    time.Unix(claimsData.AuthTime, 0),
+		AuthMethods: claimsData.AMR,
+		Permissions: permissions,
+		Groups:      groupNames,
+		TenantID:    claimsTenantID,
+	}, nil
 }
 
-func (ts *userService) GenerateUserToken(ctx context.Context, user *entity.User) (*UserTokenOutput, error) {
-	logger := ts.logger.
+func (s *userService) GenerateUserToken(ctx context.Context, opts GenerateUserTokenOptions) (*UserTokenOutput, error) {
+	logger := s.logger.
 		Named("GenerateUserToken").
 		WithContext(ctx).
-		With("user", user)
+		With("user", opts.User, "authMethods", opts.AuthMethods)
+
+	session, err := s.storages.Session.CreateSession(ctx, &entity.Session{
+		UserID:    opts.User.ID,
+		ExpiresAt: time.Now().Add(s.cfg.Auth.RefreshTokenLifetime),
+	})
+	if err != nil {
+		logger.Error("failed to create session", "err", err)
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	logger.Debug("created session", "session", session)
+
+	authTime := opts.AuthTime
+	if authTime.IsZero() {
+		authTime = time.Now()
+	}
+
+	tokens, err := s.generateSessionTokens(ctx, opts.User, session, authTime, opts.AuthMethods)
+	if err != nil {
+		logger.Error("failed to generate session tokens", "err", err)
+		return nil, fmt.Errorf("failed to generate session tokens: %w", err)
+	}
+
+	logger.Info("generated user tokens")
+	return tokens, nil
+}
+
+// generateSessionTokens is used to generate a pair of access and refresh tokens within the session.
+// authTime and authMethods describe when and how the user authenticated last time.
+func (ts *userService) generateSessionTokens(
+	ctx context.Context, user *entity.User, session *entity.Session, authTime time.Time, authMethods []string,
+) (*UserTokenOutput, error) {
+	logger := ts.logger.
+		Named("generateSessionTokens").
+		WithContext(ctx).
+		With("user", user, "session", session)
+
+	// Token is scoped to the organization user logged in, which is not necessarily the one user registered in
+	claimsData := token.UserDataClaims{
+		UserID:    user.ID,
+		TenantID:  TenantFromContext(ctx),
+		SessionID: session.ID,
+		AuthTime:  authTime.Unix(),
+		AMR:       authMethods,
+	}
+	// Groups claim is informational for other services, authorization here always uses the current groups
+	if ts.cfg.Auth.GroupsClaim {
+		groupNames, err := ts.listUserGroupNames(ctx, user.ID)
+		if err != nil {
+			logger.Error("failed to list user groups", "err", err)
+			return nil, fmt.Errorf("failed to list user groups: %w", err)
+		}
+		claimsData.Groups = groupNames
+	}
 
 	// Create new Access token
 	t := time.Now()
 	accessToken, err := token.SignJWTToken(
 		&token.UniversalClaims{
-			Iss:   ts.cfg.Auth.TokenIssuer,
-			ExpAt: t.Add(ts.cfg.Auth.AccessTokenLifetime),
-			NbfAt: t,
-			IssAt: t,
-			Payload: token.UserDataClaims{
-				UserID: user.ID,
-			},
+			Iss:     ts.cfg.Auth.TokenIssuer,
+			ExpAt:   t.Add(ts.cfg.Auth.AccessTokenLifetime),
+			NbfAt:   t,
+			IssAt:   t,
+			Payload: claimsData,
 		},
 		ts.cfg.Auth.TokenSecretKey,
 	)
@@ -298,13 +1040,11 @@ func (ts *userService) GenerateUserToken(ctx context.Context, user *entity.User)
 	t = time.Now()
 	refreshToken, err := token.SignJWTToken(
 		&token.UniversalClaims{
-			Iss:   ts.cfg.Auth.TokenIssuer,
-			ExpAt: t.Add(ts.cfg.Auth.RefreshTokenLifetime),
-			NbfAt: t,
-			IssAt: t,
-			Payload: token.UserDataClaims{
-				UserID: user.ID,
-			},
+			Iss:     ts.cfg.Auth.TokenIssuer,
+			ExpAt:   t.Add(ts.cfg.Auth.RefreshTokenLifetime),
+			NbfAt:   t,
+			IssAt:   t,
+			Payload: claimsData,
 		},
 		ts.cfg.Auth.TokenSecretKey,
 	)
@@ -319,3 +1059,240 @@ func (ts *userService) GenerateUserToken(ctx context.Context, user *entity.User)
 		RefreshToken: refreshToken,
 	}, nil
 }
+
+// listUserGroupNames returns names of groups user is an effective member of in the organization from context.
+func (s *userService) listUserGroupNames(ctx context.Context, userID string) ([]string, error) {
+	groups, err := s.storages.Group.ListUserGroups(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user groups: %w", err)
+	}
+
+	names := make([]string, 0, len(groups))
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+
+	return names, nil
+}
+
+// signPurposeToken is used to sign a single-purpose token with given lifetime.
+func (s *userService) signPurposeToken(claims token.PurposeClaims, lifetime time.Duration) (string, error) {
+	t := time.Now()
+	return token.SignJWTToken(
+		&token.UniversalClaims{
+			Iss:     s.cfg.Auth.TokenIssuer,
+			ExpAt:   t.Add(lifetime),
+			NbfAt:   t,
+			IssAt:   t,
+			Payload: claims,
+		},
+		s.cfg.Auth.TokenSecretKey,
+	)
+}
+
+// verifyPurposeToken is used to verify a single-purpose token and check if it was issued for given purpose.
+func (s *userService) verifyPurposeToken(tokenStr, purpose string) (*token.PurposeClaims, error) {
+	claims, err := token.VerifyJWTToken(tokenStr, s.cfg.Auth.TokenSecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify token: %w", err)
+	}
+
+	var claimsData token.PurposeClaims
+	if err := mapstructure.Decode(claims.GetPayload(), &claimsData); err != nil {
+		return nil, fmt.Errorf("failed to decode token payload: %w", err)
+	}
+	if claimsData.Purpose != purpose {
+		return nil, fmt.Errorf("token is issued for %q purpose", claimsData.Purpose)
+	}
+
+	return &claimsData, nil
+}
+
+// useSingleUseToken marks the single-use token as used and returns false if it has been already used.
+// Used token is remembered for the token lifetime, after that it is rejected as expired.
+func (s *userService) useSingleUseToken(ctx context.Context, claims *token.PurposeClaims, lifetime time.Duration) (bool, error) {
+	if claims.TokenID == "" {
+		return false, nil
+	}
+
+	isFirstUse, err := s.storages.UsedToken.CreateUsedToken(ctx, &entity.UsedToken{
+		ID:        claims.TokenID,
+		UserID:    claims.UserID,
+		ExpiresAt: time.Now().Add(lifetime),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to create used token: %w", err)
+	}
+
+	return isFirstUse, nil
+}
+
+// setUserPassword hashes and stores the new user password and saves it in the password history.
+func (s *userService) setUserPassword(ctx context.Context, user *entity.User, newPassword string) error {
+	hashedPassword, err := s.passwordHasher.GenerateHashFromPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	_, err = s.storages.User.UpdateUser(ctx, user.ID, &entity.User{Password: hashedPassword})
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	user.Password = hashedPassword
+
+	err = s.savePasswordHistory(ctx, user.ID, hashedPassword)
+	if err != nil {
+		return fmt.Errorf("failed to save password history: %w", err)
+	}
+
+	return nil
+}
+
+// validatePassword checks the password against the password policy and the breach corpus
+// and returns all failed rules.
+func (s *userService) validatePassword(opts *password.ValidateOptions) ([]password.Violation, error) {
+	violations := s.passwordValidator.Validate(opts)
+	if s.passwordBreachChecker == nil {
+		return violations, nil
+	}
+
+	breached, err := s.passwordBreachChecker.IsBreached(opts.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if password is breached: %w", err)
+	}
+	if breached {
+		violations = append(violations, password.Violation{
+			Rule:    password.RuleBreached,
+			Message: "password has appeared in a data breach",
+		})
+	}
+
+	return violations, nil
+}
+
+// isPasswordReused checks if the password matches the current user password or one of
+// the latest passwords from the user password history.
+func (s *userService) isPasswordReused(ctx context.Context, user *entity.User, newPassword string) (bool, error) {
+	var history password.History
+	if s.cfg.PasswordPolicy.HistorySize > 0 {
+		entries, err := s.storages.PasswordHistory.ListPasswordHistory(ctx, ListPasswordHistoryFilter{
+			UserID: user.ID,
+			Limit:  s.cfg.PasswordPolicy.HistorySize,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to list password history: %w", err)
+		}
+		history = newPasswordHistory(entries)
+	}
+
+	return password.IsReused(s.passwordHasher, newPassword, user.Password, history.Window(s.cfg.PasswordPolicy.HistorySize))
+}
+
+// savePasswordHistory stores the password hash in the user password history and deletes entries
+// exceeding the configured history size.
+func (s *userService) savePasswordHistory(ctx context.Context, userID, hashedPassword string) error {
+	if s.cfg.PasswordPolicy.HistorySize <= 0 {
+		return nil
+	}
+
+	_, err := s.storages.PasswordHistory.CreatePasswordHistory(ctx, &entity.PasswordHistory{
+		UserID:   userID,
+		Password: hashedPassword,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create password history: %w", err)
+	}
+
+	entries, err := s.storages.PasswordHistory.ListPasswordHistory(ctx, ListPasswordHistoryFilter{
+		UserID: userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list password history: %w", err)
+	}
+	prunedIDs := newPasswordHistory(entries).Prune(s.cfg.PasswordPolicy.HistorySize)
+	if len(prunedIDs) == 0 {
+		return nil
+	}
+
+	err = s.storages.PasswordHistory.DeletePasswordHistory(ctx, prunedIDs)
+	if err != nil {
+		return fmt.Errorf("failed to delete password history: %w", err)
+	}
+
+	return nil
+}
+
+// newPasswordHistory returns the password history of the stored entries ordered from the latest.
+func newPasswordHistory(entries []entity.PasswordHistory) password.History {
+	history := make(password.History, 0, len(entries))
+	for _, entry := range entries {
+		history = append(history, password.HistoryEntry{ID: entry.ID, Hash: entry.Password})
+	}
+	return history
+}
+
+// getEmailLocalPart returns the part of email address before "@".
+func getEmailLocalPart(emailAddress string) string {
+	localPart, _, _ := strings.Cut(emailAddress, "@")
+	return localPart
+}
+
+// compareDummyPassword compares the password with the hash of a random one, so the comparison takes
+// the same time as for the existing user.
+func (s *userService) compareDummyPassword(pass string) error {
+	s.dummyPasswordHashOnce.Do(func() {
+		var dummyPassword string
+		dummyPassword, s.dummyPasswordHashErr = token.GenerateRandomToken(passwordResetTokenLength)
+		if s.dummyPasswordHashErr != nil {
+			return
+		}
+		s.dummyPasswordHash, s.dummyPasswordHashErr = s.passwordHasher.GenerateHashFromPassword(dummyPassword)
+	})
+	if s.dummyPasswordHashErr != nil {
+		return fmt.Errorf("failed to generate dummy password hash: %w", s.dummyPasswordHashErr)
+	}
+
+	_, err := s.passwordHasher.CompareHashAndPassword(&password.CompareHashAndPasswordOptions{
+		Hashed:   s.dummyPasswordHash,
+		Password: pass,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to compare password: %w", err)
+	}
+
+	return nil
+}
+
+// sendRegistrationAttemptNotice emails the user that somebody tried to register with the user email.
+// Repeated attempts are not notified about within the cooldown, so registration can not be used to flood the user.
+func (s *userService) sendRegistrationAttemptNotice(ctx context.Context, user *entity.User) error {
+	// Deleted user is not notified, since the time of the notice could not be saved for it
+	if user.DeletedAt.Valid {
+		return nil
+	}
+	if user.RegistrationNoticeSentAt != nil && time.Since(*user.RegistrationNoticeSentAt) < s.cfg.Auth.RegistrationNoticeCooldown {
+		return nil
+	}
+
+	err := s.mailer.Send(ctx, &mailer.Message{
+		To:      user.EmailAddress,
+		Subject: "Registration attempt with your email address",
+		Body: fmt.Sprintf(
+			"Somebody tried to register a new account with your email address, but you already have one.\nIf it was you, log in instead or reset your password: %s/password/forgot\nOtherwise, ignore this email.",
+			s.cfg.App.FrontendURL,
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	now := time.Now()
+	_, err = s.storages.User.UpdateUser(ctx, user.ID, &entity.User{
+		RegistrationNoticeSentAt: &now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
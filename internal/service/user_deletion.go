@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/pkg/errs"
+	"github.com/taraslis453/solid-software-test/pkg/password"
+)
+
+func (s *userService) DeleteUserAccount(ctx context.Context, opts DeleteUserAccountOptions) error {
+	logger := s.logger.
+		Named("DeleteUserAccount").
+		WithContext(ctx).
+		With("userID", opts.UserID)
+
+	err := authorizeSelf(ctx, opts.UserID)
+	if err != nil {
+		logger.Info("access is forbidden")
+		return err
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &opts.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return ErrDeleteUserAccountUserNotFound
+	}
+	logger.Debug("got user")
+
+	// Deletion is confirmed either with the password or by recent authentication, e.g. for passwordless users
+	if opts.Password != "" {
+		// Password is guessed the same way as on login, so attempts are limited by the same counters
+		throttleSubjects := s.getLoginThrottleSubjects(user.TenantID, user.EmailAddress, opts.IPAddress)
+		attempt, err := s.acquireLoginAttempt(ctx, throttleSubjects)
+		if err != nil {
+			logger.Error("failed to acquire login attempt", "err", err)
+			return fmt.Errorf("failed to acquire login attempt: %w", err)
+		}
+		if attempt.isLocked {
+			logger.Info("login is locked", "retryAfter", attempt.retryAfter)
+			return errs.WithDetails(ErrDeleteUserAccountAccountLocked, newRetryAfterDetails(attempt.retryAfter))
+		}
+		if attempt.retryAfter > 0 {
+			logger.Info("login is delayed", "retryAfter", attempt.retryAfter)
+			return errs.WithDetails(ErrDeleteUserAccountTooManyAttempts, newRetryAfterDetails(attempt.retryAfter))
+		}
+
+		// Passwordless user has no password to compare with
+		if user.Password == "" {
+			logger.Info("user has no password")
+			return ErrDeleteUserAccountInvalidPassword
+		}
+		isPasswordCorrect, err := s.passwordHasher.CompareHashAndPassword(&password.CompareHashAndPasswordOptions{
+			Hashed:   user.Password,
+			Password: opts.Password,
+		})
+		if err != nil {
+			logger.Error("failed to check password correctness", "err", err)
+			return fmt.Errorf("failed to check password correctness: %w", err)
+		}
+		if !isPasswordCorrect {
+			logger.Info("invalid password")
+			return ErrDeleteUserAccountInvalidPassword
+		}
+
+		// User with enabled MFA has to pass the second factor as well
+		if user.IsMFAEnabled() {
+			_, isCodeValid, err := s.useUserMFACode(ctx, user, opts.Code, opts.RecoveryCode)
+			if err != nil {
+				logger.Error("failed to use mfa code", "err", err)
+				return fmt.Errorf("failed to use mfa code: %w", err)
+			}
+			if !isCodeValid {
+				logger.Info("invalid mfa code")
+				return ErrDeleteUserAccountInvalidCode
+			}
+		}
+
+		err = s.releaseLoginAttempt(ctx, throttleSubjects)
+		if err != nil {
+			logger.Error("failed to release login attempt", "err", err)
+			return fmt.Errorf("failed to release login attempt: %w", err)
+		}
+	} else {
+		principal := PrincipalFromContext(ctx)
+		if time.Since(principal.AuthTime) > s.cfg.Auth.ReauthWindow {
+			logger.Info("reauthentication required", "authTime", principal.AuthTime)
+			return ErrDeleteUserAccountReauthRequired
+		}
+	}
+
+	err = s.deleteUser(ctx, user.ID, user.ID)
+	if err != nil {
+		logger.Error("failed to delete user", "err", err)
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	logger.Info("successfully deleted user account")
+	return nil
+}
+
+func (s *userService) PurgeDeletedUsers(ctx context.Context) error {
+	logger := s.logger.
+		Named("PurgeDeletedUsers").
+		WithContext(ctx)
+
+	count, err := s.storages.User.PurgeUsers(ctx, PurgeUsersFilter{
+		DeletedBefore:     time.Now().Add(-s.cfg.Auth.DeletionGracePeriod),
+		ThrottleKeyPrefix: userLoginThrottleKeyPrefix,
+		RelationNamespace: userRelationNamespace,
+	})
+	if err != nil {
+		logger.Error("failed to purge users", "err", err)
+		return fmt.Errorf("failed to purge users: %w", err)
+	}
+
+	logger.Info("successfully purged deleted users", "count", count)
+	return nil
+}
+
+// deleteUser soft deletes the user on behalf of deletedByID and revokes all user sessions, so issued tokens stop
+// working at once.
+func (s *userService) deleteUser(ctx context.Context, userID, deletedByID string) error {
+	err := s.storages.User.DeleteUser(ctx, userID, deletedByID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	err = s.storages.Session.RevokeSessions(ctx, RevokeSessionsFilter{
+		UserID: userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+// isUserRestorable returns true if the user is not deleted or can be restored by logging in. Only users who deleted
+// the account themselves can restore it, deletion by an administrator is final.
+func (s *userService) isUserRestorable(user *entity.User) bool {
+	if !user.DeletedAt.Valid {
+		return true
+	}
+	return user.IsDeletedBySelf() && time.Since(user.DeletedAt.Time) <= s.cfg.Auth.DeletionGracePeriod
+}
+
+// restoreUser undoes deletion of the user logging in within the grace period. It does nothing for not deleted users.
+func (s *userService) restoreUser(ctx context.Context, user *entity.User) error {
+	if !user.DeletedAt.Valid {
+		return nil
+	}
+
+	err := s.storages.User.RestoreUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to restore user: %w", err)
+	}
+	user.DeletedAt.Valid = false
+
+	return nil
+}
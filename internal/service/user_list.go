@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+)
+
+const (
+	defaultListUsersLimit = 20
+	maxListUsersLimit     = 100
+)
+
+// listUsersCursor is the position in the list encoded into the opaque cursor.
+// Sort is kept to reject the cursor if the next page is requested with another sort.
+type listUsersCursor struct {
+	SortBy       UserSortField `json:"sortBy"`
+	IsDescending bool          `json:"isDescending"`
+	UserCursor
+}
+
+func (s *userService) ListUsers(ctx context.Context, opts ListUsersOptions) (*ListUsersOutput, error) {
+	logger := s.logger.
+		Named("ListUsers").
+		WithContext(ctx).
+		With("opts", opts)
+
+	err := authorizePermission(ctx, entity.PermissionUsersRead)
+	if err != nil {
+		logger.Info("principal is not allowed to list users")
+		return nil, err
+	}
+
+	filter, err := s.getListUsersFilter(opts)
+	if err != nil {
+		logger.Info("invalid filter", "err", err)
+		return nil, err
+	}
+
+	if opts.Cursor != "" {
+		cursor, err := decodeListUsersCursor(opts.Cursor)
+		if err != nil || cursor.SortBy != filter.SortBy || cursor.IsDescending != filter.IsDescending {
+			logger.Info("invalid cursor", "err", err)
+			return nil, ErrListUsersInvalidCursor
+		}
+		filter.After = &cursor.UserCursor
+	}
+
+	// One more user is requested to know whether there is the next page
+	limit := filter.Limit
+	filter.Limit++
+	users, err := s.storages.User.ListUsers(ctx, filter)
+	if err != nil {
+		logger.Error("failed to list users", "err", err)
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	var nextCursor string
+	if len(users) > limit {
+		users = users[:limit]
+		last := users[limit-1]
+		nextCursor = encodeListUsersCursor(listUsersCursor{
+			SortBy:       filter.SortBy,
+			IsDescending: filter.IsDescending,
+			UserCursor: UserCursor{
+				ID:           last.ID,
+				CreatedAt:    last.CreatedAt,
+				EmailAddress: last.EmailAddress,
+				Name:         last.Name,
+			},
+		})
+	}
+
+	logger.Info("successfully listed users", "count", len(users))
+	return &ListUsersOutput{
+		Users:      users,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// getListUsersFilter returns the storage filter for the options or ErrListUsersInvalidFilter.
+func (s *userService) getListUsersFilter(opts ListUsersOptions) (ListUsersFilter, error) {
+	filter := ListUsersFilter{
+		CreatedAfter:  opts.CreatedAfter,
+		CreatedBefore: opts.CreatedBefore,
+		SortBy:        opts.SortBy,
+		IsDescending:  opts.IsDescending,
+		Limit:         opts.Limit,
+	}
+	if opts.Search != "" {
+		filter.Search = &opts.Search
+	}
+	if opts.EmailDomain != "" {
+		filter.EmailDomain = &opts.EmailDomain
+	}
+
+	switch opts.Status {
+	case "":
+	case entity.UserStatusActive:
+		isVerified := true
+		filter.EmailVerified = &isVerified
+		filter.Lockout = s.getUserLockoutFilter(false)
+	case entity.UserStatusUnverified:
+		isVerified := false
+		filter.EmailVerified = &isVerified
+	case entity.UserStatusLocked:
+		filter.Lockout = s.getUserLockoutFilter(true)
+	default:
+		return ListUsersFilter{}, ErrListUsersInvalidFilter
+	}
+
+	switch opts.SortBy {
+	case "":
+		filter.SortBy = UserSortFieldCreatedAt
+	case UserSortFieldCreatedAt, UserSortFieldEmailAddress, UserSortFieldName:
+	default:
+		return ListUsersFilter{}, ErrListUsersInvalidFilter
+	}
+
+	switch {
+	case opts.Limit == 0:
+		filter.Limit = defaultListUsersLimit
+	case opts.Limit < 0 || opts.Limit > maxListUsersLimit:
+		return ListUsersFilter{}, ErrListUsersInvalidFilter
+	}
+
+	return filter, nil
+}
+
+// getUserLockoutFilter returns the filter matching users locked out the same way as getLoginWait does.
+func (s *userService) getUserLockoutFilter(isLockedOut bool) *UserLockoutFilter {
+	// Lock lasts for the lockout duration after the last failure, unless the counter gets stale earlier
+	lockWindow := s.cfg.Lockout.Duration
+	if s.cfg.Lockout.AttemptsWindow < lockWindow {
+		lockWindow = s.cfg.Lockout.AttemptsWindow
+	}
+
+	return &UserLockoutFilter{
+		IsLockedOut:       isLockedOut,
+		ThrottleKeyPrefix: userLoginThrottleKeyPrefix,
+		MaxAttempts:       s.cfg.Lockout.UserMaxAttempts,
+		FailedAfter:       time.Now().Add(-lockWindow),
+	}
+}
+
+// encodeListUsersCursor returns the opaque cursor of the position.
+func encodeListUsersCursor(cursor listUsersCursor) string {
+	// Marshaling of the struct with plain fields can not fail
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeListUsersCursor returns the position the cursor refers to.
+func decodeListUsersCursor(cursor string) (*listUsersCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cursor: %w", err)
+	}
+
+	var decoded listUsersCursor
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cursor: %w", err)
+	}
+	if decoded.ID == "" {
+		return nil, fmt.Errorf("cursor has no user id")
+	}
+
+	return &decoded, nil
+}
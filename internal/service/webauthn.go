@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/taraslis453/solid-software-test/pkg/errs"
+	"github.com/taraslis453/solid-software-test/pkg/token"
+	"github.com/taraslis453/solid-software-test/pkg/webauthn"
+
+	"github.com/taraslis453/solid-software-test/internal/entity"
+)
+
+func (s *userService) BeginUserWebAuthnRegistration(ctx context.Context, userID string) (*BeginUserWebAuthnRegistrationOutput, error) {
+	logger := s.logger.
+		Named("BeginUserWebAuthnRegistration").
+		WithContext(ctx).
+		With("userID", userID)
+
+	err := s.authorizeUserAccess(ctx, userID, entity.PermissionUsersUpdate)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("access is forbidden")
+			return nil, err
+		}
+		logger.Error("failed to authorize user access", "err", err)
+		return nil, fmt.Errorf("failed to authorize user access: %w", err)
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &userID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return nil, ErrBeginUserWebAuthnRegistrationUserNotFound
+	}
+	logger.Debug("got user")
+
+	credentialIDs, err := s.listUserWebAuthnCredentialIDs(ctx, user.ID)
+	if err != nil {
+		logger.Error("failed to list user webauthn credentials", "err", err)
+		return nil, fmt.Errorf("failed to list user webauthn credentials: %w", err)
+	}
+
+	options, err := s.webAuthn.BeginRegistration(webauthn.UserEntity{
+		ID:          []byte(user.ID),
+		Name:        user.EmailAddress,
+		DisplayName: strings.TrimSpace(user.Name + " " + user.Surname),
+	}, credentialIDs)
+	if err != nil {
+		logger.Error("failed to begin registration", "err", err)
+		return nil, fmt.Errorf("failed to begin registration: %w", err)
+	}
+
+	ceremonyToken, err := s.signWebAuthnCeremonyToken(webAuthnRegistrationTokenPurpose, user.ID, options.Challenge)
+	if err != nil {
+		logger.Error("failed to sign ceremony token", "err", err)
+		return nil, fmt.Errorf("failed to sign ceremony token: %w", err)
+	}
+
+	logger.Info("successfully began webauthn registration")
+	return &BeginUserWebAuthnRegistrationOutput{
+		Options:       options,
+		CeremonyToken: ceremonyToken,
+	}, nil
+}
+
+func (s *userService) FinishUserWebAuthnRegistration(ctx context.Context, opts FinishUserWebAuthnRegistrationOptions) (*entity.WebAuthnCredential, error) {
+	logger := s.logger.
+		Named("FinishUserWebAuthnRegistration").
+		WithContext(ctx).
+		With("userID", opts.UserID)
+
+	err := s.authorizeUserAccess(ctx, opts.UserID, entity.PermissionUsersUpdate)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info("access is forbidden")
+			return nil, err
+		}
+		logger.Error("failed to authorize user access", "err", err)
+		return nil, fmt.Errorf("failed to authorize user access: %w", err)
+	}
+
+	claims, challenge, err := s.useWebAuthnCeremonyToken(ctx, opts.CeremonyToken, webAuthnRegistrationTokenPurpose)
+	if err != nil {
+		logger.Error("failed to use ceremony token", "err", err)
+		return nil, fmt.Errorf("failed to use ceremony token: %w", err)
+	}
+	// Ceremony started by one user must not be finished by another one
+	if claims == nil || claims.UserID != opts.UserID {
+		logger.Info("invalid ceremony token")
+		return nil, ErrFinishUserWebAuthnRegistrationInvalidToken
+	}
+
+	credential, err := s.webAuthn.FinishRegistration(challenge, opts.Response)
+	if errors.Is(err, webauthn.ErrInvalidResponse) {
+		logger.Info("invalid webauthn response", "err", err)
+		return nil, ErrFinishUserWebAuthnRegistrationInvalidResponse
+	}
+	if err != nil {
+		logger.Error("failed to finish registration", "err", err)
+		return nil, fmt.Errorf("failed to finish registration: %w", err)
+	}
+
+	existingCredential, err := s.storages.WebAuthnCredential.GetWebAuthnCredential(ctx, GetWebAuthnCredentialFilter{
+		CredentialID: credential.ID,
+	})
+	if err != nil {
+		logger.Error("failed to get webauthn credential", "err", err)
+		return nil, fmt.Errorf("failed to get webauthn credential: %w", err)
+	}
+	if existingCredential != nil {
+		logger.Info("credential is already registered")
+		return nil, ErrFinishUserWebAuthnRegistrationCredentialExists
+	}
+
+	createdCredential, err := s.storages.WebAuthnCredential.CreateWebAuthnCredential(ctx, &entity.WebAuthnCredential{
+		UserID:       opts.UserID,
+		Name:         opts.Name,
+		CredentialID: credential.ID,
+		PublicKey:    credential.PublicKey,
+		SignCount:    credential.SignCount,
+		AAGUID:       credential.AAGUID,
+	})
+	if err != nil {
+		logger.Error("failed to create webauthn credential", "err", err)
+		return nil, fmt.Errorf("failed to create webauthn credential: %w", err)
+	}
+
+	logger.Info("successfully registered webauthn credential", "credentialID", createdCredential.ID)
+	return createdCredential, nil
+}
+
+func (s *userService) BeginUserWebAuthnLogin(ctx context.Context, opts BeginUserWebAuthnLoginOptions) (*BeginUserWebAuthnLoginOutput, error) {
+	logger := s.logger.
+		Named("BeginUserWebAuthnLogin").
+		WithContext(ctx).
+		With("opts", opts)
+
+	// If email is not passed or user is not found, authenticator is asked for a discoverable credential.
+	// With anti-enumeration it is asked so for every email, since listed credentials would reveal that user exists.
+	var userID string
+	var credentialIDs [][]byte
+	if opts.EmailAddress != "" && !s.cfg.Auth.AntiEnumeration {
+		user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+			EmailAddress: &opts.EmailAddress,
+		})
+		if err != nil {
+			logger.Error("failed to get user", "err", err)
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		if user != nil {
+			userID = user.ID
+			credentialIDs, err = s.listUserWebAuthnCredentialIDs(ctx, user.ID)
+			if err != nil {
+				logger.Error("failed to list user webauthn credentials", "err", err)
+				return nil, fmt.Errorf("failed to list user webauthn credentials: %w", err)
+			}
+		}
+	}
+
+	options, err := s.webAuthn.BeginLogin(credentialIDs)
+	if err != nil {
+		logger.Error("failed to begin login", "err", err)
+		return nil, fmt.Errorf("failed to begin login: %w", err)
+	}
+
+	ceremonyToken, err := s.signWebAuthnCeremonyToken(webAuthnLoginTokenPurpose, userID, options.Challenge)
+	if err != nil {
+		logger.Error("failed to sign ceremony token", "err", err)
+		return nil, fmt.Errorf("failed to sign ceremony token: %w", err)
+	}
+
+	logger.Info("successfully began webauthn login")
+	return &BeginUserWebAuthnLoginOutput{
+		Options:       options,
+		CeremonyToken: ceremonyToken,
+	}, nil
+}
+
+func (s *userService) FinishUserWebAuthnLogin(ctx context.Context, opts FinishUserWebAuthnLoginOptions) (LoginUserOutput, error) {
+	logger := s.logger.
+		Named("FinishUserWebAuthnLogin").
+		WithContext(ctx)
+
+	claims, challenge, err := s.useWebAuthnCeremonyToken(ctx, opts.CeremonyToken, webAuthnLoginTokenPurpose)
+	if err != nil {
+		logger.Error("failed to use ceremony token", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to use ceremony token: %w", err)
+	}
+	if claims == nil {
+		logger.Info("invalid ceremony token")
+		return LoginUserOutput{}, ErrFinishUserWebAuthnLoginInvalidToken
+	}
+
+	storedCredential, err := s.storages.WebAuthnCredential.GetWebAuthnCredential(ctx, GetWebAuthnCredentialFilter{
+		CredentialID: opts.Response.RawID,
+	})
+	if err != nil {
+		logger.Error("failed to get webauthn credential", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to get webauthn credential: %w", err)
+	}
+	if storedCredential == nil {
+		logger.Info("webauthn credential not found")
+		return LoginUserOutput{}, ErrFinishUserWebAuthnLoginInvalidResponse
+	}
+	logger = logger.With("userID", storedCredential.UserID)
+
+	// Credential has to belong to the user login was started for and the user handle returned by authenticator
+	if claims.UserID != "" && claims.UserID != storedCredential.UserID {
+		logger.Info("credential belongs to another user")
+		return LoginUserOutput{}, ErrFinishUserWebAuthnLoginInvalidResponse
+	}
+	userHandle := opts.Response.Response.UserHandle
+	if len(userHandle) > 0 && string(userHandle) != storedCredential.UserID {
+		logger.Info("user handle does not match credential owner")
+		return LoginUserOutput{}, ErrFinishUserWebAuthnLoginInvalidResponse
+	}
+
+	signCount, err := s.webAuthn.FinishLogin(challenge, &webauthn.Credential{
+		ID:        storedCredential.CredentialID,
+		PublicKey: storedCredential.PublicKey,
+		SignCount: storedCredential.SignCount,
+	}, opts.Response)
+	if errors.Is(err, webauthn.ErrInvalidResponse) {
+		logger.Info("invalid webauthn response", "err", err)
+		return LoginUserOutput{}, ErrFinishUserWebAuthnLoginInvalidResponse
+	}
+	if err != nil {
+		logger.Error("failed to finish login", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to finish login: %w", err)
+	}
+
+	now := time.Now()
+	_, err = s.storages.WebAuthnCredential.UpdateWebAuthnCredential(ctx, storedCredential.ID, &entity.WebAuthnCredential{
+		SignCount:  signCount,
+		LastUsedAt: &now,
+	})
+	if err != nil {
+		logger.Error("failed to update webauthn credential", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to update webauthn credential: %w", err)
+	}
+
+	user, err := s.storages.User.GetUser(ctx, GetUserFilter{
+		ID: &storedCredential.UserID,
+	})
+	if err != nil {
+		logger.Error("failed to get user", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		logger.Info("user not found")
+		return LoginUserOutput{}, ErrFinishUserWebAuthnLoginInvalidResponse
+	}
+	if s.isLoginBlockedByEmailVerification(user) {
+		logger.Info("email is not verified")
+		return LoginUserOutput{}, ErrFinishUserWebAuthnLoginEmailNotVerified
+	}
+
+	tokens, err := s.GenerateUserToken(ctx, GenerateUserTokenOptions{
+		User:        user,
+		AuthMethods: []string{AuthMethodHardwareKey},
+	})
+	if err != nil {
+		logger.Error("failed to generate tokens", "err", err)
+		return LoginUserOutput{}, fmt.Errorf("failed to generate tokens: %w", err)
+	}
+
+	logger.Info("user logged in with webauthn")
+	return LoginUserOutput{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		UserID:       user.ID,
+	}, nil
+}
+
+// listUserWebAuthnCredentialIDs returns IDs of all user credentials.
+func (s *userService) listUserWebAuthnCredentialIDs(ctx context.Context, userID string) ([][]byte, error) {
+	credentials, err := s.storages.WebAuthnCredential.ListWebAuthnCredentials(ctx, ListWebAuthnCredentialsFilter{
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([][]byte, 0, len(credentials))
+	for _, credential := range credentials {
+		ids = append(ids, credential.CredentialID)
+	}
+	return ids, nil
+}
+
+// signWebAuthnCeremonyToken signs the single-use token keeping the ceremony challenge,
+// so no ceremony state is stored on server until it is finished.
+func (s *userService) signWebAuthnCeremonyToken(purpose, userID string, challenge []byte) (string, error) {
+	return s.signPurposeToken(token.PurposeClaims{
+		Purpose:   purpose,
+		UserID:    userID,
+		TokenID:   uuid.NewString(),
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+	}, s.cfg.WebAuthn.CeremonyTimeout)
+}
+
+// useWebAuthnCeremonyToken verifies the ceremony token, marks it as used and returns its claims with the challenge.
+// Returns nil claims if token is invalid or has been already used.
+func (s *userService) useWebAuthnCeremonyToken(ctx context.Context, tokenStr, purpose string) (*token.PurposeClaims, []byte, error) {
+	claims, err := s.verifyPurposeToken(tokenStr, purpose)
+	if err != nil {
+		return nil, nil, nil
+	}
+	challenge, err := base64.RawURLEncoding.DecodeString(claims.Challenge)
+	if err != nil || len(challenge) == 0 {
+		return nil, nil, nil
+	}
+
+	isFirstUse, err := s.useSingleUseToken(ctx, claims, s.cfg.WebAuthn.CeremonyTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isFirstUse {
+		return nil, nil, nil
+	}
+
+	return claims, challenge, nil
+}
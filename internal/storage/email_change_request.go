@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+
+	// internal
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+)
+
+var _ service.EmailChangeRequestStorage = (*emailChangeRequestStorage)(nil)
+
+type emailChangeRequestStorage struct {
+	*postgresql.PostgreSQLGorm
+}
+
+func NewEmailChangeRequestStorage(postgresql *postgresql.PostgreSQLGorm) *emailChangeRequestStorage {
+	return &emailChangeRequestStorage{postgresql}
+}
+
+func (r *emailChangeRequestStorage) GetEmailChangeRequest(ctx context.Context, filter service.GetEmailChangeRequestFilter) (*entity.EmailChangeRequest, error) {
+	stmt := r.DB
+	if filter.ConfirmTokenHash != nil {
+		stmt = stmt.Where(entity.EmailChangeRequest{ConfirmTokenHash: *filter.ConfirmTokenHash})
+	}
+	if filter.UndoTokenHash != nil {
+		stmt = stmt.Where(entity.EmailChangeRequest{UndoTokenHash: *filter.UndoTokenHash})
+	}
+
+	var request entity.EmailChangeRequest
+	err := stmt.First(&request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email change request: %w", err)
+	}
+
+	return &request, nil
+}
+
+func (r *emailChangeRequestStorage) CreateEmailChangeRequest(ctx context.Context, request *entity.EmailChangeRequest) (*entity.EmailChangeRequest, error) {
+	err := r.DB.Create(request).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create email change request: %w", err)
+	}
+
+	return request, nil
+}
+
+func (r *emailChangeRequestStorage) ConfirmEmailChangeRequest(ctx context.Context, id string) (bool, error) {
+	result := r.DB.Model(&entity.EmailChangeRequest{}).
+		Where("id = ? AND confirmed_at IS NULL AND cancelled_at IS NULL", id).
+		Update("confirmed_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to confirm email change request: %w", result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *emailChangeRequestStorage) CancelEmailChangeRequest(ctx context.Context, id string) (bool, error) {
+	result := r.DB.Model(&entity.EmailChangeRequest{}).
+		Where("id = ? AND cancelled_at IS NULL", id).
+		Update("cancelled_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to cancel email change request: %w", result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *emailChangeRequestStorage) CancelPendingEmailChangeRequests(ctx context.Context, userID string) error {
+	err := r.DB.Model(&entity.EmailChangeRequest{}).
+		Where("user_id = ? AND confirmed_at IS NULL AND cancelled_at IS NULL", userID).
+		Update("cancelled_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to cancel pending email change requests: %w", err)
+	}
+
+	return nil
+}
+
+func (r *emailChangeRequestStorage) DeleteExpiredEmailChangeRequests(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB.Where("expires_at < ? AND undo_expires_at < ?", before, before).Delete(&entity.EmailChangeRequest{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired email change requests: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	// third party
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	// external
+	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+
+	// internal
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+)
+
+var _ service.GroupStorage = (*groupStorage)(nil)
+
+type groupStorage struct {
+	*postgresql.PostgreSQLGorm
+}
+
+func NewGroupStorage(postgresql *postgresql.PostgreSQLGorm) *groupStorage {
+	return &groupStorage{postgresql}
+}
+
+// effectiveGroupsQuery selects IDs of groups the user (first argument) is a direct member of within the organization
+// (second argument) and all groups they are nested into. UNION discards already visited groups, so recursion terminates even if groups form a cycle.
+const effectiveGroupsQuery = `
+WITH RECURSIVE effective_groups(id) AS (
+	SELECT group_members.group_id
+	FROM group_members
+	JOIN groups ON groups.id = group_members.group_id
+	WHERE group_members.user_id = ? AND groups.organization_id = ?
+	UNION
+	SELECT group_subgroups.group_id
+	FROM group_subgroups
+	JOIN effective_groups ON effective_groups.id = group_subgroups.subgroup_id
+)
+SELECT id FROM effective_groups`
+
+func (r *groupStorage) ListGroups(ctx context.Context) ([]entity.Group, error) {
+	var groups []entity.Group
+	err := r.DB.Where("organization_id = ?", service.TenantFromContext(ctx)).Order("name").Find(&groups).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+func (r *groupStorage) GetGroup(ctx context.Context, filter service.GetGroupFilter) (*entity.Group, error) {
+	stmt := r.DB.Where("organization_id = ?", service.TenantFromContext(ctx))
+	if filter.ID != nil {
+		stmt = stmt.Where(entity.Group{ID: *filter.ID})
+	}
+	if filter.Name != nil {
+		stmt = stmt.Where(entity.Group{Name: *filter.Name})
+	}
+
+	var group entity.Group
+	err := stmt.First(&group).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+
+	err = r.DB.Model(&entity.GroupMember{}).Where("group_id = ?", group.ID).Order("created_at").Pluck("user_id", &group.UserIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group members: %w", err)
+	}
+	err = r.DB.Model(&entity.GroupSubgroup{}).Where("group_id = ?", group.ID).Order("created_at").Pluck("subgroup_id", &group.SubgroupIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group subgroups: %w", err)
+	}
+	err = r.DB.Model(&entity.GroupRole{}).Where("group_id = ?", group.ID).Order("created_at").Pluck("role_id", &group.RoleIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group roles: %w", err)
+	}
+
+	return &group, nil
+}
+
+func (r *groupStorage) CreateGroup(ctx context.Context, group *entity.Group) (*entity.Group, error) {
+	group.OrganizationID = service.TenantFromContext(ctx)
+	err := r.DB.Create(group).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group: %w", err)
+	}
+
+	return group, nil
+}
+
+func (r *groupStorage) DeleteGroup(ctx context.Context, id string) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("group_id = ?", id).Delete(&entity.GroupMember{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete group members: %w", err)
+		}
+		err = tx.Where("group_id = ? OR subgroup_id = ?", id, id).Delete(&entity.GroupSubgroup{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete group nesting: %w", err)
+		}
+		err = tx.Where("group_id = ?", id).Delete(&entity.GroupRole{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete group roles: %w", err)
+		}
+		err = tx.Where("organization_id = ? AND id = ?", service.TenantFromContext(ctx), id).Delete(&entity.Group{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete group: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+
+	return nil
+}
+
+func (r *groupStorage) AddGroupMember(ctx context.Context, groupID, userID string) error {
+	err := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.GroupMember{
+		GroupID: groupID,
+		UserID:  userID,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to add group member: %w", err)
+	}
+
+	return nil
+}
+
+func (r *groupStorage) RemoveGroupMember(ctx context.Context, groupID, userID string) error {
+	err := r.DB.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&entity.GroupMember{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to remove group member: %w", err)
+	}
+
+	return nil
+}
+
+func (r *groupStorage) AddGroupSubgroup(ctx context.Context, groupID, subgroupID string) (bool, error) {
+	var isAdded bool
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// Concurrent nesting within the organization waits for each other, otherwise two requests could
+		// each pass the check below and together form a cycle. Transaction level lock is released on commit or rollback.
+		err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('group_subgroups' || ?))", service.TenantFromContext(ctx)).Error
+		if err != nil {
+			return fmt.Errorf("failed to lock group nesting: %w", err)
+		}
+
+		// Nesting forms a cycle if the group is the subgroup itself or is nested into it at any depth
+		var count int64
+		err = tx.Raw(`
+WITH RECURSIVE descendants(id) AS (
+	SELECT CAST(@subgroup_id AS uuid)
+	UNION
+	SELECT group_subgroups.subgroup_id
+	FROM group_subgroups
+	JOIN descendants ON descendants.id = group_subgroups.group_id
+)
+SELECT count(*) FROM descendants WHERE id = @group_id`,
+			map[string]interface{}{"group_id": groupID, "subgroup_id": subgroupID},
+		).Scan(&count).Error
+		if err != nil {
+			return fmt.Errorf("failed to find subgroup descendants: %w", err)
+		}
+		if count > 0 {
+			return nil
+		}
+
+		err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.GroupSubgroup{
+			GroupID:    groupID,
+			SubgroupID: subgroupID,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to create group subgroup: %w", err)
+		}
+		isAdded = true
+
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to add group subgroup: %w", err)
+	}
+
+	return isAdded, nil
+}
+
+func (r *groupStorage) RemoveGroupSubgroup(ctx context.Context, groupID, subgroupID string) error {
+	err := r.DB.Where("group_id = ? AND subgroup_id = ?", groupID, subgroupID).Delete(&entity.GroupSubgroup{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to remove group subgroup: %w", err)
+	}
+
+	return nil
+}
+
+func (r *groupStorage) AssignGroupRole(ctx context.Context, groupID, roleID string) error {
+	err := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.GroupRole{
+		GroupID: groupID,
+		RoleID:  roleID,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to assign group role: %w", err)
+	}
+
+	return nil
+}
+
+func (r *groupStorage) UnassignGroupRole(ctx context.Context, groupID, roleID string) error {
+	err := r.DB.Where("group_id = ? AND role_id = ?", groupID, roleID).Delete(&entity.GroupRole{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to unassign group role: %w", err)
+	}
+
+	return nil
+}
+
+func (r *groupStorage) ListUserGroups(ctx context.Context, userID string) ([]entity.Group, error) {
+	var groups []entity.Group
+	err := r.DB.
+		Where("id IN ("+effectiveGroupsQuery+")", userID, service.TenantFromContext(ctx)).
+		Order("name").
+		Find(&groups).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user groups: %w", err)
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	// external
+	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+
+	// internal
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+)
+
+var _ service.LoginThrottleStorage = (*loginThrottleStorage)(nil)
+
+type loginThrottleStorage struct {
+	*postgresql.PostgreSQLGorm
+}
+
+func NewLoginThrottleStorage(postgresql *postgresql.PostgreSQLGorm) *loginThrottleStorage {
+	return &loginThrottleStorage{postgresql}
+}
+
+func (r *loginThrottleStorage) IncrementLoginThrottles(ctx context.Context, keys []string, window time.Duration, isAllowed func(throttles []entity.LoginThrottle) bool) (bool, error) {
+	var isIncremented bool
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// Missing counters are created, so they can be locked as well
+		emptyThrottles := make([]entity.LoginThrottle, 0, len(keys))
+		for _, key := range keys {
+			emptyThrottles = append(emptyThrottles, entity.LoginThrottle{Key: key})
+		}
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&emptyThrottles).Error
+		if err != nil {
+			return fmt.Errorf("failed to create login throttles: %w", err)
+		}
+
+		// Rows are locked in the same order by all transactions, so they do not deadlock
+		var throttles []entity.LoginThrottle
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key IN ?", keys).Order("key").Find(&throttles).Error
+		if err != nil {
+			return fmt.Errorf("failed to lock login throttles: %w", err)
+		}
+		if !isAllowed(throttles) {
+			return nil
+		}
+
+		now := time.Now()
+		// Counter is started over if the last failure is out of the window
+		err = tx.Exec(`
+			UPDATE login_throttles SET
+				failed_attempts = CASE
+					WHEN last_failed_at < ? THEN 1
+					ELSE failed_attempts + 1
+				END,
+				last_failed_at = ?,
+				updated_at = ?
+			WHERE key IN ?`,
+			now.Add(-window), now, now, keys,
+		).Error
+		if err != nil {
+			return fmt.Errorf("failed to increment login throttles: %w", err)
+		}
+		isIncremented = true
+
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to increment login throttles: %w", err)
+	}
+
+	return isIncremented, nil
+}
+
+func (r *loginThrottleStorage) DecrementLoginThrottles(ctx context.Context, keys []string) error {
+	err := r.DB.Model(&entity.LoginThrottle{}).
+		Where("key IN ?", keys).
+		Update("failed_attempts", gorm.Expr("GREATEST(failed_attempts - 1, 0)")).Error
+	if err != nil {
+		return fmt.Errorf("failed to decrement login throttles: %w", err)
+	}
+
+	return nil
+}
+
+func (r *loginThrottleStorage) DeleteLoginThrottles(ctx context.Context, keys []string) error {
+	err := r.DB.Where("key IN ?", keys).Delete(&entity.LoginThrottle{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete login throttles: %w", err)
+	}
+
+	return nil
+}
+
+func (r *loginThrottleStorage) DeleteStaleLoginThrottles(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB.Where("last_failed_at < ?", before).Delete(&entity.LoginThrottle{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete stale login throttles: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	// third party
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	// external
+	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+
+	// internal
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+)
+
+var _ service.MembershipStorage = (*membershipStorage)(nil)
+
+type membershipStorage struct {
+	*postgresql.PostgreSQLGorm
+}
+
+func NewMembershipStorage(postgresql *postgresql.PostgreSQLGorm) *membershipStorage {
+	return &membershipStorage{postgresql}
+}
+
+// memberRow is the user joined with the organization membership.
+type memberRow struct {
+	entity.User
+	MembershipRoleID *string
+}
+
+func (r *membershipStorage) ListMembers(ctx context.Context, organizationID string) ([]entity.Membership, error) {
+	var rows []memberRow
+	err := r.DB.Model(&entity.User{}).
+		Select("users.*, memberships.role_id AS membership_role_id").
+		Joins("LEFT JOIN memberships ON memberships.user_id = users.id AND memberships.organization_id = ?", organizationID).
+		Where("users.tenant_id = ? OR memberships.user_id IS NOT NULL", organizationID).
+		Order("users.created_at").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+
+	members := make([]entity.Membership, 0, len(rows))
+	for i := range rows {
+		members = append(members, entity.Membership{
+			OrganizationID: organizationID,
+			UserID:         rows[i].ID,
+			RoleID:         rows[i].MembershipRoleID,
+			User:           &rows[i].User,
+		})
+	}
+
+	return members, nil
+}
+
+func (r *membershipStorage) UpsertMembership(ctx context.Context, membership *entity.Membership) (*entity.Membership, error) {
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role_id", "updated_at"}),
+	}).Create(membership).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert membership: %w", err)
+	}
+
+	return membership, nil
+}
+
+func (r *membershipStorage) DeleteMembership(ctx context.Context, organizationID, userID string) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("organization_id = ? AND user_id = ?", organizationID, userID).Delete(&entity.Membership{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete membership: %w", err)
+		}
+
+		// Former member is removed from groups, so rejoining does not restore access granted through them
+		err = tx.Where("user_id = ? AND group_id IN (SELECT id FROM groups WHERE organization_id = ?)", userID, organizationID).
+			Delete(&entity.GroupMember{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete group members: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete membership: %w", err)
+	}
+
+	return nil
+}
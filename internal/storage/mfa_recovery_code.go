@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+
+	// internal
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+)
+
+var _ service.MFARecoveryCodeStorage = (*mfaRecoveryCodeStorage)(nil)
+
+type mfaRecoveryCodeStorage struct {
+	*postgresql.PostgreSQLGorm
+}
+
+func NewMFARecoveryCodeStorage(postgresql *postgresql.PostgreSQLGorm) *mfaRecoveryCodeStorage {
+	return &mfaRecoveryCodeStorage{postgresql}
+}
+
+func (r *mfaRecoveryCodeStorage) ListMFARecoveryCodes(ctx context.Context, filter service.ListMFARecoveryCodesFilter) ([]entity.MFARecoveryCode, error) {
+	stmt := r.DB.Where(entity.MFARecoveryCode{UserID: filter.UserID})
+	if filter.OnlyUnused {
+		stmt = stmt.Where("used_at IS NULL")
+	}
+
+	var codes []entity.MFARecoveryCode
+	err := stmt.Order("created_at").Find(&codes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mfa recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+func (r *mfaRecoveryCodeStorage) ReplaceMFARecoveryCodes(ctx context.Context, userID string, codes []entity.MFARecoveryCode) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userID).Delete(&entity.MFARecoveryCode{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete mfa recovery codes: %w", err)
+		}
+
+		err = tx.Create(&codes).Error
+		if err != nil {
+			return fmt.Errorf("failed to create mfa recovery codes: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replace mfa recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *mfaRecoveryCodeStorage) UseMFARecoveryCode(ctx context.Context, id string) (bool, error) {
+	result := r.DB.Model(&entity.MFARecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to use mfa recovery code: %w", result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
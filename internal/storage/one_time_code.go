@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+
+	// internal
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+)
+
+var _ service.OneTimeCodeStorage = (*oneTimeCodeStorage)(nil)
+
+type oneTimeCodeStorage struct {
+	*postgresql.PostgreSQLGorm
+}
+
+func NewOneTimeCodeStorage(postgresql *postgresql.PostgreSQLGorm) *oneTimeCodeStorage {
+	return &oneTimeCodeStorage{postgresql}
+}
+
+func (r *oneTimeCodeStorage) GetOneTimeCode(ctx context.Context, filter service.GetOneTimeCodeFilter) (*entity.OneTimeCode, error) {
+	var code entity.OneTimeCode
+	err := r.DB.
+		Where(entity.OneTimeCode{UserID: filter.UserID, Purpose: filter.Purpose}).
+		Order("created_at DESC").
+		First(&code).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get one-time code: %w", err)
+	}
+
+	return &code, nil
+}
+
+func (r *oneTimeCodeStorage) CreateOneTimeCode(ctx context.Context, code *entity.OneTimeCode) (*entity.OneTimeCode, error) {
+	err := r.DB.Create(code).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create one-time code: %w", err)
+	}
+
+	return code, nil
+}
+
+func (r *oneTimeCodeStorage) IncrementOneTimeCodeAttempts(ctx context.Context, id string, maxAttempts int) (bool, error) {
+	result := r.DB.Model(&entity.OneTimeCode{}).
+		Where("id = ? AND used_at IS NULL AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to increment one-time code attempts: %w", result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *oneTimeCodeStorage) UseOneTimeCode(ctx context.Context, id string) (bool, error) {
+	result := r.DB.Model(&entity.OneTimeCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to use one-time code: %w", result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *oneTimeCodeStorage) InvalidateOneTimeCodes(ctx context.Context, userID, purpose string) error {
+	err := r.DB.Model(&entity.OneTimeCode{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to invalidate one-time codes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *oneTimeCodeStorage) DeleteExpiredOneTimeCodes(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB.Where("expires_at < ?", before).Delete(&entity.OneTimeCode{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired one-time codes: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	// third party
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	// external
+	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+
+	// internal
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+)
+
+var _ service.OrganizationStorage = (*organizationStorage)(nil)
+
+type organizationStorage struct {
+	*postgresql.PostgreSQLGorm
+}
+
+func NewOrganizationStorage(postgresql *postgresql.PostgreSQLGorm) *organizationStorage {
+	return &organizationStorage{postgresql}
+}
+
+func (r *organizationStorage) GetOrganization(ctx context.Context, filter service.GetOrganizationFilter) (*entity.Organization, error) {
+	stmt := r.DB
+	if filter.ID != nil {
+		stmt = stmt.Where(entity.Organization{ID: *filter.ID})
+	}
+	if filter.Slug != nil {
+		stmt = stmt.Where(entity.Organization{Slug: *filter.Slug})
+	}
+
+	var organization entity.Organization
+	err := stmt.First(&organization).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	return &organization, nil
+}
+
+func (r *organizationStorage) CreateOrganization(ctx context.Context, organization *entity.Organization) (*entity.Organization, error) {
+	err := r.DB.Create(organization).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	return organization, nil
+}
+
+func (r *organizationStorage) SeedOrganization(ctx context.Context, organization *entity.Organization) error {
+	err := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(organization).Error
+	if err != nil {
+		return fmt.Errorf("failed to seed organization: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	// external
+	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+
+	// internal
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+)
+
+var _ service.PasswordHistoryStorage = (*passwordHistoryStorage)(nil)
+
+type passwordHistoryStorage struct {
+	*postgresql.PostgreSQLGorm
+}
+
+func NewPasswordHistoryStorage(postgresql *postgresql.PostgreSQLGorm) *passwordHistoryStorage {
+	return &passwordHistoryStorage{postgresql}
+}
+
+func (r *passwordHistoryStorage) ListPasswordHistory(ctx context.Context, filter service.ListPasswordHistoryFilter) ([]entity.PasswordHistory, error) {
+	stmt := r.DB.Where(entity.PasswordHistory{UserID: filter.UserID}).Order("created_at DESC")
+	if filter.Limit > 0 {
+		stmt = stmt.Limit(filter.Limit)
+	}
+
+	var history []entity.PasswordHistory
+	err := stmt.Find(&history).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list password history: %w", err)
+	}
+
+	return history, nil
+}
+
+func (r *passwordHistoryStorage) CreatePasswordHistory(ctx context.Context, history *entity.PasswordHistory) (*entity.PasswordHistory, error) {
+	err := r.DB.Create(history).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create password history: %w", err)
+	}
+
+	return history, nil
+}
+
+func (r *passwordHistoryStorage) DeletePasswordHistory(ctx context.Context, ids []string) error {
+	err := r.DB.Where("id IN ?", ids).Delete(&entity.PasswordHistory{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete password history: %w", err)
+	}
+
+	return nil
+}
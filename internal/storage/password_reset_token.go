@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+
+	// internal
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+)
+
+var _ service.PasswordResetTokenStorage = (*passwordResetTokenStorage)(nil)
+
+type passwordResetTokenStorage struct {
+	*postgresql.PostgreSQLGorm
+}
+
+func NewPasswordResetTokenStorage(postgresql *postgresql.PostgreSQLGorm) *passwordResetTokenStorage {
+	return &passwordResetTokenStorage{postgresql}
+}
+
+func (r *passwordResetTokenStorage) GetPasswordResetToken(ctx context.Context, filter service.GetPasswordResetTokenFilter) (*entity.PasswordResetToken, error) {
+	stmt := r.DB
+	if filter.TokenHash != nil {
+		stmt = stmt.Where(entity.PasswordResetToken{TokenHash: *filter.TokenHash})
+	}
+
+	var resetToken entity.PasswordResetToken
+	err := stmt.First(&resetToken).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+
+	return &resetToken, nil
+}
+
+func (r *passwordResetTokenStorage) CreatePasswordResetToken(ctx context.Context, resetToken *entity.PasswordResetToken) (*entity.PasswordResetToken, error) {
+	err := r.DB.Create(resetToken).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	return resetToken, nil
+}
+
+func (r *passwordResetTokenStorage) UsePasswordResetToken(ctx context.Context, id string) (bool, error) {
+	result := r.DB.Model(&entity.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to use password reset token: %w", result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *passwordResetTokenStorage) InvalidatePasswordResetTokens(ctx context.Context, userID string) error {
+	err := r.DB.Model(&entity.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (r *passwordResetTokenStorage) DeleteExpiredPasswordResetTokens(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB.Where("expires_at < ?", before).Delete(&entity.PasswordResetToken{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired password reset tokens: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	// third party
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	// external
+	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+
+	// internal
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+)
+
+var _ service.RelationTupleStorage = (*relationTupleStorage)(nil)
+
+type relationTupleStorage struct {
+	*postgresql.PostgreSQLGorm
+}
+
+func NewRelationTupleStorage(postgresql *postgresql.PostgreSQLGorm) *relationTupleStorage {
+	return &relationTupleStorage{postgresql}
+}
+
+// existsAtRevision limits the query to tuples existing at the revision.
+func existsAtRevision(db *gorm.DB, revision int64) *gorm.DB {
+	return db.Where("created_revision <= ? AND (deleted_revision IS NULL OR deleted_revision > ?)", revision, revision)
+}
+
+func (r *relationTupleStorage) WriteRelationTuples(ctx context.Context, writes, deletes []entity.RelationTuple) (int64, error) {
+	organizationID := service.TenantFromContext(ctx)
+
+	var revision int64
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// Writes of the organization are serialized, so its revisions are committed in the order they grow
+		// and a snapshot read at the latest revision never misses a write committed later with a lower one.
+		// Transaction level lock is released on commit or rollback.
+		err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('relation_tuples' || ?))", organizationID).Error
+		if err != nil {
+			return fmt.Errorf("failed to lock relation tuples: %w", err)
+		}
+
+		tupleRevision := &entity.RelationTupleRevision{OrganizationID: organizationID}
+		err = tx.Create(tupleRevision).Error
+		if err != nil {
+			return fmt.Errorf("failed to create revision: %w", err)
+		}
+		revision = tupleRevision.Revision
+
+		for _, tuple := range deletes {
+			err = tx.Model(&entity.RelationTuple{}).
+				Where(
+					"organization_id = ? AND namespace = ? AND object_id = ? AND relation = ? AND "+
+						"subject_namespace = ? AND subject_id = ? AND subject_relation = ? AND deleted_revision IS NULL",
+					organizationID, tuple.Namespace, tuple.ObjectID, tuple.Relation,
+					tuple.SubjectNamespace, tuple.SubjectID, tuple.SubjectRelation,
+				).
+				Update("deleted_revision", revision).Error
+			if err != nil {
+				return fmt.Errorf("failed to delete relation tuple: %w", err)
+			}
+		}
+
+		for _, tuple := range writes {
+			tuple.OrganizationID = organizationID
+			tuple.CreatedRevision = revision
+			err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tuple).Error
+			if err != nil {
+				return fmt.Errorf("failed to create relation tuple: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to write relation tuples: %w", err)
+	}
+
+	return revision, nil
+}
+
+func (r *relationTupleStorage) GetRelationTupleRevision(ctx context.Context) (int64, error) {
+	var revision int64
+	err := r.DB.Model(&entity.RelationTupleRevision{}).
+		Where("organization_id = ?", service.TenantFromContext(ctx)).
+		Select("COALESCE(MAX(revision), 0)").
+		Scan(&revision).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get relation tuple revision: %w", err)
+	}
+
+	return revision, nil
+}
+
+func (r *relationTupleStorage) ListRelationTuples(ctx context.Context, filter service.ListRelationTuplesFilter) ([]entity.RelationTuple, error) {
+	var tuples []entity.RelationTuple
+	err := existsAtRevision(r.DB, filter.Revision).
+		Where("organization_id = ? AND namespace = ? AND object_id = ? AND relation = ?",
+			service.TenantFromContext(ctx), filter.Namespace, filter.ObjectID, filter.Relation).
+		Order("created_revision").
+		Find(&tuples).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list relation tuples: %w", err)
+	}
+
+	return tuples, nil
+}
+
+func (r *relationTupleStorage) ListRelationObjectIDs(ctx context.Context, namespace string, revision int64) ([]string, error) {
+	var ids []string
+	err := existsAtRevision(r.DB.Model(&entity.RelationTuple{}), revision).
+		Where("organization_id = ? AND namespace = ?", service.TenantFromContext(ctx), namespace).
+		Distinct("object_id").
+		Order("object_id").
+		Pluck("object_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list relation object ids: %w", err)
+	}
+
+	return ids, nil
+}
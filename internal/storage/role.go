@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	// third party
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	// external
+	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+
+	// internal
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+)
+
+var _ service.RoleStorage = (*roleStorage)(nil)
+
+type roleStorage struct {
+	*postgresql.PostgreSQLGorm
+}
+
+func NewRoleStorage(postgresql *postgresql.PostgreSQLGorm) *roleStorage {
+	return &roleStorage{postgresql}
+}
+
+func (r *roleStorage) ListPermissions(ctx context.Context) ([]entity.Permission, error) {
+	var permissions []entity.Permission
+	err := r.DB.Order("name").Find(&permissions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+func (r *roleStorage) ListRoles(ctx context.Context) ([]entity.Role, error) {
+	var roles []entity.Role
+	err := r.DB.Preload("Permissions").Order("name").Find(&roles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (r *roleStorage) GetRole(ctx context.Context, filter service.GetRoleFilter) (*entity.Role, error) {
+	stmt := r.DB.Preload("Permissions")
+	if filter.ID != nil {
+		stmt = stmt.Where(entity.Role{ID: *filter.ID})
+	}
+	if filter.Name != nil {
+		stmt = stmt.Where(entity.Role{Name: *filter.Name})
+	}
+
+	var role entity.Role
+	err := stmt.First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	return &role, nil
+}
+
+func (r *roleStorage) CreateRole(ctx context.Context, role *entity.Role) (*entity.Role, error) {
+	// Permissions are only referenced, they are never created with the role
+	err := r.DB.Omit("Permissions.*").Create(role).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	return role, nil
+}
+
+func (r *roleStorage) SeedRoles(ctx context.Context, permissions []entity.Permission, roles []entity.Role) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&permissions).Error
+		if err != nil {
+			return fmt.Errorf("failed to upsert permissions: %w", err)
+		}
+
+		for _, role := range roles {
+			err = tx.Where(entity.Role{Name: role.Name}).
+				Attrs(entity.Role{Description: role.Description}).
+				Omit("Permissions").
+				FirstOrCreate(&role).Error
+			if err != nil {
+				return fmt.Errorf("failed to get or create role %s: %w", role.Name, err)
+			}
+
+			err = tx.Model(&role).Omit("Permissions.*").Association("Permissions").Replace(role.Permissions)
+			if err != nil {
+				return fmt.Errorf("failed to replace role %s permissions: %w", role.Name, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to seed roles: %w", err)
+	}
+
+	return nil
+}
+
+func (r *roleStorage) AssignUserRole(ctx context.Context, userID, roleID string) error {
+	err := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.UserRole{
+		UserID: userID,
+		RoleID: roleID,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to assign user role: %w", err)
+	}
+
+	return nil
+}
+
+func (r *roleStorage) UnassignUserRole(ctx context.Context, userID, roleID string) error {
+	err := r.DB.Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&entity.UserRole{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to unassign user role: %w", err)
+	}
+
+	return nil
+}
+
+func (r *roleStorage) ListUserPermissions(ctx context.Context, userID string) ([]string, error) {
+	var permissions []string
+	err := r.DB.Table("role_permissions").
+		Distinct("permission_name").
+		Where("role_id IN (SELECT role_id FROM user_roles WHERE user_id = ?)", userID).
+		Or("role_id IN (SELECT role_id FROM memberships WHERE user_id = ? AND organization_id = ?)",
+			userID, service.TenantFromContext(ctx)).
+		Or("role_id IN (SELECT role_id FROM group_roles WHERE group_id IN ("+effectiveGroupsQuery+"))",
+			userID, service.TenantFromContext(ctx)).
+		Pluck("permission_name", &permissions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user permissions: %w", err)
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+
+	// internal
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+)
+
+var _ service.SessionStorage = (*sessionStorage)(nil)
+
+type sessionStorage struct {
+	*postgresql.PostgreSQLGorm
+}
+
+func NewSessionStorage(postgresql *postgresql.PostgreSQLGorm) *sessionStorage {
+	return &sessionStorage{postgresql}
+}
+
+func (r *sessionStorage) GetSession(ctx context.Context, filter service.GetSessionFilter) (*entity.Session, error) {
+	stmt := r.DB
+	if filter.ID != nil {
+		stmt = stmt.Where(entity.Session{ID: *filter.ID})
+	}
+
+	var session entity.Session
+	err := stmt.First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return &session, nil
+}
+
+func (r *sessionStorage) CreateSession(ctx context.Context, session *entity.Session) (*entity.Session, error) {
+	err := r.DB.Create(session).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *sessionStorage) UpdateSession(ctx context.Context, id string, session *entity.Session) (*entity.Session, error) {
+	err := r.DB.Model(&entity.Session{}).Where("id = ?", id).Updates(session).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *sessionStorage) RevokeSessions(ctx context.Context, filter service.RevokeSessionsFilter) error {
+	stmt := r.DB.Model(&entity.Session{}).Where("user_id = ? AND revoked_at IS NULL", filter.UserID)
+	if filter.ExceptID != nil {
+		stmt = stmt.Where("id <> ?", *filter.ExceptID)
+	}
+
+	err := stmt.Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sessionStorage) DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB.Where("expires_at < ?", before).Delete(&entity.Session{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
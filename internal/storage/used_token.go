@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	// third party
+	"gorm.io/gorm/clause"
+
+	// external
+	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+
+	// internal
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+)
+
+var _ service.UsedTokenStorage = (*usedTokenStorage)(nil)
+
+type usedTokenStorage struct {
+	*postgresql.PostgreSQLGorm
+}
+
+func NewUsedTokenStorage(postgresql *postgresql.PostgreSQLGorm) *usedTokenStorage {
+	return &usedTokenStorage{postgresql}
+}
+
+func (r *usedTokenStorage) CreateUsedToken(ctx context.Context, usedToken *entity.UsedToken) (bool, error) {
+	result := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(usedToken)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to create used token: %w", result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *usedTokenStorage) DeleteExpiredUsedTokens(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB.Where("expires_at < ?", before).Delete(&entity.UsedToken{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired used tokens: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	// third party
+	"github.com/jackc/pgconn"
 	"gorm.io/gorm"
 
 	// external
@@ -26,14 +29,50 @@ func NewUserStorage(postgresql *postgresql.PostgreSQLGorm) *userStorage {
 	return &userStorage{postgresql}
 }
 
+// tenantScope returns the query limited to users registered in or being members of the organization from context,
+// so users of other organizations can not be read. It is used only to read users by ID and to list them.
+func tenantScope(ctx context.Context, db *gorm.DB) *gorm.DB {
+	tenantID := service.TenantFromContext(ctx)
+	return db.Where(
+		"(tenant_id = ? OR id IN (SELECT user_id FROM memberships WHERE organization_id = ?))", tenantID, tenantID,
+	)
+}
+
+// homeTenantScope returns the query limited to users registered in the organization from context.
+// Emails and phones are unique only within the organization, so users are looked up by them with this scope.
+func homeTenantScope(ctx context.Context, db *gorm.DB) *gorm.DB {
+	return db.Where("tenant_id = ?", service.TenantFromContext(ctx))
+}
+
+// writeTenantScope returns the query limited to users registered in the organization from context
+// and the principal itself, so users who only joined the organization can not be changed by its managers.
+func writeTenantScope(ctx context.Context, db *gorm.DB) *gorm.DB {
+	tenantID := service.TenantFromContext(ctx)
+	principal := service.PrincipalFromContext(ctx)
+	if principal == nil {
+		return db.Where("tenant_id = ?", tenantID)
+	}
+	return db.Where("(tenant_id = ? OR id = ?)", tenantID, principal.UserID)
+}
+
 func (r *userStorage) GetUser(ctx context.Context, filter service.GetUserFilter) (*entity.User, error) {
-	stmt := r.DB
+	stmt := tenantScope(ctx, r.DB)
+	if filter.EmailAddress != nil || filter.VerifiedPhone != nil {
+		stmt = homeTenantScope(ctx, r.DB)
+	}
+	if filter.WithDeleted {
+		stmt = stmt.Unscoped()
+	}
 	if filter.EmailAddress != nil {
-		stmt = stmt.Where(entity.User{EmailAddress: *filter.EmailAddress})
+		// Emails are unique regardless of case, see CreateUserEmailIndex
+		stmt = stmt.Where("lower(email_address) = lower(?)", *filter.EmailAddress)
 	}
 	if filter.ID != nil {
 		stmt = stmt.Where(entity.User{ID: *filter.ID})
 	}
+	if filter.VerifiedPhone != nil {
+		stmt = stmt.Where("phone = ? AND phone_verified_at IS NOT NULL", *filter.VerifiedPhone)
+	}
 
 	var user entity.User
 	err := stmt.First(&user).Error
@@ -47,8 +86,152 @@ func (r *userStorage) GetUser(ctx context.Context, filter service.GetUserFilter)
 	return &user, nil
 }
 
+// userSearchExpression is the text users are searched in. It is indexed by CreateUserSearchIndex,
+// so the search has to use the same expression for the index to be used.
+const userSearchExpression = "(coalesce(name, '') || ' ' || coalesce(surname, '') || ' ' || email_address)"
+
+// CreateUserSearchIndex creates the trigram index speeding up search of users by a part of their name or email.
+func CreateUserSearchIndex(postgresql *postgresql.PostgreSQLGorm) error {
+	err := postgresql.DB.Exec(
+		"CREATE INDEX IF NOT EXISTS idx_users_search ON users USING gin (" + userSearchExpression + " gin_trgm_ops)",
+	).Error
+	if err != nil {
+		return fmt.Errorf("failed to create user search index: %w", err)
+	}
+
+	return nil
+}
+
+// userEmailIndex is the name of the index keeping email addresses unique within the organization regardless of case.
+const userEmailIndex = "idx_users_tenant_email"
+
+// CreateUserEmailIndex creates the unique index on email addresses of users of the organization.
+func CreateUserEmailIndex(postgresql *postgresql.PostgreSQLGorm) error {
+	err := postgresql.DB.Exec(
+		"CREATE UNIQUE INDEX IF NOT EXISTS " + userEmailIndex + " ON users (tenant_id, lower(email_address))",
+	).Error
+	if err != nil {
+		return fmt.Errorf("failed to create user email index: %w", err)
+	}
+
+	return nil
+}
+
+// isUserEmailTaken returns true if the error is caused by the email address already taken in the organization.
+func isUserEmailTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == userEmailIndex
+}
+
+// userPhoneIndex is the name of the index keeping verified phones unique within the organization.
+const userPhoneIndex = "idx_users_tenant_verified_phone"
+
+// CreateUserPhoneIndex creates the unique index on verified phones of users of the organization.
+func CreateUserPhoneIndex(postgresql *postgresql.PostgreSQLGorm) error {
+	err := postgresql.DB.Exec(
+		"CREATE UNIQUE INDEX IF NOT EXISTS " + userPhoneIndex + " ON users (tenant_id, phone) WHERE phone_verified_at IS NOT NULL",
+	).Error
+	if err != nil {
+		return fmt.Errorf("failed to create user phone index: %w", err)
+	}
+
+	return nil
+}
+
+// isUserPhoneTaken returns true if the error is caused by the phone already verified by another user of the organization.
+func isUserPhoneTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == userPhoneIndex
+}
+
+// userSortColumns are the columns users are sorted by for each sort field.
+var userSortColumns = map[service.UserSortField]string{
+	service.UserSortFieldCreatedAt:    "created_at",
+	service.UserSortFieldEmailAddress: "email_address",
+	service.UserSortFieldName:         "name",
+}
+
+// likePatternEscaper escapes wildcards of LIKE pattern, so the text is matched literally.
+var likePatternEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (r *userStorage) ListUsers(ctx context.Context, filter service.ListUsersFilter) ([]entity.User, error) {
+	sortColumn, ok := userSortColumns[filter.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field: %s", filter.SortBy)
+	}
+
+	stmt := tenantScope(ctx, r.DB)
+	if filter.Search != nil {
+		stmt = stmt.Where(userSearchExpression+" ILIKE ?", "%"+likePatternEscaper.Replace(*filter.Search)+"%")
+	}
+	if filter.EmailDomain != nil {
+		stmt = stmt.Where("lower(split_part(email_address, '@', 2)) = lower(?)", *filter.EmailDomain)
+	}
+	if filter.CreatedAfter != nil {
+		stmt = stmt.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		stmt = stmt.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.EmailVerified != nil {
+		if *filter.EmailVerified {
+			stmt = stmt.Where("email_verified_at IS NOT NULL")
+		} else {
+			stmt = stmt.Where("email_verified_at IS NULL")
+		}
+	}
+	if filter.Lockout != nil {
+		lockedOut := "EXISTS (SELECT 1 FROM login_throttles WHERE key = ?::text || users.tenant_id::text || ':' || lower(users.email_address) AND failed_attempts >= ? AND last_failed_at > ?)"
+		if !filter.Lockout.IsLockedOut {
+			lockedOut = "NOT " + lockedOut
+		}
+		stmt = stmt.Where(lockedOut, filter.Lockout.ThrottleKeyPrefix, filter.Lockout.MaxAttempts, filter.Lockout.FailedAfter)
+	}
+
+	// Users with equal sort values are ordered by ID, so every user has the unique position the cursor can point to
+	direction, comparison := "ASC", ">"
+	if filter.IsDescending {
+		direction, comparison = "DESC", "<"
+	}
+	if filter.After != nil {
+		var afterValue interface{}
+		switch filter.SortBy {
+		case service.UserSortFieldCreatedAt:
+			afterValue = filter.After.CreatedAt
+		case service.UserSortFieldEmailAddress:
+			afterValue = filter.After.EmailAddress
+		case service.UserSortFieldName:
+			afterValue = filter.After.Name
+		}
+		stmt = stmt.Where(fmt.Sprintf("(%s, id) %s (?, ?)", sortColumn, comparison), afterValue, filter.After.ID)
+	}
+
+	var users []entity.User
+	err := stmt.
+		Order(fmt.Sprintf("%s %s, id %s", sortColumn, direction, direction)).
+		Limit(filter.Limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return users, nil
+}
+
 func (r *userStorage) CreateUser(ctx context.Context, user *entity.User) (*entity.User, error) {
-	err := r.DB.Create(user).Error
+	user.TenantID = service.TenantFromContext(ctx)
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// Email change to the same address waits for the registration, so its check sees the new user
+		err := lockUserEmail(tx, user.TenantID, user.EmailAddress)
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(user).Error
+	})
+	if isUserEmailTaken(err) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -57,7 +240,7 @@ func (r *userStorage) CreateUser(ctx context.Context, user *entity.User) (*entit
 }
 
 func (r *userStorage) UpdateUser(ctx context.Context, id string, user *entity.User) (*entity.User, error) {
-	err := r.DB.Model(&entity.User{}).Where("id = ?", id).Updates(user).Error
+	err := writeTenantScope(ctx, r.DB.Model(&entity.User{})).Where("id = ?", id).Updates(user).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
@@ -65,11 +248,181 @@ func (r *userStorage) UpdateUser(ctx context.Context, id string, user *entity.Us
 	return user, nil
 }
 
-func (r *userStorage) DeleteUser(ctx context.Context, id string) error {
-	err := r.DB.Delete(&entity.User{}, id).Error
+func (r *userStorage) DeleteUser(ctx context.Context, id, deletedByID string) error {
+	err := writeTenantScope(ctx, r.DB.Model(&entity.User{})).Where("id = ?", id).Updates(map[string]interface{}{
+		"deleted_at": time.Now(),
+		"deleted_by": deletedByID,
+	}).Error
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
 	return nil
 }
+
+func (r *userStorage) RestoreUser(ctx context.Context, id string) error {
+	err := writeTenantScope(ctx, r.DB.Unscoped().Model(&entity.User{})).Where("id = ?", id).Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"deleted_by": nil,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to restore user: %w", err)
+	}
+
+	return nil
+}
+
+// lockUserEmail locks the email address of the organization until the end of the transaction.
+func lockUserEmail(tx *gorm.DB, tenantID, emailAddress string) error {
+	err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(? || lower(?)))", tenantID, emailAddress).Error
+	if err != nil {
+		return fmt.Errorf("failed to lock email address: %w", err)
+	}
+
+	return nil
+}
+
+// userDataModels are the models of data belonging to users, purged together with them.
+var userDataModels = []interface{}{
+	&entity.PasswordHistory{},
+	&entity.Session{},
+	&entity.PasswordResetToken{},
+	&entity.MFARecoveryCode{},
+	&entity.WebAuthnCredential{},
+	&entity.OneTimeCode{},
+	&entity.EmailChangeRequest{},
+	&entity.UserRole{},
+	&entity.Membership{},
+	&entity.GroupMember{},
+}
+
+func (r *userStorage) PurgeUsers(ctx context.Context, filter service.PurgeUsersFilter) (int64, error) {
+	var count int64
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// Purge is not limited to the organization from context, since it runs outside of requests
+		purged := func(column string, args ...interface{}) *gorm.DB {
+			return tx.Unscoped().Model(&entity.User{}).Select(column, args...).Where("deleted_at < ?", filter.DeletedBefore)
+		}
+		for _, model := range userDataModels {
+			err := tx.Where("user_id IN (?)", purged("id")).Delete(model).Error
+			if err != nil {
+				return fmt.Errorf("failed to delete user data: %w", err)
+			}
+		}
+
+		// Tokens issued before the user is known have no owner, they expire on their own
+		err := tx.Where("user_id IN (?)", purged("id::text")).Delete(&entity.UsedToken{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete used tokens: %w", err)
+		}
+
+		// Relations of the user are removed at all revisions, so no trace of the user is left
+		err = tx.Where("(subject_namespace = ? AND subject_id IN (?)) OR (namespace = ? AND object_id IN (?))",
+			filter.RelationNamespace, purged("id::text"), filter.RelationNamespace, purged("id::text"),
+		).Delete(&entity.RelationTuple{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete relation tuples: %w", err)
+		}
+
+		throttleKeys := purged("?::text || tenant_id::text || ':' || lower(email_address)", filter.ThrottleKeyPrefix)
+		err = tx.Where("key IN (?)", throttleKeys).Delete(&entity.LoginThrottle{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete login throttles: %w", err)
+		}
+
+		result := tx.Unscoped().Where("deleted_at < ?", filter.DeletedBefore).Delete(&entity.User{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete users: %w", result.Error)
+		}
+		count = result.RowsAffected
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge users: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *userStorage) ClearUserPhoneVerification(ctx context.Context, id string) error {
+	err := writeTenantScope(ctx, r.DB.Model(&entity.User{})).Where("id = ?", id).Update("phone_verified_at", nil).Error
+	if err != nil {
+		return fmt.Errorf("failed to clear user phone verification: %w", err)
+	}
+
+	return nil
+}
+
+func (r *userStorage) SetUserVerifiedPhone(ctx context.Context, id, phone string) (bool, error) {
+	err := writeTenantScope(ctx, r.DB.Model(&entity.User{})).Where("id = ?", id).Updates(map[string]interface{}{
+		"phone":             phone,
+		"phone_verified_at": time.Now(),
+	}).Error
+	if isUserPhoneTaken(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to set user verified phone: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *userStorage) UseUserTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	// Deleted user can still complete login to restore the account
+	result := writeTenantScope(ctx, r.DB.Unscoped().Model(&entity.User{})).
+		Where("id = ? AND totp_last_used_step < ?", id, step).
+		Update("totp_last_used_step", step)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to use user totp step: %w", result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *userStorage) ChangeUserEmail(ctx context.Context, id, oldEmailAddress, newEmailAddress string) (bool, error) {
+	var isChanged bool
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// Concurrent changes and registrations with the same address wait for each other, so the check below can not
+		// be raced
+		tenantID := service.TenantFromContext(ctx)
+		err := lockUserEmail(tx, tenantID, newEmailAddress)
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		err = tx.Model(&entity.User{}).
+			Where("tenant_id = ? AND lower(email_address) = lower(?) AND id <> ?", tenantID, newEmailAddress, id).
+			Count(&count).Error
+		if err != nil {
+			return fmt.Errorf("failed to count users with email address: %w", err)
+		}
+		if count > 0 {
+			return nil
+		}
+
+		// Email is swapped only if it has not been changed since the check in service
+		result := tx.Model(&entity.User{}).
+			Where("tenant_id = ? AND id = ? AND email_address = ?", tenantID, id, oldEmailAddress).
+			Updates(map[string]interface{}{
+				"email_address":     newEmailAddress,
+				"email_verified_at": time.Now(),
+			})
+		if isUserEmailTaken(result.Error) {
+			return nil
+		}
+		if result.Error != nil {
+			return fmt.Errorf("failed to update user email address: %w", result.Error)
+		}
+		isChanged = result.RowsAffected == 1
+
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to change user email: %w", err)
+	}
+
+	return isChanged, nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/taraslis453/solid-software-test/pkg/postgresql"
+
+	// internal
+	"github.com/taraslis453/solid-software-test/internal/entity"
+	"github.com/taraslis453/solid-software-test/internal/service"
+)
+
+var _ service.WebAuthnCredentialStorage = (*webAuthnCredentialStorage)(nil)
+
+type webAuthnCredentialStorage struct {
+	*postgresql.PostgreSQLGorm
+}
+
+func NewWebAuthnCredentialStorage(postgresql *postgresql.PostgreSQLGorm) *webAuthnCredentialStorage {
+	return &webAuthnCredentialStorage{postgresql}
+}
+
+func (r *webAuthnCredentialStorage) ListWebAuthnCredentials(ctx context.Context, filter service.ListWebAuthnCredentialsFilter) ([]entity.WebAuthnCredential, error) {
+	var credentials []entity.WebAuthnCredential
+	err := r.DB.Where(entity.WebAuthnCredential{UserID: filter.UserID}).Order("created_at").Find(&credentials).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webauthn credentials: %w", err)
+	}
+
+	return credentials, nil
+}
+
+func (r *webAuthnCredentialStorage) GetWebAuthnCredential(ctx context.Context, filter service.GetWebAuthnCredentialFilter) (*entity.WebAuthnCredential, error) {
+	stmt := r.DB
+	if filter.CredentialID != nil {
+		stmt = stmt.Where("credential_id = ?", filter.CredentialID)
+	}
+
+	var credential entity.WebAuthnCredential
+	err := stmt.First(&credential).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webauthn credential: %w", err)
+	}
+
+	return &credential, nil
+}
+
+func (r *webAuthnCredentialStorage) CreateWebAuthnCredential(ctx context.Context, credential *entity.WebAuthnCredential) (*entity.WebAuthnCredential, error) {
+	err := r.DB.Create(credential).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webauthn credential: %w", err)
+	}
+
+	return credential, nil
+}
+
+func (r *webAuthnCredentialStorage) UpdateWebAuthnCredential(ctx context.Context, id string, credential *entity.WebAuthnCredential) (*entity.WebAuthnCredential, error) {
+	err := r.DB.Model(&entity.WebAuthnCredential{}).Where("id = ?", id).Updates(credential).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webauthn credential: %w", err)
+	}
+
+	return credential, nil
+}
@@ -0,0 +1,68 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+type aesGCM struct {
+	aead cipher.AEAD
+}
+
+// Check if implements the interface.
+var _ Encryptor = (*aesGCM)(nil)
+
+// NewAESGCM creates AES-256-GCM encryptor. The 256-bit key is derived from the passed key with SHA-256.
+func NewAESGCM(key string) (*aesGCM, error) {
+	if key == "" {
+		return nil, errors.New("encryption key is empty")
+	}
+
+	derivedKey := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(derivedKey[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcm: %w", err)
+	}
+
+	return &aesGCM{aead: aead}, nil
+}
+
+func (e *aesGCM) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", fmt.Errorf("failed to read random nonce: %w", err)
+	}
+
+	// nonce is stored as a prefix of the ciphertext
+	ciphertext := e.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func (e *aesGCM) Decrypt(encoded string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+	if len(ciphertext) < e.aead.NonceSize() {
+		return "", errors.New("ciphertext is too short")
+	}
+
+	nonce, ciphertext := ciphertext[:e.aead.NonceSize()], ciphertext[e.aead.NonceSize():]
+	plaintext, err := e.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt: %w", err)
+	}
+
+	return string(plaintext), nil
+}
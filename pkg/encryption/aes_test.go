@@ -0,0 +1,25 @@
+package encryption
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_aesGCM_EncryptDecrypt(t *testing.T) {
+	encryptor, err := NewAESGCM("secret key")
+	require.NoError(t, err, "failed to create encryptor")
+
+	ciphertext, err := encryptor.Encrypt("JBSWY3DPEHPK3PXP")
+	require.NoError(t, err, "failed to encrypt")
+	require.NotEqual(t, "JBSWY3DPEHPK3PXP", ciphertext, "ciphertext equals plaintext")
+
+	plaintext, err := encryptor.Decrypt(ciphertext)
+	require.NoError(t, err, "failed to decrypt")
+	require.Equal(t, "JBSWY3DPEHPK3PXP", plaintext)
+
+	otherEncryptor, err := NewAESGCM("other key")
+	require.NoError(t, err, "failed to create encryptor")
+	_, err = otherEncryptor.Decrypt(ciphertext)
+	require.Error(t, err, "decrypted with wrong key")
+}
@@ -0,0 +1,9 @@
+package encryption
+
+// Encryptor provides logic for symmetric encryption of secrets stored at rest.
+type Encryptor interface {
+	// Encrypt is used to encrypt plaintext and return encoded ciphertext.
+	Encrypt(plaintext string) (string, error)
+	// Decrypt is used to decrypt ciphertext returned by Encrypt.
+	Decrypt(ciphertext string) (string, error)
+}
@@ -2,8 +2,9 @@ package errs
 
 // Err implements the Error interface with error marshaling.
 type Err struct {
-	Message string `json:"message"`
-	Code    string `json:"code"`
+	Message string      `json:"message"`
+	Code    string      `json:"code"`
+	Details interface{} `json:"details,omitempty"`
 }
 
 func New(message, code string) error {
@@ -13,6 +14,20 @@ func New(message, code string) error {
 	}
 }
 
+// WithDetails returns a copy of given error with attached details.
+// If error is not custom it is returned as is.
+func WithDetails(err error, details interface{}) error {
+	v, ok := err.(*Err)
+	if !ok {
+		return err
+	}
+	return &Err{
+		Message: v.Message,
+		Code:    v.Code,
+		Details: details,
+	}
+}
+
 func (e *Err) Error() string {
 	return e.Message
 }
@@ -31,3 +46,12 @@ func GetCode(err error) string {
 	}
 	return v.Code
 }
+
+// GetDetails returns details of given error or nil if error is not custom
+func GetDetails(err error) interface{} {
+	v, ok := err.(*Err)
+	if !ok {
+		return nil
+	}
+	return v.Details
+}
@@ -0,0 +1,89 @@
+package lockout
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Policy describes how failed login attempts are throttled.
+type Policy struct {
+	// Duration is the time attempts are locked for after the attempts limit is reached.
+	Duration time.Duration
+	// BaseDelay is the delay after the first failed attempt, it doubles on every next one up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// AttemptsWindow is the time failed attempts are counted within, older counters are started over.
+	AttemptsWindow time.Duration
+}
+
+// Counter is the number of failed attempts counted by a key, e.g. user or IP address.
+type Counter struct {
+	Key            string
+	FailedAttempts int
+	LastFailedAt   time.Time
+}
+
+// IsStale returns true if the last failed attempt of the counter is out of the attempts window,
+// so the counter is started over on the next failed attempt.
+func (p Policy) IsStale(counter Counter, now time.Time) bool {
+	return now.Sub(counter.LastFailedAt) > p.AttemptsWindow
+}
+
+// Wait returns the time to wait before the next attempt is allowed for the counters with attempts limits of their keys.
+// isLocked is true if attempts limit of any counter is reached, otherwise only the progressive delay is applied.
+func (p Policy) Wait(counters []Counter, maxAttempts map[string]int, now time.Time) (time.Duration, bool) {
+	var retryAfter time.Duration
+	var isLocked bool
+	for _, counter := range counters {
+		if p.IsStale(counter, now) {
+			continue
+		}
+
+		var wait time.Duration
+		isLimitReached := counter.FailedAttempts >= maxAttempts[counter.Key]
+		if isLimitReached {
+			wait = p.Duration
+		} else {
+			wait = p.Delay(counter.FailedAttempts)
+		}
+
+		wait = counter.LastFailedAt.Add(wait).Sub(now)
+		if wait <= 0 {
+			continue
+		}
+		isLocked = isLocked || isLimitReached
+		if wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	return retryAfter, isLocked
+}
+
+// Delay returns the delay required after the number of failed attempts. It doubles on every failed attempt.
+func (p Policy) Delay(failedAttempts int) time.Duration {
+	if failedAttempts <= 0 {
+		return 0
+	}
+
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(failedAttempts-1))
+	if delay > float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+// SplitRelease splits keys of the successful attempt into counters to reset, having the prefix, and counters to
+// decrement. Decremented counters keep earlier failures, so e.g. logging into own account from an IP does not unlock
+// guessing of other accounts from it.
+func SplitRelease(keys []string, resetPrefix string) (resetKeys, decrementKeys []string) {
+	for _, key := range keys {
+		if strings.HasPrefix(key, resetPrefix) {
+			resetKeys = append(resetKeys, key)
+		} else {
+			decrementKeys = append(decrementKeys, key)
+		}
+	}
+	return resetKeys, decrementKeys
+}
@@ -0,0 +1,144 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = Policy{
+	Duration:       15 * time.Minute,
+	BaseDelay:      time.Second,
+	MaxDelay:       30 * time.Second,
+	AttemptsWindow: time.Hour,
+}
+
+func TestPolicy_Delay(t *testing.T) {
+	testCases := []struct {
+		name           string
+		failedAttempts int
+		expectedDelay  time.Duration
+	}{
+		{name: "positive:no failed attempts", failedAttempts: 0, expectedDelay: 0},
+		{name: "positive:first failed attempt", failedAttempts: 1, expectedDelay: time.Second},
+		{name: "positive:doubles on every failed attempt", failedAttempts: 4, expectedDelay: 8 * time.Second},
+		{name: "positive:capped at max delay", failedAttempts: 10, expectedDelay: 30 * time.Second},
+		{name: "negative:negative failed attempts", failedAttempts: -1, expectedDelay: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedDelay, testPolicy.Delay(tc.failedAttempts))
+		})
+	}
+}
+
+func TestPolicy_Wait(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	maxAttempts := map[string]int{"user:a": 5, "ip:1": 20}
+
+	testCases := []struct {
+		name               string
+		counters           []Counter
+		expectedRetryAfter time.Duration
+		expectedIsLocked   bool
+	}{
+		{
+			name:               "positive:no counters",
+			counters:           nil,
+			expectedRetryAfter: 0,
+			expectedIsLocked:   false,
+		},
+		{
+			name: "positive:delay after failed attempts",
+			counters: []Counter{
+				{Key: "user:a", FailedAttempts: 3, LastFailedAt: now.Add(-time.Second)},
+			},
+			expectedRetryAfter: 3 * time.Second,
+			expectedIsLocked:   false,
+		},
+		{
+			name: "positive:delay is over",
+			counters: []Counter{
+				{Key: "user:a", FailedAttempts: 3, LastFailedAt: now.Add(-time.Minute)},
+			},
+			expectedRetryAfter: 0,
+			expectedIsLocked:   false,
+		},
+		{
+			name: "positive:locked after attempts limit",
+			counters: []Counter{
+				{Key: "user:a", FailedAttempts: 5, LastFailedAt: now.Add(-5 * time.Minute)},
+			},
+			expectedRetryAfter: 10 * time.Minute,
+			expectedIsLocked:   true,
+		},
+		{
+			name: "positive:lock is over",
+			counters: []Counter{
+				{Key: "user:a", FailedAttempts: 5, LastFailedAt: now.Add(-20 * time.Minute)},
+			},
+			expectedRetryAfter: 0,
+			expectedIsLocked:   false,
+		},
+		{
+			name: "positive:longest wait of counters",
+			counters: []Counter{
+				{Key: "user:a", FailedAttempts: 2, LastFailedAt: now},
+				{Key: "ip:1", FailedAttempts: 20, LastFailedAt: now.Add(-time.Minute)},
+			},
+			expectedRetryAfter: 14 * time.Minute,
+			expectedIsLocked:   true,
+		},
+		{
+			name: "positive:lock of one counter with delay of another",
+			counters: []Counter{
+				{Key: "user:a", FailedAttempts: 5, LastFailedAt: now},
+				{Key: "ip:1", FailedAttempts: 6, LastFailedAt: now},
+			},
+			expectedRetryAfter: 15 * time.Minute,
+			expectedIsLocked:   true,
+		},
+		{
+			name: "negative:stale counter is started over",
+			counters: []Counter{
+				{Key: "user:a", FailedAttempts: 5, LastFailedAt: now.Add(-2 * time.Hour)},
+			},
+			expectedRetryAfter: 0,
+			expectedIsLocked:   false,
+		},
+		{
+			name: "negative:lock longer than window is not applied to stale counter",
+			counters: []Counter{
+				{Key: "user:a", FailedAttempts: 5, LastFailedAt: now.Add(-time.Hour - time.Second)},
+				{Key: "ip:1", FailedAttempts: 1, LastFailedAt: now.Add(-time.Hour - time.Second)},
+			},
+			expectedRetryAfter: 0,
+			expectedIsLocked:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			retryAfter, isLocked := testPolicy.Wait(tc.counters, maxAttempts, now)
+			require.Equal(t, tc.expectedRetryAfter, retryAfter, "unexpected retry after")
+			require.Equal(t, tc.expectedIsLocked, isLocked, "unexpected lock")
+		})
+	}
+}
+
+func TestPolicy_IsStale(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	require.False(t, testPolicy.IsStale(Counter{LastFailedAt: now.Add(-time.Minute)}, now), "recent counter must not be stale")
+	require.False(t, testPolicy.IsStale(Counter{LastFailedAt: now.Add(-time.Hour)}, now), "counter at window end must not be stale")
+	require.True(t, testPolicy.IsStale(Counter{LastFailedAt: now.Add(-time.Hour - time.Second)}, now), "counter out of window must be stale")
+}
+
+func TestSplitRelease(t *testing.T) {
+	resetKeys, decrementKeys := SplitRelease([]string{"user:t:a@b.c", "ip:1", "mfa:token"}, "user:")
+
+	require.Equal(t, []string{"user:t:a@b.c"}, resetKeys, "user counter must be reset")
+	require.Equal(t, []string{"ip:1", "mfa:token"}, decrementKeys, "other counters must be decremented")
+}
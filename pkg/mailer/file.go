@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fileMailer writes every email to a separate file in the directory instead of sending it.
+// Used for local development and tests to read sent links.
+type fileMailer struct {
+	dir string
+}
+
+// Check if implements the interface.
+var _ Mailer = (*fileMailer)(nil)
+
+func NewFileMailer(dir string) (*fileMailer, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	return &fileMailer{dir: dir}, nil
+}
+
+func (m *fileMailer) Send(ctx context.Context, message *Message) error {
+	now := time.Now()
+	// file names are sorted by sending time
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), uuid.NewString())
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&content, "To: %s\r\n", message.To)
+	fmt.Fprintf(&content, "Subject: %s\r\n", message.Subject)
+	content.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	content.WriteString(message.Body)
+
+	err := os.WriteFile(filepath.Join(m.dir, name), []byte(content.String()), 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write email file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer, err := NewFileMailer(dir)
+	require.NoError(t, err, "failed to create mailer")
+
+	err = mailer.Send(context.Background(), &Message{To: "user@example.com", Subject: "Hello", Body: "link"})
+	require.NoError(t, err, "failed to send email")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err, "failed to read mail directory")
+	require.Len(t, entries, 1, "unexpected number of emails")
+
+	content, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err, "failed to read email")
+	require.True(t, strings.Contains(string(content), "To: user@example.com\r\n"), "recipient is missing")
+	require.True(t, strings.Contains(string(content), "Subject: Hello\r\n"), "subject is missing")
+	require.True(t, strings.HasSuffix(string(content), "\r\n\r\nlink"), "body is missing")
+}
@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/taraslis453/solid-software-test/pkg/logging"
+)
+
+// tokenPattern matches tokens in email bodies, passed either as link query parameter or as plain text after a colon.
+var tokenPattern = regexp.MustCompile(`(?i)(token=|token: )[^\s&]+`)
+
+// redactTokens returns the email body with tokens replaced, so logs do not grant access to accounts.
+func redactTokens(body string) string {
+	return tokenPattern.ReplaceAllString(body, "${1}[REDACTED]")
+}
+
+// logMailer writes emails to the log instead of sending them. Tokens are redacted from the logged body.
+// Used for local development.
+type logMailer struct {
+	logger logging.Logger
+}
+
+// Check if implements the interface.
+var _ Mailer = (*logMailer)(nil)
+
+func NewLogMailer(l logging.Logger) *logMailer {
+	return &logMailer{
+		logger: l.Named("logMailer"),
+	}
+}
+
+func (m *logMailer) Send(ctx context.Context, message *Message) error {
+	m.logger.
+		WithContext(ctx).
+		Info("email sent", "to", message.To, "subject", message.Subject, "body", redactTokens(message.Body))
+	return nil
+}
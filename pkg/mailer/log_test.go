@@ -0,0 +1,12 @@
+package mailer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactTokens(t *testing.T) {
+	body := redactTokens("Follow the link: http://localhost/password/reset?token=abc-_123&lang=en\nOr submit the token: eyJ.abc.def\nThe token expires in 30m.")
+	require.Equal(t, "Follow the link: http://localhost/password/reset?token=[REDACTED]&lang=en\nOr submit the token: [REDACTED]\nThe token expires in 30m.", body)
+}
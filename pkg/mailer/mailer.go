@@ -0,0 +1,15 @@
+package mailer
+
+import "context"
+
+// Mailer provides logic for sending emails.
+type Mailer interface {
+	// Send is used to send an email message.
+	Send(ctx context.Context, message *Message) error
+}
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
@@ -0,0 +1,188 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// bloomFilterMagic identifies the breach Bloom filter file format.
+var bloomFilterMagic = [8]byte{'H', 'I', 'B', 'P', 'B', 'L', 'M', '1'}
+
+// bloomBreachChecker looks up password hashes in the prebuilt Bloom filter loaded into memory.
+// Bloom filter has no false negatives, but may report a not breached password as breached
+// with the false positive rate chosen on build.
+type bloomBreachChecker struct {
+	filter *bloomFilter
+}
+
+// Check if implements the interface.
+var _ BreachChecker = (*bloomBreachChecker)(nil)
+
+// LoadBloomBreachChecker loads the Bloom filter file built by BuildBloomFilter.
+func LoadBloomBreachChecker(path string) (*bloomBreachChecker, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bloom filter file: %w", err)
+	}
+	defer file.Close()
+
+	filter, err := readBloomFilter(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bloom filter: %w", err)
+	}
+
+	return &bloomBreachChecker{filter: filter}, nil
+}
+
+func (c *bloomBreachChecker) IsBreached(password string) (bool, error) {
+	digest := sha1.Sum([]byte(password))
+	return c.filter.contains(digest[:]), nil
+}
+
+type BuildBloomFilterOptions struct {
+	// Source is a directory with HIBP range files or a single file with "HASH:COUNT" lines.
+	Source string
+	// Output is a path of the Bloom filter file to create.
+	Output string
+	// FalsePositiveRate is a desired probability of reporting not breached password as breached.
+	FalsePositiveRate float64
+}
+
+// BuildBloomFilter builds the Bloom filter file from the HIBP SHA-1 corpus and returns the number of added hashes.
+func BuildBloomFilter(opts *BuildBloomFilterOptions) (uint64, error) {
+	if opts.FalsePositiveRate <= 0 || opts.FalsePositiveRate >= 1 {
+		return 0, fmt.Errorf("false positive rate must be between 0 and 1")
+	}
+
+	// count hashes first to size the filter
+	var count uint64
+	err := forEachCorpusHash(opts.Source, func(digest []byte) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count corpus hashes: %w", err)
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("corpus is empty")
+	}
+
+	filter := newBloomFilter(count, opts.FalsePositiveRate)
+	err = forEachCorpusHash(opts.Source, func(digest []byte) error {
+		filter.add(digest)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to add corpus hashes: %w", err)
+	}
+
+	file, err := os.Create(opts.Output)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create bloom filter file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	err = filter.write(writer)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write bloom filter: %w", err)
+	}
+	err = writer.Flush()
+	if err != nil {
+		return 0, fmt.Errorf("failed to flush bloom filter: %w", err)
+	}
+
+	return count, nil
+}
+
+// bloomFilter is a Bloom filter over SHA-1 digests. Since digests are already uniformly distributed,
+// bit positions are derived from the digest itself with double hashing.
+type bloomFilter struct {
+	bits      []byte
+	bitsCount uint64
+	hashCount uint32
+}
+
+func newBloomFilter(n uint64, falsePositiveRate float64) *bloomFilter {
+	bitsCount := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	hashCount := uint32(math.Max(1, math.Round(float64(bitsCount)/float64(n)*math.Ln2)))
+
+	return &bloomFilter{
+		bits:      make([]byte, (bitsCount+7)/8),
+		bitsCount: bitsCount,
+		hashCount: hashCount,
+	}
+}
+
+func (f *bloomFilter) add(digest []byte) {
+	h1, h2 := splitDigest(digest)
+	for i := uint64(0); i < uint64(f.hashCount); i++ {
+		position := (h1 + i*h2) % f.bitsCount
+		f.bits[position/8] |= 1 << (position % 8)
+	}
+}
+
+func (f *bloomFilter) contains(digest []byte) bool {
+	h1, h2 := splitDigest(digest)
+	for i := uint64(0); i < uint64(f.hashCount); i++ {
+		position := (h1 + i*h2) % f.bitsCount
+		if f.bits[position/8]&(1<<(position%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// splitDigest returns two independent hashes from the digest. Second hash is always odd
+// so the positions do not collapse into one.
+func splitDigest(digest []byte) (uint64, uint64) {
+	return binary.LittleEndian.Uint64(digest[0:8]), binary.LittleEndian.Uint64(digest[8:16]) | 1
+}
+
+// write writes the filter in the format: magic, bits count (uint64), hash count (uint32), bits.
+func (f *bloomFilter) write(w io.Writer) error {
+	header := make([]byte, 0, len(bloomFilterMagic)+12)
+	header = append(header, bloomFilterMagic[:]...)
+	header = binary.LittleEndian.AppendUint64(header, f.bitsCount)
+	header = binary.LittleEndian.AppendUint32(header, f.hashCount)
+
+	_, err := w.Write(header)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(f.bits)
+	return err
+}
+
+func readBloomFilter(r io.Reader) (*bloomFilter, error) {
+	header := make([]byte, len(bloomFilterMagic)+12)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if string(header[:len(bloomFilterMagic)]) != string(bloomFilterMagic[:]) {
+		return nil, errors.New("invalid bloom filter file")
+	}
+
+	filter := &bloomFilter{
+		bitsCount: binary.LittleEndian.Uint64(header[8:16]),
+		hashCount: binary.LittleEndian.Uint32(header[16:20]),
+	}
+	if filter.bitsCount == 0 || filter.hashCount == 0 {
+		return nil, errors.New("invalid bloom filter parameters")
+	}
+
+	filter.bits = make([]byte, (filter.bitsCount+7)/8)
+	_, err = io.ReadFull(r, filter.bits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bits: %w", err)
+	}
+
+	return filter, nil
+}
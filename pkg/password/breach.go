@@ -0,0 +1,170 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// hashPrefixLength is the length of the SHA-1 hash prefix used in the HIBP range files names.
+	hashPrefixLength = 5
+	// hashHexLength is the length of hex encoded SHA-1 hash.
+	hashHexLength = sha1.Size * 2
+)
+
+// BreachChecker provides logic for checking passwords against the known data breaches.
+type BreachChecker interface {
+	// IsBreached is used to check if password appears in the breach corpus.
+	IsBreached(password string) (bool, error)
+}
+
+// rangeFilesBreachChecker looks up password hashes in the Have I Been Pwned range files stored on local disk.
+// Every file is named by the first 5 characters of SHA-1 hash and contains lines in "SUFFIX:COUNT" format.
+type rangeFilesBreachChecker struct {
+	dir string
+}
+
+// Check if implements the interface.
+var _ BreachChecker = (*rangeFilesBreachChecker)(nil)
+
+func NewRangeFilesBreachChecker(dir string) (*rangeFilesBreachChecker, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat range files directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("range files path %q is not a directory", dir)
+	}
+
+	return &rangeFilesBreachChecker{dir: dir}, nil
+}
+
+func (c *rangeFilesBreachChecker) IsBreached(password string) (bool, error) {
+	hash := hashPasswordSHA1(password)
+	prefix, suffix := hash[:hashPrefixLength], hash[hashPrefixLength:]
+
+	file, err := openRangeFile(c.dir, prefix)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open range file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineSuffix, breached := parseCorpusLine(scanner.Text())
+		if breached && strings.EqualFold(lineSuffix, suffix) {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read range file: %w", err)
+	}
+
+	return false, nil
+}
+
+// openRangeFile opens range file with or without ".txt" extension.
+func openRangeFile(dir, prefix string) (*os.File, error) {
+	file, err := os.Open(filepath.Join(dir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return os.Open(filepath.Join(dir, prefix))
+	}
+	return file, err
+}
+
+// hashPasswordSHA1 returns upper case hex encoded SHA-1 hash of the password as it is used by HIBP.
+func hashPasswordSHA1(password string) string {
+	digest := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(digest[:]))
+}
+
+// parseCorpusLine parses "HASH:COUNT" line and returns the hash.
+// Lines with zero count are padding entries and are reported as not breached.
+func parseCorpusLine(line string) (string, bool) {
+	hash, count, _ := strings.Cut(strings.TrimSpace(line), ":")
+	if hash == "" || count == "0" {
+		return "", false
+	}
+	return hash, true
+}
+
+// forEachCorpusHash calls fn for every breached SHA-1 hash from the corpus. Source can be either
+// a directory with HIBP range files or a single file with lines in "HASH:COUNT" format.
+func forEachCorpusHash(source string, fn func(digest []byte) error) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return fmt.Errorf("failed to stat corpus source: %w", err)
+	}
+	if !info.IsDir() {
+		return forEachFileHash(source, "", fn)
+	}
+
+	entries, err := os.ReadDir(source)
+	if err != nil {
+		return fmt.Errorf("failed to read corpus directory: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	for _, entry := range entries {
+		prefix := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if entry.IsDir() || len(prefix) != hashPrefixLength {
+			continue
+		}
+		if _, err := hex.DecodeString(prefix + "0"); err != nil {
+			continue
+		}
+
+		err := forEachFileHash(filepath.Join(source, entry.Name()), prefix, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// forEachFileHash calls fn for every hash in the file, prepending prefix to every line.
+func forEachFileHash(path, prefix string, fn func(digest []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open corpus file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		hash, breached := parseCorpusLine(scanner.Text())
+		if !breached {
+			continue
+		}
+
+		hash = prefix + hash
+		if len(hash) != hashHexLength {
+			return fmt.Errorf("invalid hash length in %s:%d", path, lineNumber)
+		}
+		digest, err := hex.DecodeString(hash)
+		if err != nil {
+			return fmt.Errorf("invalid hash in %s:%d: %w", path, lineNumber, err)
+		}
+
+		err = fn(digest)
+		if err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read corpus file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeRangeFiles creates HIBP range files for passed passwords and returns the directory.
+func writeRangeFiles(t *testing.T, passwords ...string) string {
+	dir := t.TempDir()
+	for _, password := range passwords {
+		hash := hashPasswordSHA1(password)
+		path := filepath.Join(dir, hash[:hashPrefixLength]+".txt")
+
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		require.NoError(t, err, "failed to open range file")
+		_, err = file.WriteString(hash[hashPrefixLength:] + ":42\r\n")
+		require.NoError(t, err, "failed to write range file")
+		require.NoError(t, file.Close(), "failed to close range file")
+	}
+	return dir
+}
+
+func Test_rangeFilesBreachChecker_IsBreached(t *testing.T) {
+	dir := writeRangeFiles(t, "password", "123456")
+	checker, err := NewRangeFilesBreachChecker(dir)
+	require.NoError(t, err, "failed to create checker")
+
+	testCases := []struct {
+		name             string
+		password         string
+		expectedBreached bool
+	}{
+		{name: "positive:breached", password: "password", expectedBreached: true},
+		{name: "positive:not breached", password: "Blue-Canyon-42-Ridge", expectedBreached: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			breached, err := checker.IsBreached(tc.password)
+			require.NoError(t, err, "failed to check password")
+			require.Equal(t, tc.expectedBreached, breached)
+		})
+	}
+}
+
+func Test_bloomBreachChecker_IsBreached(t *testing.T) {
+	dir := writeRangeFiles(t, "password", "123456", "qwerty")
+	output := filepath.Join(t.TempDir(), "breached.bloom")
+
+	count, err := BuildBloomFilter(&BuildBloomFilterOptions{
+		Source:            dir,
+		Output:            output,
+		FalsePositiveRate: 0.0001,
+	})
+	require.NoError(t, err, "failed to build bloom filter")
+	require.EqualValues(t, 3, count)
+
+	checker, err := LoadBloomBreachChecker(output)
+	require.NoError(t, err, "failed to load bloom filter")
+
+	testCases := []struct {
+		name             string
+		password         string
+		expectedBreached bool
+	}{
+		{name: "positive:breached", password: "qwerty", expectedBreached: true},
+		{name: "positive:not breached", password: "Blue-Canyon-42-Ridge", expectedBreached: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			breached, err := checker.IsBreached(tc.password)
+			require.NoError(t, err, "failed to check password")
+			require.Equal(t, tc.expectedBreached, breached)
+		})
+	}
+}
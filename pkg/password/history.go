@@ -0,0 +1,67 @@
+package password
+
+import "fmt"
+
+// HistoryEntry is the hash of the password previously set by the user.
+type HistoryEntry struct {
+	ID   string
+	Hash string
+}
+
+// History is the password history of the user ordered from the latest entry.
+type History []HistoryEntry
+
+// Window returns the latest size entries, new password must not match any of them.
+func (h History) Window(size int) History {
+	if size <= 0 {
+		return nil
+	}
+	if len(h) > size {
+		return h[:size]
+	}
+	return h
+}
+
+// Prune returns IDs of entries exceeding the history size, which are not kept anymore.
+func (h History) Prune(size int) []string {
+	if size < 0 {
+		size = 0
+	}
+
+	var ids []string
+	for i := size; i < len(h); i++ {
+		ids = append(ids, h[i].ID)
+	}
+	return ids
+}
+
+// IsReused returns true if the password matches the current password hash or one of the history entries.
+func IsReused(hasher Hasher, password, currentHash string, history History) (bool, error) {
+	hashes := []string{currentHash}
+	for _, entry := range history {
+		// current password is usually the latest history entry, so do not compare it twice
+		if entry.Hash != currentHash {
+			hashes = append(hashes, entry.Hash)
+		}
+	}
+
+	for _, hash := range hashes {
+		// passwordless user has no current password
+		if hash == "" {
+			continue
+		}
+
+		isPasswordEqual, err := hasher.CompareHashAndPassword(&CompareHashAndPasswordOptions{
+			Hashed:   hash,
+			Password: password,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to compare password: %w", err)
+		}
+		if isPasswordEqual {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
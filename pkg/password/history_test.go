@@ -0,0 +1,84 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// plainHasher "hashes" passwords by prefixing them, so tests do not spend time on bcrypt.
+type plainHasher struct {
+	compared []string
+}
+
+func (h *plainHasher) GenerateHashFromPassword(password string) (string, error) {
+	return "hash:" + password, nil
+}
+
+func (h *plainHasher) CompareHashAndPassword(opts *CompareHashAndPasswordOptions) (bool, error) {
+	h.compared = append(h.compared, opts.Hashed)
+	return opts.Hashed == "hash:"+opts.Password, nil
+}
+
+func newTestHistory(passwords ...string) History {
+	history := make(History, 0, len(passwords))
+	for _, password := range passwords {
+		history = append(history, HistoryEntry{ID: password, Hash: "hash:" + password})
+	}
+	return history
+}
+
+func TestHistory_Window(t *testing.T) {
+	history := newTestHistory("p5", "p4", "p3", "p2", "p1")
+
+	require.Equal(t, newTestHistory("p5", "p4", "p3"), history.Window(3), "window must keep the latest entries")
+	require.Equal(t, history, history.Window(10), "window larger than history must keep all entries")
+	require.Empty(t, history.Window(0), "disabled history must have empty window")
+}
+
+func TestHistory_Prune(t *testing.T) {
+	history := newTestHistory("p5", "p4", "p3", "p2", "p1")
+
+	require.Equal(t, []string{"p2", "p1"}, history.Prune(3), "oldest entries exceeding size must be pruned")
+	require.Empty(t, history.Prune(5), "history of the size must not be pruned")
+	require.Equal(t, []string{"p5", "p4", "p3", "p2", "p1"}, history.Prune(0), "disabled history must be pruned entirely")
+}
+
+func TestIsReused(t *testing.T) {
+	history := newTestHistory("p4", "p3", "p2", "p1")
+
+	testCases := []struct {
+		name          string
+		password      string
+		size          int
+		expectedReuse bool
+	}{
+		{name: "current password", password: "p4", size: 0, expectedReuse: true},
+		{name: "password within window", password: "p2", size: 3, expectedReuse: true},
+		{name: "password out of window", password: "p1", size: 3, expectedReuse: false},
+		{name: "new password", password: "p5", size: 3, expectedReuse: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hasher := &plainHasher{}
+			isReused, err := IsReused(hasher, tc.password, "hash:p4", history.Window(tc.size))
+			require.NoError(t, err, "failed to check reuse")
+			require.Equal(t, tc.expectedReuse, isReused)
+		})
+	}
+}
+
+func TestIsReused_ComparesCurrentOnce(t *testing.T) {
+	hasher := &plainHasher{}
+	_, err := IsReused(hasher, "new", "hash:p2", newTestHistory("p2", "p1"))
+	require.NoError(t, err, "failed to check reuse")
+	require.Equal(t, []string{"hash:p2", "hash:p1"}, hasher.compared, "current password must be compared once")
+}
+
+func TestIsReused_Passwordless(t *testing.T) {
+	hasher := &plainHasher{}
+	isReused, err := IsReused(hasher, "new", "", nil)
+	require.NoError(t, err, "failed to check reuse")
+	require.False(t, isReused)
+	require.Empty(t, hasher.compared, "empty hash must not be compared")
+}
@@ -0,0 +1,144 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	RuleMinLength        = "min_length"
+	RuleMaxLength        = "max_length"
+	RuleUppercase        = "uppercase"
+	RuleLowercase        = "lowercase"
+	RuleDigit            = "digit"
+	RuleSymbol           = "symbol"
+	RuleStrength         = "strength"
+	RuleContainsUserData = "contains_user_data"
+	RuleBreached         = "breached"
+)
+
+// minUserInputLength is the minimal length of user input that is checked for inclusion into the password.
+// Shorter inputs (e.g. two-letter names) produce too many false positives.
+const minUserInputLength = 3
+
+// Validator provides logic for checking password against a password policy.
+type Validator interface {
+	// Validate is used to check the password and return all failed rules.
+	// If password satisfies the policy - returns empty slice.
+	Validate(opts *ValidateOptions) []Violation
+}
+
+// Policy describes the rules password has to satisfy.
+type Policy struct {
+	// MinLength is the minimal number of characters.
+	MinLength int
+	// MaxLength is the maximal number of bytes, since bcrypt ignores bytes after the 72nd.
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// MinStrength is the minimal strength score (from 0 to 4) returned by EstimateStrength.
+	MinStrength int
+}
+
+// Violation describes a single failed policy rule.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type ValidateOptions struct {
+	Password string
+	// UserInputs are user related values (name, email, etc.) which must not be a part of the password.
+	UserInputs []string
+}
+
+type policyValidator struct {
+	policy Policy
+}
+
+// Check if implements the interface.
+var _ Validator = (*policyValidator)(nil)
+
+func NewPolicyValidator(policy Policy) *policyValidator {
+	return &policyValidator{policy: policy}
+}
+
+func (v *policyValidator) Validate(opts *ValidateOptions) []Violation {
+	var violations []Violation
+
+	if v.policy.MinLength > 0 && utf8.RuneCountInString(opts.Password) < v.policy.MinLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("password must be at least %d characters long", v.policy.MinLength),
+		})
+	}
+	if v.policy.MaxLength > 0 && len(opts.Password) > v.policy.MaxLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMaxLength,
+			Message: fmt.Sprintf("password must be at most %d bytes long", v.policy.MaxLength),
+		})
+	}
+
+	classes := getCharClasses(opts.Password)
+	if v.policy.RequireUpper && !classes.upper {
+		violations = append(violations, Violation{Rule: RuleUppercase, Message: "password must contain an uppercase letter"})
+	}
+	if v.policy.RequireLower && !classes.lower {
+		violations = append(violations, Violation{Rule: RuleLowercase, Message: "password must contain a lowercase letter"})
+	}
+	if v.policy.RequireDigit && !classes.digit {
+		violations = append(violations, Violation{Rule: RuleDigit, Message: "password must contain a digit"})
+	}
+	if v.policy.RequireSymbol && !classes.symbol {
+		violations = append(violations, Violation{Rule: RuleSymbol, Message: "password must contain a symbol"})
+	}
+
+	lowerPassword := strings.ToLower(opts.Password)
+	for _, input := range opts.UserInputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		if utf8.RuneCountInString(input) < minUserInputLength {
+			continue
+		}
+		if strings.Contains(lowerPassword, input) {
+			violations = append(violations, Violation{
+				Rule:    RuleContainsUserData,
+				Message: "password must not contain your name, surname or email",
+			})
+			break
+		}
+	}
+
+	if EstimateStrength(opts.Password, opts.UserInputs...) < v.policy.MinStrength {
+		violations = append(violations, Violation{Rule: RuleStrength, Message: "password is too weak"})
+	}
+
+	return violations
+}
+
+type charClasses struct {
+	upper  bool
+	lower  bool
+	digit  bool
+	symbol bool
+}
+
+func getCharClasses(password string) charClasses {
+	var classes charClasses
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			classes.upper = true
+		case unicode.IsLower(r):
+			classes.lower = true
+		case unicode.IsDigit(r):
+			classes.digit = true
+		default:
+			classes.symbol = true
+		}
+	}
+	return classes
+}
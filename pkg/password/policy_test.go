@@ -0,0 +1,115 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_policyValidator_Validate(t *testing.T) {
+	validator := NewPolicyValidator(Policy{
+		MinLength:     8,
+		MaxLength:     72,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		MinStrength:   3,
+	})
+
+	type args struct {
+		password   string
+		userInputs []string
+	}
+	testCases := []struct {
+		name          string
+		args          args
+		expectedRules []string
+	}{
+		{
+			name: "positive:strong password",
+			args: args{
+				password:   "Tr0ub4dor&3Horse",
+				userInputs: []string{"John", "Doe", "john.doe"},
+			},
+			expectedRules: nil,
+		},
+		{
+			name: "negative:too short and weak",
+			args: args{
+				password: "a",
+			},
+			expectedRules: []string{RuleMinLength, RuleUppercase, RuleDigit, RuleSymbol, RuleStrength},
+		},
+		{
+			name: "negative:too long",
+			args: args{
+				password: "Aa1!" + string(make([]byte, 80)),
+			},
+			expectedRules: []string{RuleMaxLength},
+		},
+		{
+			name: "negative:too long in bytes",
+			args: args{
+				// 42 characters, but 80 bytes
+				password: "Aa1!" + strings.Repeat("ї", 38),
+			},
+			expectedRules: []string{RuleMaxLength},
+		},
+		{
+			name: "negative:contains user data",
+			args: args{
+				password:   "Johnathan#2024x",
+				userInputs: []string{"Johnathan", "Doe", "jdoe"},
+			},
+			expectedRules: []string{RuleContainsUserData},
+		},
+		{
+			name: "negative:common password with substitutions",
+			args: args{
+				password: "P@ssw0rd123!",
+			},
+			expectedRules: []string{RuleStrength},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := validator.Validate(&ValidateOptions{
+				Password:   tc.args.password,
+				UserInputs: tc.args.userInputs,
+			})
+
+			var rules []string
+			for _, violation := range violations {
+				require.NotEmpty(t, violation.Message, "violation message is empty")
+				rules = append(rules, violation.Rule)
+			}
+			require.Equal(t, tc.expectedRules, rules)
+		})
+	}
+}
+
+func TestEstimateStrength(t *testing.T) {
+	testCases := []struct {
+		name          string
+		password      string
+		userInputs    []string
+		expectedScore int
+	}{
+		{name: "empty", password: "", expectedScore: 0},
+		{name: "common password", password: "password", expectedScore: 0},
+		{name: "keyboard sequence", password: "qwerty123", expectedScore: 1},
+		{name: "repeats", password: "aaaaaaaaaa", expectedScore: 0},
+		{name: "user input", password: "johndoe", userInputs: []string{"johndoe"}, expectedScore: 0},
+		{name: "random", password: "x7#Kq9!vLm2$", expectedScore: 4},
+		{name: "passphrase", password: "correct horse battery staple", expectedScore: 4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedScore, EstimateStrength(tc.password, tc.userInputs...))
+		})
+	}
+}
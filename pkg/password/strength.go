@@ -0,0 +1,181 @@
+package password
+
+import (
+	"math"
+	"unicode"
+)
+
+// repeatWeight is the entropy weight of a character that repeats the previous one.
+const repeatWeight = 0.1
+
+// commonWords contains the most popular passwords and password building blocks.
+// Any occurrence of them in the password is counted as a single character.
+var commonWords = []string{
+	"password", "qwerty", "letmein", "welcome", "admin", "login", "iloveyou",
+	"monkey", "dragon", "master", "sunshine", "princess", "shadow", "football", "baseball",
+	"superman", "batman", "trustno", "secret", "summer", "winter", "spring", "autumn",
+	"hello", "freedom", "whatever", "starwars", "michael", "charlie", "jordan", "love",
+}
+
+// sequenceSources contains alphabets and keyboard rows used to detect sequences like "abc", "321" or "qwer".
+var sequenceSources = []string{
+	"abcdefghijklmnopqrstuvwxyz",
+	"0123456789",
+	"qwertyuiop",
+	"asdfghjkl",
+	"zxcvbnm",
+}
+
+// leetSubstitutions maps commonly used substitutions to original letters.
+var leetSubstitutions = map[rune]rune{
+	'0': 'o', '1': 'l', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's', '!': 'i',
+}
+
+// EstimateStrength returns zxcvbn-like password strength score from 0 (too guessable) to 4 (very unguessable).
+// Score is calculated from the estimated number of guesses needed to crack the password, where
+// common words, user inputs, sequences and repeats reduce the password entropy.
+func EstimateStrength(password string, userInputs ...string) int {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return 0
+	}
+
+	lower := make([]rune, len(runes))
+	normalized := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+		normalized[i] = lower[i]
+		if sub, ok := leetSubstitutions[lower[i]]; ok {
+			normalized[i] = sub
+		}
+	}
+
+	// weights contains entropy contribution of each character
+	weights := make([]float64, len(runes))
+	for i := range weights {
+		weights[i] = 1
+	}
+
+	for _, word := range commonWords {
+		markOccurrences(normalized, []rune(word), weights)
+	}
+	for _, input := range userInputs {
+		inputRunes := []rune(input)
+		for i, r := range inputRunes {
+			inputRunes[i] = unicode.ToLower(r)
+		}
+		if len(inputRunes) < minUserInputLength {
+			continue
+		}
+		markOccurrences(lower, inputRunes, weights)
+	}
+	markSequences(lower, weights)
+	for i := 1; i < len(lower); i++ {
+		if lower[i] == lower[i-1] && weights[i] > repeatWeight {
+			weights[i] = repeatWeight
+		}
+	}
+
+	var effectiveLength float64
+	for _, w := range weights {
+		effectiveLength += w
+	}
+	log10Guesses := effectiveLength * math.Log10(float64(charsetSize(runes)))
+
+	// thresholds are taken from zxcvbn
+	switch {
+	case log10Guesses < 3:
+		return 0
+	case log10Guesses < 6:
+		return 1
+	case log10Guesses < 8:
+		return 2
+	case log10Guesses < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// markOccurrences marks every occurrence of the word as a single character.
+func markOccurrences(password, word []rune, weights []float64) {
+	if len(word) == 0 {
+		return
+	}
+	for start := 0; start+len(word) <= len(password); start++ {
+		if string(password[start:start+len(word)]) == string(word) {
+			markSpan(weights, start, start+len(word))
+		}
+	}
+}
+
+// markSequences marks every sequence of at least 3 consecutive characters (forward or backward)
+// of the known sequence sources as a single character.
+func markSequences(password []rune, weights []float64) {
+	for _, source := range sequenceSources {
+		positions := make(map[rune]int, len(source))
+		for i, r := range source {
+			if _, ok := positions[r]; !ok {
+				positions[r] = i
+			}
+		}
+
+		for start := 0; start < len(password)-1; start++ {
+			first, ok := positions[password[start]]
+			if !ok {
+				continue
+			}
+			second, ok := positions[password[start+1]]
+			if !ok {
+				continue
+			}
+			direction := second - first
+			if direction != 1 && direction != -1 {
+				continue
+			}
+
+			end := start + 2
+			for end < len(password) {
+				next, ok := positions[password[end]]
+				if !ok {
+					break
+				}
+				prev := positions[password[end-1]]
+				if next-prev != direction {
+					break
+				}
+				end++
+			}
+			if end-start >= 3 {
+				markSpan(weights, start, end)
+			}
+		}
+	}
+}
+
+// markSpan makes the whole span contribute as a single character.
+func markSpan(weights []float64, start, end int) {
+	weights[start] = math.Min(weights[start], 1)
+	for i := start + 1; i < end; i++ {
+		weights[i] = 0
+	}
+}
+
+// charsetSize returns the size of the alphabet password characters are picked from.
+func charsetSize(password []rune) int {
+	var size int
+	classes := getCharClasses(string(password))
+	if classes.lower {
+		size += 26
+	}
+	if classes.upper {
+		size += 26
+	}
+	if classes.digit {
+		size += 10
+	}
+	if classes.symbol {
+		size += 33
+	}
+	return size
+}
@@ -0,0 +1,356 @@
+// Package policy provides an attribute-based authorization engine evaluating declarative policies.
+//
+// Policies are written in JSON:
+//
+//	{
+//	  "policies": [
+//	    {
+//	      "id": "support-same-domain",
+//	      "description": "support reads users of their own company only",
+//	      "effect": "deny",
+//	      "actions": ["users:read"],
+//	      "conditions": [
+//	        {"attribute": "subject.permissions", "operator": "contains", "value": "users:read"},
+//	        {"attribute": "resource.emailDomain", "operator": "ne", "valueFrom": "subject.emailDomain"}
+//	      ]
+//	    }
+//	  ]
+//	}
+//
+// A policy applies to the request if one of its actions matches the request action and all conditions hold.
+// Deny policies override allow ones. If no policy applies, the decision is EffectNotApplicable and the caller
+// falls back to its own rules.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Effect is the outcome of a policy or a decision.
+type Effect string
+
+const (
+	EffectAllow Effect = "allow"
+	EffectDeny  Effect = "deny"
+	// EffectNotApplicable is the decision effect when no policy applies to the request.
+	EffectNotApplicable Effect = "not_applicable"
+)
+
+// Operator compares the condition attribute with the operand.
+type Operator string
+
+const (
+	// OperatorEq holds if attribute equals the operand.
+	OperatorEq Operator = "eq"
+	// OperatorNe holds if attribute does not equal the operand.
+	OperatorNe Operator = "ne"
+	// OperatorIn holds if attribute equals one of the operand list values.
+	OperatorIn Operator = "in"
+	// OperatorNotIn holds if attribute equals none of the operand list values.
+	OperatorNotIn Operator = "notIn"
+	// OperatorContains holds if attribute list contains the operand.
+	OperatorContains Operator = "contains"
+	// OperatorExists holds if presence of the attribute matches the boolean operand.
+	OperatorExists Operator = "exists"
+)
+
+// Prefixes of attribute references.
+const (
+	subjectPrefix  = "subject."
+	resourcePrefix = "resource."
+)
+
+// Attributes describe the subject or the resource. Values are strings, booleans, numbers or lists of them.
+type Attributes map[string]interface{}
+
+// Policy is a single rule allowing or denying actions.
+type Policy struct {
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	Effect      Effect `json:"effect"`
+	// Actions the policy applies to. "*" matches any action, "users:*" matches actions starting with "users:".
+	Actions []string `json:"actions"`
+	// Conditions all have to hold for the policy to apply. Policy without conditions applies to all requests
+	// with matching action.
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// Condition compares the attribute with either the literal Value or the attribute referenced by ValueFrom.
+type Condition struct {
+	// Attribute is the reference to the compared attribute, e.g. "subject.id" or "resource.emailDomain".
+	Attribute string      `json:"attribute"`
+	Operator  Operator    `json:"operator"`
+	Value     interface{} `json:"value,omitempty"`
+	// ValueFrom is the reference to the attribute used as the operand instead of Value.
+	ValueFrom string `json:"valueFrom,omitempty"`
+}
+
+// Request is the authorization question: can the subject perform the action on the resource.
+type Request struct {
+	Subject  Attributes `json:"subject"`
+	Resource Attributes `json:"resource"`
+	Action   string     `json:"action"`
+}
+
+// Decision is the result of request evaluation.
+type Decision struct {
+	Effect Effect `json:"effect"`
+	// PolicyID is the id of the policy the decision is made by, empty if no policy applies.
+	PolicyID string `json:"policyId,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// Engine evaluates requests against the set of policies. It is safe for concurrent use.
+type Engine struct {
+	policies []Policy
+}
+
+// New validates policies and returns the engine evaluating them.
+func New(policies []Policy) (*Engine, error) {
+	ids := make(map[string]bool, len(policies))
+	for _, policy := range policies {
+		err := validatePolicy(policy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy %q: %w", policy.ID, err)
+		}
+		if ids[policy.ID] {
+			return nil, fmt.Errorf("duplicate policy id %q", policy.ID)
+		}
+		ids[policy.ID] = true
+	}
+
+	return &Engine{policies: policies}, nil
+}
+
+// document is the JSON document policies are loaded from.
+type document struct {
+	Policies []Policy `json:"policies"`
+}
+
+// Parse is used to create the engine from JSON document.
+func Parse(data []byte) (*Engine, error) {
+	var doc document
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode policies: %w", err)
+	}
+
+	return New(doc.Policies)
+}
+
+// LoadFile is used to create the engine from JSON document stored in the file.
+func LoadFile(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policies file: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Policies returns the evaluated policies.
+func (e *Engine) Policies() []Policy {
+	return e.policies
+}
+
+// Evaluate returns the decision for the request. Any applicable deny policy denies the request,
+// otherwise any applicable allow policy allows it.
+func (e *Engine) Evaluate(req Request) Decision {
+	var allowedBy *Policy
+	for i, policy := range e.policies {
+		if !policy.matchesAction(req.Action) || !policy.matchesConditions(req) {
+			continue
+		}
+		if policy.Effect == EffectDeny {
+			return Decision{Effect: EffectDeny, PolicyID: policy.ID, Reason: policy.reason("denied")}
+		}
+		if allowedBy == nil {
+			allowedBy = &e.policies[i]
+		}
+	}
+
+	if allowedBy != nil {
+		return Decision{Effect: EffectAllow, PolicyID: allowedBy.ID, Reason: allowedBy.reason("allowed")}
+	}
+	return Decision{Effect: EffectNotApplicable, Reason: fmt.Sprintf("no policy applies to action %q", req.Action)}
+}
+
+func (p Policy) reason(verb string) string {
+	if p.Description == "" {
+		return fmt.Sprintf("%s by policy %q", verb, p.ID)
+	}
+	return fmt.Sprintf("%s by policy %q: %s", verb, p.ID, p.Description)
+}
+
+func (p Policy) matchesAction(action string) bool {
+	for _, pattern := range p.Actions {
+		if pattern == "*" || pattern == action {
+			return true
+		}
+		if strings.HasSuffix(pattern, ":*") && strings.HasPrefix(action, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Policy) matchesConditions(req Request) bool {
+	for _, condition := range p.Conditions {
+		if !condition.holds(req) {
+			return false
+		}
+	}
+	return true
+}
+
+// holds evaluates the condition. Missing attribute or operand fails every operator except exists.
+func (c Condition) holds(req Request) bool {
+	value, ok := resolve(req, c.Attribute)
+	if c.Operator == OperatorExists {
+		return ok == c.Value.(bool)
+	}
+	if !ok {
+		return false
+	}
+
+	operand := c.Value
+	if c.ValueFrom != "" {
+		operand, ok = resolve(req, c.ValueFrom)
+		if !ok {
+			return false
+		}
+	}
+	value, operand = normalize(value), normalize(operand)
+
+	switch c.Operator {
+	case OperatorEq:
+		return reflect.DeepEqual(value, operand)
+	case OperatorNe:
+		return !reflect.DeepEqual(value, operand)
+	case OperatorIn:
+		return containsValue(operand, value)
+	case OperatorNotIn:
+		list, isList := operand.([]interface{})
+		return isList && !containsValue(list, value)
+	case OperatorContains:
+		return containsValue(value, operand)
+	default:
+		return false
+	}
+}
+
+// containsValue returns true if list is a list with the value.
+func containsValue(list, value interface{}) bool {
+	values, ok := list.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the value of the referenced request attribute.
+func resolve(req Request, ref string) (interface{}, bool) {
+	var attributes Attributes
+	var name string
+	switch {
+	case strings.HasPrefix(ref, subjectPrefix):
+		attributes, name = req.Subject, strings.TrimPrefix(ref, subjectPrefix)
+	case strings.HasPrefix(ref, resourcePrefix):
+		attributes, name = req.Resource, strings.TrimPrefix(ref, resourcePrefix)
+	default:
+		return nil, false
+	}
+
+	value, ok := attributes[name]
+	if !ok || value == nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// normalize converts numbers to float64 and lists to []interface{}, so values built in Go
+// compare equal to values decoded from JSON.
+func normalize(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = normalize(v.Index(i).Interface())
+		}
+		return list
+	default:
+		return value
+	}
+}
+
+func validatePolicy(policy Policy) error {
+	if policy.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if policy.Effect != EffectAllow && policy.Effect != EffectDeny {
+		return fmt.Errorf("unknown effect %q", policy.Effect)
+	}
+	if len(policy.Actions) == 0 {
+		return fmt.Errorf("at least one action is required")
+	}
+
+	for i, condition := range policy.Conditions {
+		err := validateCondition(condition)
+		if err != nil {
+			return fmt.Errorf("invalid condition %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func validateCondition(condition Condition) error {
+	if !isAttributeRef(condition.Attribute) {
+		return fmt.Errorf("attribute %q has to start with %q or %q", condition.Attribute, subjectPrefix, resourcePrefix)
+	}
+	if condition.ValueFrom != "" {
+		if !isAttributeRef(condition.ValueFrom) {
+			return fmt.Errorf("valueFrom %q has to start with %q or %q", condition.ValueFrom, subjectPrefix, resourcePrefix)
+		}
+		if condition.Value != nil {
+			return fmt.Errorf("value and valueFrom can not be set both")
+		}
+	}
+
+	switch condition.Operator {
+	case OperatorEq, OperatorNe, OperatorContains:
+	case OperatorIn, OperatorNotIn:
+		if condition.ValueFrom == "" && reflect.ValueOf(condition.Value).Kind() != reflect.Slice {
+			return fmt.Errorf("operator %q requires list value", condition.Operator)
+		}
+	case OperatorExists:
+		if _, ok := condition.Value.(bool); !ok || condition.ValueFrom != "" {
+			return fmt.Errorf("operator %q requires boolean value", condition.Operator)
+		}
+	default:
+		return fmt.Errorf("unknown operator %q", condition.Operator)
+	}
+
+	return nil
+}
+
+func isAttributeRef(ref string) bool {
+	return (strings.HasPrefix(ref, subjectPrefix) && len(ref) > len(subjectPrefix)) ||
+		(strings.HasPrefix(ref, resourcePrefix) && len(ref) > len(resourcePrefix))
+}
@@ -0,0 +1,165 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicies = `{
+  "policies": [
+    {
+      "id": "support-read",
+      "effect": "allow",
+      "actions": ["users:read"],
+      "conditions": [
+        {"attribute": "subject.roles", "operator": "contains", "value": "support"},
+        {"attribute": "resource.emailDomain", "operator": "eq", "valueFrom": "subject.emailDomain"}
+      ]
+    },
+    {
+      "id": "no-admin-changes",
+      "description": "admins are changed only by admins",
+      "effect": "deny",
+      "actions": ["users:*"],
+      "conditions": [
+        {"attribute": "resource.isAdmin", "operator": "eq", "value": true},
+        {"attribute": "subject.isAdmin", "operator": "ne", "value": true}
+      ]
+    },
+    {
+      "id": "unverified",
+      "effect": "deny",
+      "actions": ["users:update"],
+      "conditions": [
+        {"attribute": "subject.authMethods", "operator": "exists", "value": false}
+      ]
+    }
+  ]
+}`
+
+func TestEngine_Evaluate(t *testing.T) {
+	engine, err := Parse([]byte(testPolicies))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name             string
+		req              Request
+		expectedEffect   Effect
+		expectedPolicyID string
+	}{
+		{
+			name: "positive:allowed by matching conditions",
+			req: Request{
+				Subject:  Attributes{"roles": []string{"support"}, "emailDomain": "example.com"},
+				Resource: Attributes{"emailDomain": "example.com", "isAdmin": false},
+				Action:   "users:read",
+			},
+			expectedEffect:   EffectAllow,
+			expectedPolicyID: "support-read",
+		},
+		{
+			name: "positive:deny overrides allow",
+			req: Request{
+				Subject:  Attributes{"roles": []string{"support"}, "emailDomain": "example.com", "isAdmin": false},
+				Resource: Attributes{"emailDomain": "example.com", "isAdmin": true},
+				Action:   "users:read",
+			},
+			expectedEffect:   EffectDeny,
+			expectedPolicyID: "no-admin-changes",
+		},
+		{
+			name: "positive:missing attribute checked with exists",
+			req: Request{
+				Subject:  Attributes{"isAdmin": true},
+				Resource: Attributes{"isAdmin": false},
+				Action:   "users:update",
+			},
+			expectedEffect:   EffectDeny,
+			expectedPolicyID: "unverified",
+		},
+		{
+			name: "negative:conditions do not hold",
+			req: Request{
+				Subject:  Attributes{"roles": []string{"support"}, "emailDomain": "example.com"},
+				Resource: Attributes{"emailDomain": "other.com"},
+				Action:   "users:read",
+			},
+			expectedEffect: EffectNotApplicable,
+		},
+		{
+			name: "negative:action does not match",
+			req: Request{
+				Subject:  Attributes{"roles": []string{"support"}, "emailDomain": "example.com"},
+				Resource: Attributes{"emailDomain": "example.com"},
+				Action:   "roles:manage",
+			},
+			expectedEffect: EffectNotApplicable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decision := engine.Evaluate(tc.req)
+			require.Equal(t, tc.expectedEffect, decision.Effect)
+			require.Equal(t, tc.expectedPolicyID, decision.PolicyID)
+			require.NotEmpty(t, decision.Reason)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policies []Policy
+		isValid  bool
+	}{
+		{
+			name:     "positive:no policies",
+			policies: nil,
+			isValid:  true,
+		},
+		{
+			name: "positive:numbers compared with list",
+			policies: []Policy{{ID: "p", Effect: EffectAllow, Actions: []string{"*"}, Conditions: []Condition{
+				{Attribute: "subject.level", Operator: OperatorIn, Value: []int{1, 2}},
+			}}},
+			isValid: true,
+		},
+		{
+			name:     "negative:unknown effect",
+			policies: []Policy{{ID: "p", Effect: "maybe", Actions: []string{"*"}}},
+		},
+		{
+			name:     "negative:no actions",
+			policies: []Policy{{ID: "p", Effect: EffectAllow}},
+		},
+		{
+			name:     "negative:duplicate id",
+			policies: []Policy{{ID: "p", Effect: EffectAllow, Actions: []string{"*"}}, {ID: "p", Effect: EffectDeny, Actions: []string{"*"}}},
+		},
+		{
+			name: "negative:invalid attribute reference",
+			policies: []Policy{{ID: "p", Effect: EffectAllow, Actions: []string{"*"}, Conditions: []Condition{
+				{Attribute: "user.id", Operator: OperatorEq, Value: "1"},
+			}}},
+		},
+		{
+			name: "negative:in operator without list",
+			policies: []Policy{{ID: "p", Effect: EffectAllow, Actions: []string{"*"}, Conditions: []Condition{
+				{Attribute: "subject.id", Operator: OperatorIn, Value: "1"},
+			}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(tc.policies)
+			if tc.isValid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
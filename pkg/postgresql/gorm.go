@@ -1,6 +1,7 @@
 package postgresql
 
 import (
+	"context"
 	"fmt"
 
 	// third party
@@ -38,5 +39,34 @@ func NewPostgreSQLGorm(cfg Config) (*PostgreSQLGorm, error) {
 		return nil, fmt.Errorf("failed to create uuid-ossp extension: %s", err)
 	}
 
+	// create trigram extension used for text search.
+	err = db.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pg_trgm extension: %s", err)
+	}
+
 	return &PostgreSQLGorm{DB: db}, nil
 }
+
+// RunLocked runs fn holding the advisory lock with the key, so it is not run concurrently by other replicas.
+// If the lock is held by someone else, returns false without running fn. The lock is held by the transaction,
+// so it is released even if connection is lost.
+func (p *PostgreSQLGorm) RunLocked(ctx context.Context, key string, fn func(ctx context.Context) error) (bool, error) {
+	var isLocked bool
+	err := p.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Raw("SELECT pg_try_advisory_xact_lock(hashtext(?))", key).Scan(&isLocked).Error
+		if err != nil {
+			return fmt.Errorf("failed to acquire advisory lock: %w", err)
+		}
+		if !isLocked {
+			return nil
+		}
+
+		return fn(ctx)
+	})
+	if err != nil {
+		return isLocked, err
+	}
+
+	return isLocked, nil
+}
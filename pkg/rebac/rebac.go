@@ -0,0 +1,535 @@
+// Package rebac provides a relationship-based authorization engine in the style of Google Zanzibar.
+//
+// Access is derived from relation tuples ("document:readme#viewer@user:42") and namespace configuration
+// describing how relations are computed from each other. Namespaces are written in JSON:
+//
+//	{
+//	  "namespaces": [
+//	    {"name": "user"},
+//	    {
+//	      "name": "group",
+//	      "relations": [{"name": "member"}]
+//	    },
+//	    {
+//	      "name": "document",
+//	      "relations": [
+//	        {"name": "parent"},
+//	        {"name": "owner"},
+//	        {"name": "editor", "rewrite": {"union": [{"this": true}, {"computedUserset": "owner"}]}},
+//	        {"name": "viewer", "rewrite": {"union": [
+//	          {"this": true},
+//	          {"computedUserset": "editor"},
+//	          {"tupleToUserset": {"tupleset": "parent", "computedUserset": "viewer"}}
+//	        ]}}
+//	      ]
+//	    }
+//	  ]
+//	}
+//
+// Relation without rewrite consists of the subjects of its tuples only. Rewrite rules are:
+//   - this: subjects of the relation tuples, subject sets are followed;
+//   - computedUserset: subjects of another relation of the same object;
+//   - tupleToUserset: subjects of the computedUserset relation of objects the tupleset relation points to;
+//   - union, intersection and exclusion of other rules.
+package rebac
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// MaxDepth is the maximum number of nested relations followed while a request is evaluated.
+const MaxDepth = 32
+
+// ErrMaxDepthExceeded is returned if evaluation follows more than MaxDepth nested relations.
+var ErrMaxDepthExceeded = errors.New("max depth exceeded")
+
+// Config is the set of namespaces.
+type Config struct {
+	Namespaces []Namespace `json:"namespaces"`
+}
+
+// Namespace is the type of objects, e.g. "document".
+type Namespace struct {
+	Name string `json:"name"`
+	// Relations objects of the namespace can have. Namespace without relations is used for direct subjects only.
+	Relations []Relation `json:"relations,omitempty"`
+}
+
+// Relation is the named relation between objects of the namespace and subjects.
+type Relation struct {
+	Name string `json:"name"`
+	// Rewrite computes relation subjects, they are subjects of the relation tuples only if it is nil.
+	Rewrite *Rewrite `json:"rewrite,omitempty"`
+}
+
+// Rewrite is the rule computing the subjects of a relation. Exactly one of its fields has to be set.
+type Rewrite struct {
+	This            bool            `json:"this,omitempty"`
+	ComputedUserset string          `json:"computedUserset,omitempty"`
+	TupleToUserset  *TupleToUserset `json:"tupleToUserset,omitempty"`
+	Union           []Rewrite       `json:"union,omitempty"`
+	Intersection    []Rewrite       `json:"intersection,omitempty"`
+	Exclusion       *Exclusion      `json:"exclusion,omitempty"`
+}
+
+// TupleToUserset follows the Tupleset relation of the object to other objects
+// and takes subjects of their ComputedUserset relation.
+type TupleToUserset struct {
+	Tupleset        string `json:"tupleset"`
+	ComputedUserset string `json:"computedUserset"`
+}
+
+// Exclusion takes subjects of Base which are not subjects of Subtract.
+type Exclusion struct {
+	Base     Rewrite `json:"base"`
+	Subtract Rewrite `json:"subtract"`
+}
+
+// thisRewrite is the rewrite of relations without one.
+var thisRewrite = &Rewrite{This: true}
+
+// TupleReader reads relation tuples. Engine reads all tuples of a request through one reader,
+// so it has to read a consistent snapshot for results to be consistent.
+type TupleReader interface {
+	// ReadTuples returns tuples of the object relation.
+	ReadTuples(ctx context.Context, object Object, relation string) ([]Tuple, error)
+	// ListObjectIDs returns IDs of the namespace objects having any relation tuples.
+	ListObjectIDs(ctx context.Context, namespace string) ([]string, error)
+}
+
+// Tree is the node of the expanded relation.
+type Tree struct {
+	// Operation is "leaf", "union", "intersection" or "exclusion".
+	Operation string `json:"operation"`
+	// Userset is the object relation the node stands for, set for nodes of a whole relation.
+	Userset *Subject `json:"userset,omitempty"`
+	// Subjects are set for leaves. Subject sets are not expanded further.
+	Subjects []Subject `json:"subjects,omitempty"`
+	Children []*Tree   `json:"children,omitempty"`
+}
+
+// Tree operations.
+const (
+	OperationLeaf         = "leaf"
+	OperationUnion        = "union"
+	OperationIntersection = "intersection"
+	OperationExclusion    = "exclusion"
+)
+
+// Engine evaluates requests against relation tuples according to the namespaces. It is safe for concurrent use.
+type Engine struct {
+	config     Config
+	namespaces map[string]map[string]*Rewrite
+}
+
+// New validates the configuration and returns the engine evaluating it.
+func New(config Config) (*Engine, error) {
+	namespaces := make(map[string]map[string]*Rewrite, len(config.Namespaces))
+	for _, namespace := range config.Namespaces {
+		if namespace.Name == "" {
+			return nil, fmt.Errorf("namespace name is required")
+		}
+		if _, ok := namespaces[namespace.Name]; ok {
+			return nil, fmt.Errorf("duplicate namespace %q", namespace.Name)
+		}
+		relations := make(map[string]*Rewrite, len(namespace.Relations))
+		for _, relation := range namespace.Relations {
+			if relation.Name == "" {
+				return nil, fmt.Errorf("namespace %q has relation without name", namespace.Name)
+			}
+			if _, ok := relations[relation.Name]; ok {
+				return nil, fmt.Errorf("namespace %q has duplicate relation %q", namespace.Name, relation.Name)
+			}
+			relations[relation.Name] = relation.Rewrite
+		}
+		namespaces[namespace.Name] = relations
+	}
+
+	// Rewrites are validated once all relations are known, since they reference each other
+	for _, namespace := range config.Namespaces {
+		for _, relation := range namespace.Relations {
+			if relation.Rewrite == nil {
+				continue
+			}
+			err := validateRewrite(namespaces[namespace.Name], *relation.Rewrite)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rewrite of relation %q of namespace %q: %w", relation.Name, namespace.Name, err)
+			}
+		}
+	}
+
+	return &Engine{config: config, namespaces: namespaces}, nil
+}
+
+// Parse is used to create the engine from JSON document.
+func Parse(data []byte) (*Engine, error) {
+	var config Config
+	err := json.Unmarshal(data, &config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode namespaces: %w", err)
+	}
+
+	return New(config)
+}
+
+// LoadFile is used to create the engine from JSON document stored in the file.
+func LoadFile(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read namespaces file: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Config returns the evaluated configuration.
+func (e *Engine) Config() Config {
+	return e.config
+}
+
+// ValidateObjectRelation returns error if the namespace of the object is not configured
+// or does not have the relation.
+func (e *Engine) ValidateObjectRelation(object Object, relation string) error {
+	relations, ok := e.namespaces[object.Namespace]
+	if !ok {
+		return fmt.Errorf("unknown namespace %q", object.Namespace)
+	}
+	if _, ok := relations[relation]; !ok {
+		return fmt.Errorf("namespace %q has no relation %q", object.Namespace, relation)
+	}
+	return nil
+}
+
+// ValidateSubject returns error if the namespace of the subject is not configured,
+// or it does not have the relation of the subject set.
+func (e *Engine) ValidateSubject(subject Subject) error {
+	if subject.IsSet() {
+		return e.ValidateObjectRelation(subject.Object(), subject.Relation)
+	}
+	if _, ok := e.namespaces[subject.Namespace]; !ok {
+		return fmt.Errorf("unknown namespace %q", subject.Namespace)
+	}
+	return nil
+}
+
+// ValidateTuple returns error if the tuple references namespaces or relations which are not configured.
+func (e *Engine) ValidateTuple(tuple Tuple) error {
+	err := e.ValidateObjectRelation(tuple.Object, tuple.Relation)
+	if err != nil {
+		return err
+	}
+	return e.ValidateSubject(tuple.Subject)
+}
+
+// Check returns true if the subject has the relation to the object.
+func (e *Engine) Check(ctx context.Context, reader TupleReader, object Object, relation string, subject Subject) (bool, error) {
+	err := e.ValidateObjectRelation(object, relation)
+	if err != nil {
+		return false, err
+	}
+	err = e.ValidateSubject(subject)
+	if err != nil {
+		return false, err
+	}
+
+	c := &checker{engine: e, reader: reader, subject: subject, path: map[string]bool{}}
+	return c.check(ctx, object, relation, 0)
+}
+
+// Expand returns the tree of subjects having the relation to the object.
+func (e *Engine) Expand(ctx context.Context, reader TupleReader, object Object, relation string) (*Tree, error) {
+	err := e.ValidateObjectRelation(object, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &expander{engine: e, reader: reader, path: map[string]bool{}}
+	return x.expand(ctx, object, relation, 0)
+}
+
+// ListObjects returns sorted IDs of the namespace objects the subject has the relation to.
+// Every object having any tuples is checked, so it is meant for namespaces of moderate size.
+func (e *Engine) ListObjects(ctx context.Context, reader TupleReader, namespace, relation string, subject Subject) ([]string, error) {
+	err := e.ValidateObjectRelation(Object{Namespace: namespace}, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := reader.ListObjectIDs(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list object ids: %w", err)
+	}
+
+	allowedIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		isAllowed, err := e.Check(ctx, reader, Object{Namespace: namespace, ID: id}, relation, subject)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check object %q: %w", id, err)
+		}
+		if isAllowed {
+			allowedIDs = append(allowedIDs, id)
+		}
+	}
+	sort.Strings(allowedIDs)
+
+	return allowedIDs, nil
+}
+
+// rewrite returns the rewrite of the configured relation.
+func (e *Engine) rewrite(object Object, relation string) (*Rewrite, bool) {
+	rewrite, ok := e.namespaces[object.Namespace][relation]
+	if !ok {
+		return nil, false
+	}
+	if rewrite == nil {
+		return thisRewrite, true
+	}
+	return rewrite, true
+}
+
+// checker evaluates a single Check request.
+type checker struct {
+	engine  *Engine
+	reader  TupleReader
+	subject Subject
+	// path holds object relations being evaluated, so cyclic tuples are not followed forever.
+	path map[string]bool
+}
+
+func (c *checker) check(ctx context.Context, object Object, relation string, depth int) (bool, error) {
+	if depth > MaxDepth {
+		return false, ErrMaxDepthExceeded
+	}
+	// Subject set has the relation it stands for
+	if c.subject.IsSet() && c.subject.Object() == object && c.subject.Relation == relation {
+		return true, nil
+	}
+
+	rewrite, ok := c.engine.rewrite(object, relation)
+	if !ok {
+		// Tuples may point to relations removed from the configuration, they grant nothing
+		return false, nil
+	}
+
+	key := Subject{Namespace: object.Namespace, ID: object.ID, Relation: relation}.String()
+	if c.path[key] {
+		return false, nil
+	}
+	c.path[key] = true
+	defer delete(c.path, key)
+
+	return c.checkRewrite(ctx, object, relation, *rewrite, depth)
+}
+
+func (c *checker) checkRewrite(ctx context.Context, object Object, relation string, rewrite Rewrite, depth int) (bool, error) {
+	switch {
+	case rewrite.This:
+		tuples, err := c.reader.ReadTuples(ctx, object, relation)
+		if err != nil {
+			return false, fmt.Errorf("failed to read tuples: %w", err)
+		}
+		for _, tuple := range tuples {
+			if tuple.Subject == c.subject {
+				return true, nil
+			}
+		}
+		for _, tuple := range tuples {
+			if !tuple.Subject.IsSet() {
+				continue
+			}
+			isAllowed, err := c.check(ctx, tuple.Subject.Object(), tuple.Subject.Relation, depth+1)
+			if err != nil || isAllowed {
+				return isAllowed, err
+			}
+		}
+		return false, nil
+
+	case rewrite.ComputedUserset != "":
+		return c.check(ctx, object, rewrite.ComputedUserset, depth+1)
+
+	case rewrite.TupleToUserset != nil:
+		tuples, err := c.reader.ReadTuples(ctx, object, rewrite.TupleToUserset.Tupleset)
+		if err != nil {
+			return false, fmt.Errorf("failed to read tuples: %w", err)
+		}
+		for _, tuple := range tuples {
+			isAllowed, err := c.check(ctx, tuple.Subject.Object(), rewrite.TupleToUserset.ComputedUserset, depth+1)
+			if err != nil || isAllowed {
+				return isAllowed, err
+			}
+		}
+		return false, nil
+
+	case rewrite.Union != nil:
+		for _, child := range rewrite.Union {
+			isAllowed, err := c.checkRewrite(ctx, object, relation, child, depth)
+			if err != nil || isAllowed {
+				return isAllowed, err
+			}
+		}
+		return false, nil
+
+	case rewrite.Intersection != nil:
+		for _, child := range rewrite.Intersection {
+			isAllowed, err := c.checkRewrite(ctx, object, relation, child, depth)
+			if err != nil || !isAllowed {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case rewrite.Exclusion != nil:
+		isAllowed, err := c.checkRewrite(ctx, object, relation, rewrite.Exclusion.Base, depth)
+		if err != nil || !isAllowed {
+			return false, err
+		}
+		isExcluded, err := c.checkRewrite(ctx, object, relation, rewrite.Exclusion.Subtract, depth)
+		if err != nil {
+			return false, err
+		}
+		return !isExcluded, nil
+	}
+
+	return false, nil
+}
+
+// expander evaluates a single Expand request.
+type expander struct {
+	engine *Engine
+	reader TupleReader
+	path   map[string]bool
+}
+
+func (x *expander) expand(ctx context.Context, object Object, relation string, depth int) (*Tree, error) {
+	if depth > MaxDepth {
+		return nil, ErrMaxDepthExceeded
+	}
+
+	userset := Subject{Namespace: object.Namespace, ID: object.ID, Relation: relation}
+	rewrite, ok := x.engine.rewrite(object, relation)
+	if !ok || x.path[userset.String()] {
+		return &Tree{Operation: OperationLeaf, Userset: &userset}, nil
+	}
+	x.path[userset.String()] = true
+	defer delete(x.path, userset.String())
+
+	tree, err := x.expandRewrite(ctx, object, relation, *rewrite, depth)
+	if err != nil {
+		return nil, err
+	}
+	tree.Userset = &userset
+
+	return tree, nil
+}
+
+func (x *expander) expandRewrite(ctx context.Context, object Object, relation string, rewrite Rewrite, depth int) (*Tree, error) {
+	switch {
+	case rewrite.This:
+		tuples, err := x.reader.ReadTuples(ctx, object, relation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tuples: %w", err)
+		}
+		subjects := make([]Subject, 0, len(tuples))
+		for _, tuple := range tuples {
+			subjects = append(subjects, tuple.Subject)
+		}
+		return &Tree{Operation: OperationLeaf, Subjects: subjects}, nil
+
+	case rewrite.ComputedUserset != "":
+		return x.expand(ctx, object, rewrite.ComputedUserset, depth+1)
+
+	case rewrite.TupleToUserset != nil:
+		tuples, err := x.reader.ReadTuples(ctx, object, rewrite.TupleToUserset.Tupleset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tuples: %w", err)
+		}
+		tree := &Tree{Operation: OperationUnion}
+		for _, tuple := range tuples {
+			child, err := x.expand(ctx, tuple.Subject.Object(), rewrite.TupleToUserset.ComputedUserset, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			tree.Children = append(tree.Children, child)
+		}
+		return tree, nil
+	}
+
+	var operation string
+	var children []Rewrite
+	switch {
+	case rewrite.Union != nil:
+		operation, children = OperationUnion, rewrite.Union
+	case rewrite.Intersection != nil:
+		operation, children = OperationIntersection, rewrite.Intersection
+	case rewrite.Exclusion != nil:
+		operation, children = OperationExclusion, []Rewrite{rewrite.Exclusion.Base, rewrite.Exclusion.Subtract}
+	}
+	tree := &Tree{Operation: operation}
+	for _, child := range children {
+		childTree, err := x.expandRewrite(ctx, object, relation, child, depth)
+		if err != nil {
+			return nil, err
+		}
+		tree.Children = append(tree.Children, childTree)
+	}
+
+	return tree, nil
+}
+
+// validateRewrite checks that exactly one rule is set and referenced relations exist in the namespace.
+// Relations of the objects tupleToUserset points to are not known in advance, so they are not checked.
+func validateRewrite(relations map[string]*Rewrite, rewrite Rewrite) error {
+	rules := 0
+	if rewrite.This {
+		rules++
+	}
+	if rewrite.ComputedUserset != "" {
+		rules++
+		if _, ok := relations[rewrite.ComputedUserset]; !ok {
+			return fmt.Errorf("computedUserset references unknown relation %q", rewrite.ComputedUserset)
+		}
+	}
+	if rewrite.TupleToUserset != nil {
+		rules++
+		if _, ok := relations[rewrite.TupleToUserset.Tupleset]; !ok {
+			return fmt.Errorf("tupleToUserset references unknown relation %q", rewrite.TupleToUserset.Tupleset)
+		}
+		if rewrite.TupleToUserset.ComputedUserset == "" {
+			return fmt.Errorf("tupleToUserset computedUserset is required")
+		}
+	}
+	for _, children := range [][]Rewrite{rewrite.Union, rewrite.Intersection} {
+		if children == nil {
+			continue
+		}
+		rules++
+		if len(children) == 0 {
+			return fmt.Errorf("union and intersection require at least one rule")
+		}
+		for _, child := range children {
+			err := validateRewrite(relations, child)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if rewrite.Exclusion != nil {
+		rules++
+		for _, child := range []Rewrite{rewrite.Exclusion.Base, rewrite.Exclusion.Subtract} {
+			err := validateRewrite(relations, child)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if rules != 1 {
+		return fmt.Errorf("exactly one rule has to be set, got %d", rules)
+	}
+
+	return nil
+}
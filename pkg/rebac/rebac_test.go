@@ -0,0 +1,286 @@
+package rebac
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testNamespaces = `{
+  "namespaces": [
+    {"name": "user"},
+    {"name": "group", "relations": [{"name": "member"}]},
+    {"name": "folder", "relations": [{"name": "viewer"}]},
+    {
+      "name": "document",
+      "relations": [
+        {"name": "parent"},
+        {"name": "owner"},
+        {"name": "banned"},
+        {"name": "editor", "rewrite": {"union": [{"this": true}, {"computedUserset": "owner"}]}},
+        {"name": "viewer", "rewrite": {"exclusion": {
+          "base": {"union": [
+            {"this": true},
+            {"computedUserset": "editor"},
+            {"tupleToUserset": {"tupleset": "parent", "computedUserset": "viewer"}}
+          ]},
+          "subtract": {"computedUserset": "banned"}
+        }}}
+      ]
+    }
+  ]
+}`
+
+// memoryReader reads tuples from memory.
+type memoryReader []Tuple
+
+func (r memoryReader) ReadTuples(ctx context.Context, object Object, relation string) ([]Tuple, error) {
+	var tuples []Tuple
+	for _, tuple := range r {
+		if tuple.Object == object && tuple.Relation == relation {
+			tuples = append(tuples, tuple)
+		}
+	}
+	return tuples, nil
+}
+
+func (r memoryReader) ListObjectIDs(ctx context.Context, namespace string) ([]string, error) {
+	seen := map[string]bool{}
+	var ids []string
+	for _, tuple := range r {
+		if tuple.Object.Namespace == namespace && !seen[tuple.Object.ID] {
+			seen[tuple.Object.ID] = true
+			ids = append(ids, tuple.Object.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func newMemoryReader(t *testing.T, tuples ...string) memoryReader {
+	reader := make(memoryReader, 0, len(tuples))
+	for _, s := range tuples {
+		tuple, err := ParseTuple(s)
+		require.NoError(t, err)
+		reader = append(reader, tuple)
+	}
+	return reader
+}
+
+func TestEngine_Check(t *testing.T) {
+	engine, err := Parse([]byte(testNamespaces))
+	require.NoError(t, err)
+
+	reader := newMemoryReader(t,
+		"document:readme#owner@user:alice",
+		"document:readme#viewer@group:eng#member",
+		"document:readme#parent@folder:docs",
+		"document:readme#banned@user:mallory",
+		"folder:docs#viewer@user:carol",
+		"group:eng#member@user:bob",
+		"group:eng#member@user:mallory",
+		"group:eng#member@group:ops#member",
+		"group:ops#member@user:dave",
+		// Cycle does not make the check loop forever
+		"group:ops#member@group:eng#member",
+	)
+
+	testCases := []struct {
+		name      string
+		object    string
+		relation  string
+		subject   string
+		isAllowed bool
+	}{
+		{
+			name:      "positive:owner is editor",
+			object:    "document:readme",
+			relation:  "editor",
+			subject:   "user:alice",
+			isAllowed: true,
+		},
+		{
+			name:      "positive:owner is viewer through editor",
+			object:    "document:readme",
+			relation:  "viewer",
+			subject:   "user:alice",
+			isAllowed: true,
+		},
+		{
+			name:      "positive:group member is viewer",
+			object:    "document:readme",
+			relation:  "viewer",
+			subject:   "user:bob",
+			isAllowed: true,
+		},
+		{
+			name:      "positive:nested group member is viewer",
+			object:    "document:readme",
+			relation:  "viewer",
+			subject:   "user:dave",
+			isAllowed: true,
+		},
+		{
+			name:      "positive:parent folder viewer is viewer",
+			object:    "document:readme",
+			relation:  "viewer",
+			subject:   "user:carol",
+			isAllowed: true,
+		},
+		{
+			name:      "positive:subject set is viewer",
+			object:    "document:readme",
+			relation:  "viewer",
+			subject:   "group:ops#member",
+			isAllowed: true,
+		},
+		{
+			name:     "negative:banned group member is not viewer",
+			object:   "document:readme",
+			relation: "viewer",
+			subject:  "user:mallory",
+		},
+		{
+			name:     "negative:group member is not editor",
+			object:   "document:readme",
+			relation: "editor",
+			subject:  "user:bob",
+		},
+		{
+			name:     "negative:unrelated user",
+			object:   "document:readme",
+			relation: "viewer",
+			subject:  "user:eve",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			object, err := ParseObject(tc.object)
+			require.NoError(t, err)
+			subject, err := ParseSubject(tc.subject)
+			require.NoError(t, err)
+
+			isAllowed, err := engine.Check(context.Background(), reader, object, tc.relation, subject)
+			require.NoError(t, err)
+			require.Equal(t, tc.isAllowed, isAllowed)
+		})
+	}
+
+	_, err = engine.Check(context.Background(), reader, Object{Namespace: "document", ID: "readme"}, "reader", Subject{Namespace: "user", ID: "bob"})
+	require.Error(t, err)
+}
+
+func TestEngine_ListObjects(t *testing.T) {
+	engine, err := Parse([]byte(testNamespaces))
+	require.NoError(t, err)
+
+	reader := newMemoryReader(t,
+		"document:b#viewer@user:bob",
+		"document:a#owner@user:bob",
+		"document:c#owner@user:alice",
+	)
+
+	ids, err := engine.ListObjects(context.Background(), reader, "document", "viewer", Subject{Namespace: "user", ID: "bob"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, ids)
+}
+
+func TestEngine_Expand(t *testing.T) {
+	engine, err := Parse([]byte(testNamespaces))
+	require.NoError(t, err)
+
+	reader := newMemoryReader(t,
+		"document:readme#owner@user:alice",
+		"document:readme#editor@group:eng#member",
+	)
+
+	tree, err := engine.Expand(context.Background(), reader, Object{Namespace: "document", ID: "readme"}, "editor")
+	require.NoError(t, err)
+	require.Equal(t, OperationUnion, tree.Operation)
+	require.Equal(t, "document:readme#editor", tree.Userset.String())
+	require.Len(t, tree.Children, 2)
+	require.Equal(t, []Subject{{Namespace: "group", ID: "eng", Relation: "member"}}, tree.Children[0].Subjects)
+	require.Equal(t, "document:readme#owner", tree.Children[1].Userset.String())
+	require.Equal(t, []Subject{{Namespace: "user", ID: "alice"}}, tree.Children[1].Subjects)
+}
+
+func TestParseTuple(t *testing.T) {
+	testCases := []struct {
+		name    string
+		tuple   string
+		isValid bool
+	}{
+		{name: "positive:direct subject", tuple: "document:readme#viewer@user:42", isValid: true},
+		{name: "positive:subject set", tuple: "document:readme#viewer@group:eng#member", isValid: true},
+		{name: "negative:no relation", tuple: "document:readme@user:42"},
+		{name: "negative:no subject", tuple: "document:readme#viewer"},
+		{name: "negative:no object id", tuple: "document#viewer@user:42"},
+		{name: "negative:empty subject relation", tuple: "document:readme#viewer@group:eng#"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tuple, err := ParseTuple(tc.tuple)
+			if tc.isValid {
+				require.NoError(t, err)
+				require.Equal(t, tc.tuple, tuple.String())
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  Config
+		isValid bool
+	}{
+		{
+			name:    "positive:no namespaces",
+			isValid: true,
+		},
+		{
+			name: "negative:duplicate namespace",
+			config: Config{Namespaces: []Namespace{
+				{Name: "user"}, {Name: "user"},
+			}},
+		},
+		{
+			name: "negative:unknown computed relation",
+			config: Config{Namespaces: []Namespace{
+				{Name: "document", Relations: []Relation{{Name: "viewer", Rewrite: &Rewrite{ComputedUserset: "editor"}}}},
+			}},
+		},
+		{
+			name: "negative:several rules",
+			config: Config{Namespaces: []Namespace{
+				{Name: "document", Relations: []Relation{
+					{Name: "owner"},
+					{Name: "viewer", Rewrite: &Rewrite{This: true, ComputedUserset: "owner"}},
+				}},
+			}},
+		},
+		{
+			name: "negative:empty union",
+			config: Config{Namespaces: []Namespace{
+				{Name: "document", Relations: []Relation{{Name: "viewer", Rewrite: &Rewrite{Union: []Rewrite{}}}}},
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(tc.config)
+			if tc.isValid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package rebac
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Object is the resource relations are defined on, written as "namespace:id", e.g. "document:readme".
+type Object struct {
+	Namespace string
+	ID        string
+}
+
+// ParseObject is used to parse the object written as "namespace:id".
+func ParseObject(s string) (Object, error) {
+	namespace, id, ok := strings.Cut(s, ":")
+	if !ok || namespace == "" || id == "" || strings.ContainsAny(id, "#@") {
+		return Object{}, fmt.Errorf("object %q has to be written as namespace:id", s)
+	}
+
+	return Object{Namespace: namespace, ID: id}, nil
+}
+
+func (o Object) String() string {
+	return o.Namespace + ":" + o.ID
+}
+
+func (o Object) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+func (o *Object) UnmarshalText(text []byte) error {
+	object, err := ParseObject(string(text))
+	if err != nil {
+		return err
+	}
+	*o = object
+	return nil
+}
+
+// Subject is the one relation is granted to. It is either a direct subject written as "namespace:id",
+// e.g. "user:42", or a subject set written as "namespace:id#relation", e.g. "group:eng#member",
+// standing for all subjects having the relation to the object.
+type Subject struct {
+	Namespace string
+	ID        string
+	// Relation is empty for direct subjects.
+	Relation string
+}
+
+// ParseSubject is used to parse the subject written as "namespace:id" or "namespace:id#relation".
+func ParseSubject(s string) (Subject, error) {
+	objectStr, relation, isSet := strings.Cut(s, "#")
+	object, err := ParseObject(objectStr)
+	if err != nil {
+		return Subject{}, fmt.Errorf("subject %q has to be written as namespace:id or namespace:id#relation", s)
+	}
+	if isSet && relation == "" {
+		return Subject{}, fmt.Errorf("subject %q has empty relation", s)
+	}
+
+	return Subject{Namespace: object.Namespace, ID: object.ID, Relation: relation}, nil
+}
+
+// Object returns the object the subject set is defined on, or the direct subject itself as an object.
+func (s Subject) Object() Object {
+	return Object{Namespace: s.Namespace, ID: s.ID}
+}
+
+// IsSet returns true if the subject is a subject set.
+func (s Subject) IsSet() bool {
+	return s.Relation != ""
+}
+
+func (s Subject) String() string {
+	if s.Relation == "" {
+		return s.Object().String()
+	}
+	return s.Object().String() + "#" + s.Relation
+}
+
+func (s Subject) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Subject) UnmarshalText(text []byte) error {
+	subject, err := ParseSubject(string(text))
+	if err != nil {
+		return err
+	}
+	*s = subject
+	return nil
+}
+
+// Tuple is the stored fact that the subject has the relation to the object,
+// written as "namespace:id#relation@subject", e.g. "document:readme#viewer@group:eng#member".
+type Tuple struct {
+	Object   Object
+	Relation string
+	Subject  Subject
+}
+
+// ParseTuple is used to parse the tuple written as "namespace:id#relation@subject".
+func ParseTuple(s string) (Tuple, error) {
+	userset, subjectStr, ok := strings.Cut(s, "@")
+	if !ok {
+		return Tuple{}, fmt.Errorf("tuple %q has to be written as namespace:id#relation@subject", s)
+	}
+	objectStr, relation, ok := strings.Cut(userset, "#")
+	if !ok || relation == "" {
+		return Tuple{}, fmt.Errorf("tuple %q has to be written as namespace:id#relation@subject", s)
+	}
+	object, err := ParseObject(objectStr)
+	if err != nil {
+		return Tuple{}, err
+	}
+	subject, err := ParseSubject(subjectStr)
+	if err != nil {
+		return Tuple{}, err
+	}
+
+	return Tuple{Object: object, Relation: relation, Subject: subject}, nil
+}
+
+func (t Tuple) String() string {
+	return t.Object.String() + "#" + t.Relation + "@" + t.Subject.String()
+}
+
+func (t Tuple) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *Tuple) UnmarshalText(text []byte) error {
+	tuple, err := ParseTuple(string(text))
+	if err != nil {
+		return err
+	}
+	*t = tuple
+	return nil
+}
@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/taraslis453/solid-software-test/pkg/logging"
+)
+
+// Job is the task run periodically.
+type Job struct {
+	// Name identifies the job in logs and locks, so it has to be unique.
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Locker is used to run the job in only one replica at a time.
+type Locker interface {
+	// RunLocked runs fn holding the lock with the key. If the lock is held by someone else,
+	// returns false without running fn.
+	RunLocked(ctx context.Context, key string, fn func(ctx context.Context) error) (bool, error)
+}
+
+// Scheduler runs every job on start and then once per job interval, until it is stopped.
+type Scheduler struct {
+	jobs   []Job
+	locker Locker
+	logger logging.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(locker Locker, logger logging.Logger, jobs ...Job) *Scheduler {
+	return &Scheduler{
+		jobs:   jobs,
+		locker: locker,
+		logger: logger.Named("scheduler"),
+	}
+}
+
+// Start starts running jobs in background.
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+}
+
+// Stop cancels context of running jobs and waits for them to return. Jobs are not run after Stop.
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	s.run(ctx, job)
+	for {
+		select {
+		case <-ticker.C:
+			// Both channels may be ready, the job is not run again once scheduler is stopped
+			if ctx.Err() != nil {
+				return
+			}
+			s.run(ctx, job)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	logger := s.logger.
+		Named("run").
+		With("job", job.Name)
+
+	startedAt := time.Now()
+	isRun, err := s.locker.RunLocked(ctx, "scheduler:"+job.Name, job.Run)
+	if err != nil {
+		if ctx.Err() != nil {
+			logger.Info("job is cancelled", "err", err)
+			return
+		}
+		logger.Error("failed to run job", "err", err)
+		return
+	}
+	if !isRun {
+		logger.Debug("job is run by another replica")
+		return
+	}
+
+	logger.Debug("successfully ran job", "duration", time.Since(startedAt))
+}
+
+// MemoryLocker locks within the process. Used with the single replica and in tests.
+type MemoryLocker struct {
+	mu     sync.Mutex
+	locked map[string]bool
+}
+
+// Check if implements the interface.
+var _ Locker = (*MemoryLocker)(nil)
+
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{
+		locked: map[string]bool{},
+	}
+}
+
+func (l *MemoryLocker) RunLocked(ctx context.Context, key string, fn func(ctx context.Context) error) (bool, error) {
+	l.mu.Lock()
+	if l.locked[key] {
+		l.mu.Unlock()
+		return false, nil
+	}
+	l.locked[key] = true
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		delete(l.locked, key)
+		l.mu.Unlock()
+	}()
+
+	return true, fn(ctx)
+}
@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/taraslis453/solid-software-test/pkg/logging"
+)
+
+func TestScheduler(t *testing.T) {
+	logger := logging.NewZapLogger("error")
+
+	var runs int32
+	scheduler := New(NewMemoryLocker(), logger, Job{
+		Name:     "count",
+		Interval: 10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		},
+	})
+	scheduler.Start()
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&runs) >= 3
+	}, time.Second, 5*time.Millisecond)
+
+	scheduler.Stop()
+	stoppedRuns := atomic.LoadInt32(&runs)
+	time.Sleep(30 * time.Millisecond)
+	require.Equal(t, stoppedRuns, atomic.LoadInt32(&runs))
+}
+
+func TestScheduler_StopCancelsRunningJob(t *testing.T) {
+	logger := logging.NewZapLogger("error")
+
+	started := make(chan struct{})
+	var isCancelled int32
+	scheduler := New(NewMemoryLocker(), logger, Job{
+		Name:     "block",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			atomic.StoreInt32(&isCancelled, 1)
+			return ctx.Err()
+		},
+	})
+	scheduler.Start()
+	<-started
+
+	scheduler.Stop()
+	require.Equal(t, int32(1), atomic.LoadInt32(&isCancelled))
+}
+
+func TestMemoryLocker(t *testing.T) {
+	locker := NewMemoryLocker()
+
+	isRun, err := locker.RunLocked(context.Background(), "job", func(ctx context.Context) error {
+		// Lock is held by the outer run
+		isRun, err := locker.RunLocked(ctx, "job", func(ctx context.Context) error {
+			return nil
+		})
+		require.NoError(t, err)
+		require.False(t, isRun)
+
+		// Other keys are not locked
+		isRun, err = locker.RunLocked(ctx, "other", func(ctx context.Context) error {
+			return nil
+		})
+		require.NoError(t, err)
+		require.True(t, isRun)
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, isRun)
+
+	// Lock is released after the run
+	isRun, err = locker.RunLocked(context.Background(), "job", func(ctx context.Context) error {
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, isRun)
+}
@@ -0,0 +1,53 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileSender appends every message as a JSON line to the file instead of sending it.
+// Used for local development to read sent codes.
+type fileSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Check if implements the interface.
+var _ Sender = (*fileSender)(nil)
+
+func NewFileSender(path string) *fileSender {
+	return &fileSender{path: path}
+}
+
+type fileMessage struct {
+	SentAt time.Time `json:"sentAt"`
+	To     string    `json:"to"`
+	Body   string    `json:"body"`
+}
+
+func (s *fileSender) Send(ctx context.Context, message *Message) error {
+	line, err := json.Marshal(fileMessage{SentAt: time.Now(), To: message.To, Body: message.Body})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open outbox file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package sms
+
+import (
+	"context"
+
+	"github.com/taraslis453/solid-software-test/pkg/logging"
+)
+
+// logSender writes messages to the log instead of sending them. Used for local development.
+type logSender struct {
+	logger logging.Logger
+}
+
+// Check if implements the interface.
+var _ Sender = (*logSender)(nil)
+
+func NewLogSender(l logging.Logger) *logSender {
+	return &logSender{
+		logger: l.Named("logSender"),
+	}
+}
+
+func (s *logSender) Send(ctx context.Context, message *Message) error {
+	s.logger.
+		WithContext(ctx).
+		Info("sms sent", "to", message.To, "body", message.Body)
+	return nil
+}
@@ -0,0 +1,48 @@
+package sms
+
+import (
+	"context"
+	"sync"
+)
+
+// MemorySender keeps sent messages in memory. Used in tests to read sent codes.
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// Check if implements the interface.
+var _ Sender = (*MemorySender)(nil)
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) Send(ctx context.Context, message *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, *message)
+	return nil
+}
+
+// Messages returns all sent messages in the sending order.
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+// LastMessage returns the last message sent to the phone number.
+func (s *MemorySender) LastMessage(to string) (Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if s.messages[i].To == to {
+			return s.messages[i], true
+		}
+	}
+	return Message{}, false
+}
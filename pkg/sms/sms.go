@@ -0,0 +1,15 @@
+package sms
+
+import "context"
+
+// Sender provides logic for sending SMS messages.
+type Sender interface {
+	// Send is used to send a text message to the phone number.
+	Send(ctx context.Context, message *Message) error
+}
+
+type Message struct {
+	// To is the phone number in E.164 format.
+	To   string
+	Body string
+}
@@ -0,0 +1,40 @@
+package sms
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemorySender(t *testing.T) {
+	sender := NewMemorySender()
+	require.NoError(t, sender.Send(context.Background(), &Message{To: "+380501112233", Body: "first"}))
+	require.NoError(t, sender.Send(context.Background(), &Message{To: "+380504445566", Body: "other"}))
+	require.NoError(t, sender.Send(context.Background(), &Message{To: "+380501112233", Body: "second"}))
+
+	require.Len(t, sender.Messages(), 3, "unexpected number of messages")
+
+	message, ok := sender.LastMessage("+380501112233")
+	require.True(t, ok, "message not found")
+	require.Equal(t, "second", message.Body, "unexpected last message")
+
+	_, ok = sender.LastMessage("+380000000000")
+	require.False(t, ok, "message to unknown number found")
+}
+
+func TestFileSender(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sms.log")
+	sender := NewFileSender(path)
+	require.NoError(t, sender.Send(context.Background(), &Message{To: "+380501112233", Body: "first"}))
+	require.NoError(t, sender.Send(context.Background(), &Message{To: "+380501112233", Body: "second"}))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err, "failed to read outbox file")
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2, "unexpected number of messages")
+	require.True(t, strings.Contains(lines[1], `"body":"second"`), "unexpected message: %s", lines[1])
+}
@@ -23,6 +23,38 @@ type UniversalClaims struct {
 type UserDataClaims struct {
 	// UserID is the ID of the token owner.
 	UserID string `json:"userId"`
+	// TenantID is the ID of the organization token owner belongs to.
+	TenantID string `json:"tenantId"`
+	// SessionID is the ID of the session token was issued within.
+	SessionID string `json:"sessionId"`
+	// AuthTime is the UNIX time when the user authenticated within the session last time.
+	AuthTime int64 `json:"authTime"`
+	// AMR is the list of methods used to authenticate the user (e.g. "pwd", "otp").
+	AMR []string `json:"amr"`
+	// Groups are names of groups user is an effective member of when the token is issued, set only if enabled.
+	Groups []string `json:"groups,omitempty"`
+}
+
+// PurposeClaims is the payload of single-purpose tokens (e.g. MFA challenge). Purpose prevents
+// using the token for anything else than it was issued for.
+type PurposeClaims struct {
+	// Purpose is the action token was issued for.
+	Purpose string `json:"purpose"`
+	// UserID is the ID of the token owner.
+	UserID string `json:"userId"`
+	// Email is the email address token was sent to, set for tokens which prove the email ownership.
+	Email string `json:"email,omitempty"`
+	// TokenID is the unique token ID, set for tokens which can be used only once.
+	TokenID string `json:"tokenId,omitempty"`
+	// Challenge is the base64url encoded WebAuthn ceremony challenge.
+	Challenge string `json:"challenge,omitempty"`
+	// AMR is the list of methods user has been already authenticated with, e.g. first factor of MFA login.
+	AMR []string `json:"amr,omitempty"`
+	// TenantID is the ID of the organization token is issued in: the organization of the token owner, or the inviting
+	// organization for organization invitations. Token is consumed within this organization.
+	TenantID string `json:"tenantId,omitempty"`
+	// RoleID is the ID of the role granted with organization invitation.
+	RoleID string `json:"roleId,omitempty"`
 }
 
 func (claims UniversalClaims) GetIssuer() string {
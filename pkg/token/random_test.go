@@ -0,0 +1,29 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateRandomToken(t *testing.T) {
+	first, err := GenerateRandomToken(32)
+	require.NoError(t, err, "failed to generate token")
+	second, err := GenerateRandomToken(32)
+	require.NoError(t, err, "failed to generate token")
+
+	require.Len(t, first, 43, "unexpected token length")
+	require.NotEqual(t, first, second, "tokens are equal")
+}
+
+func TestGenerateNumericCode(t *testing.T) {
+	code, err := GenerateNumericCode(6)
+	require.NoError(t, err, "failed to generate code")
+	require.Regexp(t, `^[0-9]{6}$`, code, "unexpected code format")
+}
+
+func TestHashToken(t *testing.T) {
+	require.Equal(t, HashToken("token"), HashToken("token"), "hashes of equal tokens mismatched")
+	require.NotEqual(t, HashToken("token"), HashToken("other"), "hashes of different tokens are equal")
+	require.Len(t, HashToken("token"), 64, "unexpected hash length")
+}
@@ -0,0 +1,134 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	// Digits is the number of digits in the generated code.
+	Digits = 6
+	// Period is the time step of the code.
+	Period = 30 * time.Second
+
+	// secretLength is the number of random bytes in the secret as recommended by RFC 4226.
+	secretLength = 20
+)
+
+var base32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret generates a new random base32 encoded secret.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretLength)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+
+	return base32Encoding.EncodeToString(secret), nil
+}
+
+// GenerateCode generates the code for the secret at the given time as described in RFC 6238.
+func GenerateCode(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return generateCode(key, timeStep(t)), nil
+}
+
+// Validate checks the code allowing skew time steps of clock drift in both directions
+// and returns the time step the code matched. The step can be used to reject replayed codes.
+func Validate(secret, code string, t time.Time, skew int) (int64, bool, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(code) != Digits {
+		return 0, false, nil
+	}
+
+	step := timeStep(t)
+	for i := -int64(skew); i <= int64(skew); i++ {
+		expected := generateCode(key, step+i)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step + i, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+type URIOptions struct {
+	Issuer      string
+	AccountName string
+	Secret      string
+}
+
+// URI returns the otpauth:// key URI used by authenticator apps.
+func URI(opts *URIOptions) string {
+	label := url.PathEscape(opts.Issuer) + ":" + url.PathEscape(opts.AccountName)
+
+	query := url.Values{}
+	query.Set("secret", opts.Secret)
+	query.Set("issuer", opts.Issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// QRCodePNG encodes the key URI into PNG image of QR code with given size in pixels.
+func QRCodePNG(uri string, size int) ([]byte, error) {
+	png, err := qrcode.Encode(uri, qrcode.Medium, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode qr code: %w", err)
+	}
+
+	return png, nil
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	key, err := base32Encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret: %w", err)
+	}
+	return key, nil
+}
+
+func timeStep(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// generateCode generates HOTP code as described in RFC 4226.
+func generateCode(key []byte, counter int64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < Digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", Digits, value%modulo)
+}
@@ -0,0 +1,72 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCode(t *testing.T) {
+	// test vectors from RFC 6238 truncated to 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	testCases := []struct {
+		name         string
+		time         time.Time
+		expectedCode string
+	}{
+		{name: "positive:59", time: time.Unix(59, 0), expectedCode: "287082"},
+		{name: "positive:1111111109", time: time.Unix(1111111109, 0), expectedCode: "081804"},
+		{name: "positive:1234567890", time: time.Unix(1234567890, 0), expectedCode: "005924"},
+		{name: "positive:2000000000", time: time.Unix(2000000000, 0), expectedCode: "279037"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, err := GenerateCode(secret, tc.time)
+			require.NoError(t, err, "failed to generate code")
+			require.Equal(t, tc.expectedCode, code)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err, "failed to generate secret")
+
+	now := time.Now()
+	code, err := GenerateCode(secret, now.Add(-Period))
+	require.NoError(t, err, "failed to generate code")
+
+	step, ok, err := Validate(secret, code, now, 1)
+	require.NoError(t, err, "failed to validate code")
+	require.True(t, ok, "code is not valid within skew")
+	require.Equal(t, now.Unix()/30-1, step)
+
+	_, ok, err = Validate(secret, code, now.Add(2*Period), 1)
+	require.NoError(t, err, "failed to validate code")
+	require.False(t, ok, "code is valid outside of skew")
+
+	_, ok, err = Validate(secret, "12345", now, 1)
+	require.NoError(t, err, "failed to validate code")
+	require.False(t, ok, "code with wrong length is valid")
+}
+
+func TestURI(t *testing.T) {
+	uri := URI(&URIOptions{
+		Issuer:      "API",
+		AccountName: "john.doe@example.com",
+		Secret:      "JBSWY3DPEHPK3PXP",
+	})
+
+	require.True(t, strings.HasPrefix(uri, "otpauth://totp/API:john.doe@example.com?"), "unexpected uri: %s", uri)
+	require.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	require.Contains(t, uri, "issuer=API")
+
+	png, err := QRCodePNG(uri, 256)
+	require.NoError(t, err, "failed to encode qr code")
+	require.True(t, strings.HasPrefix(string(png), "\x89PNG"), "qr code is not png")
+}
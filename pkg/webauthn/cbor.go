@@ -0,0 +1,193 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxCBORDepth limits nesting of decoded CBOR items.
+const maxCBORDepth = 16
+
+// CBOR major types.
+const (
+	cborUnsignedInt = 0
+	cborNegativeInt = 1
+	cborByteString  = 2
+	cborTextString  = 3
+	cborArray       = 4
+	cborMap         = 5
+	cborTag         = 6
+	cborSimple      = 7
+)
+
+var errCBORUnexpectedEnd = errors.New("unexpected end of cbor data")
+
+// decodeCBOR decodes a single CBOR data item and returns it with the number of consumed bytes.
+// It supports the subset of CBOR used by WebAuthn: integers are decoded into int64, byte strings into []byte,
+// text strings into string, arrays into []interface{} and maps into map[interface{}]interface{}.
+func decodeCBOR(data []byte) (interface{}, int, error) {
+	d := &cborDecoder{data: data}
+	value, err := d.decode(0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, d.pos, nil
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+	// headPos is the position of the initial byte of the item being decoded.
+	headPos int
+}
+
+func (d *cborDecoder) decode(depth int) (interface{}, error) {
+	if depth > maxCBORDepth {
+		return nil, errors.New("cbor data is nested too deep")
+	}
+
+	major, info, err := d.readHead()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUnsignedInt:
+		if info > math.MaxInt64 {
+			return nil, errors.New("cbor integer overflows int64")
+		}
+		return int64(info), nil
+	case cborNegativeInt:
+		if info > math.MaxInt64 {
+			return nil, errors.New("cbor integer overflows int64")
+		}
+		return -1 - int64(info), nil
+	case cborByteString:
+		b, err := d.readBytes(info)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case cborTextString:
+		b, err := d.readBytes(info)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case cborArray:
+		if info > uint64(len(d.data)-d.pos) {
+			return nil, errCBORUnexpectedEnd
+		}
+		array := make([]interface{}, 0, info)
+		for i := uint64(0); i < info; i++ {
+			item, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, item)
+		}
+		return array, nil
+	case cborMap:
+		if info > uint64(len(d.data)-d.pos) {
+			return nil, errCBORUnexpectedEnd
+		}
+		m := make(map[interface{}]interface{}, info)
+		for i := uint64(0); i < info; i++ {
+			key, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, fmt.Errorf("unsupported cbor map key type %T", key)
+			}
+			value, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+		}
+		return m, nil
+	case cborTag:
+		// tags carry no meaning for WebAuthn, so only the tagged item is returned
+		return d.decode(depth + 1)
+	case cborSimple:
+		return d.decodeSimple(info)
+	default:
+		return nil, fmt.Errorf("unsupported cbor major type %d", major)
+	}
+}
+
+func (d *cborDecoder) decodeSimple(info uint64) (interface{}, error) {
+	// the initial byte additional info is needed to distinguish simple values from floats
+	additionalInfo := d.data[d.headPos] & 0x1f
+	switch additionalInfo {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 26:
+		return float64(math.Float32frombits(uint32(info))), nil
+	case 27:
+		return math.Float64frombits(info), nil
+	default:
+		return nil, fmt.Errorf("unsupported cbor simple value %d", additionalInfo)
+	}
+}
+
+// readHead reads the initial byte with the argument and returns major type and the argument value.
+func (d *cborDecoder) readHead() (byte, uint64, error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, errCBORUnexpectedEnd
+	}
+	d.headPos = d.pos
+	initial := d.data[d.pos]
+	d.pos++
+
+	major := initial >> 5
+	info := uint64(initial & 0x1f)
+	switch {
+	case info < 24:
+		return major, info, nil
+	case info == 24:
+		b, err := d.readBytes(1)
+		if err != nil {
+			return 0, 0, err
+		}
+		return major, uint64(b[0]), nil
+	case info == 25:
+		b, err := d.readBytes(2)
+		if err != nil {
+			return 0, 0, err
+		}
+		return major, uint64(binary.BigEndian.Uint16(b)), nil
+	case info == 26:
+		b, err := d.readBytes(4)
+		if err != nil {
+			return 0, 0, err
+		}
+		return major, uint64(binary.BigEndian.Uint32(b)), nil
+	case info == 27:
+		b, err := d.readBytes(8)
+		if err != nil {
+			return 0, 0, err
+		}
+		return major, binary.BigEndian.Uint64(b), nil
+	default:
+		return 0, 0, fmt.Errorf("unsupported cbor additional info %d", info)
+	}
+}
+
+func (d *cborDecoder) readBytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, errCBORUnexpectedEnd
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
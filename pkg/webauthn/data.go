@@ -0,0 +1,143 @@
+package webauthn
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Authenticator data flags.
+const (
+	flagUserPresent        = 0x01
+	flagUserVerified       = 0x04
+	flagAttestedCredential = 0x40
+)
+
+// authenticatorDataMinLength is the length of RP ID hash, flags and sign counter.
+const authenticatorDataMinLength = 37
+
+// Client data types.
+const (
+	clientDataTypeCreate = "webauthn.create"
+	clientDataTypeGet    = "webauthn.get"
+)
+
+// URLEncodedBytes is a byte slice encoded to JSON as unpadded base64url string, the way WebAuthn
+// clients serialize binary values.
+type URLEncodedBytes []byte
+
+func (b URLEncodedBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *URLEncodedBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := decodeBase64URL(s)
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// decodeBase64URL decodes base64url string with or without padding.
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// authenticatorData is the parsed authenticator data structure.
+type authenticatorData struct {
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+	// the fields below are present only if attested credential data flag is set
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < authenticatorDataMinLength {
+		return nil, errors.New("authenticator data is too short")
+	}
+
+	authData := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if authData.flags&flagAttestedCredential == 0 {
+		return authData, nil
+	}
+
+	rest := data[authenticatorDataMinLength:]
+	if len(rest) < 18 {
+		return nil, errors.New("attested credential data is too short")
+	}
+	authData.aaguid = rest[:16]
+	credentialIDLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < credentialIDLength {
+		return nil, errors.New("credential id is too short")
+	}
+	authData.credentialID = rest[:credentialIDLength]
+	rest = rest[credentialIDLength:]
+
+	// public key length is known only after decoding, extensions may follow it
+	_, n, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode credential public key: %w", err)
+	}
+	authData.publicKey = rest[:n]
+
+	return authData, nil
+}
+
+func (d *authenticatorData) isUserPresent() bool {
+	return d.flags&flagUserPresent != 0
+}
+
+func (d *authenticatorData) isUserVerified() bool {
+	return d.flags&flagUserVerified != 0
+}
+
+// collectedClientData is the client data JSON signed by authenticator.
+type collectedClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin,omitempty"`
+}
+
+// attestationObject is the CBOR encoded result of the registration ceremony.
+type attestationObject struct {
+	format   string
+	authData []byte
+}
+
+func parseAttestationObject(data []byte) (*attestationObject, error) {
+	value, _, err := decodeCBOR(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode attestation object: %w", err)
+	}
+	m, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("attestation object is not a map")
+	}
+
+	format, _ := m["fmt"].(string)
+	authData, _ := m["authData"].([]byte)
+	if format == "" || authData == nil {
+		return nil, errors.New("attestation object is missing required fields")
+	}
+
+	return &attestationObject{format: format, authData: authData}, nil
+}
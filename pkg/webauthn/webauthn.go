@@ -0,0 +1,357 @@
+// Package webauthn implements the relying party side of WebAuthn registration and authentication ceremonies.
+// Only "none" attestation conveyance is used, so authenticators are trusted on first use
+// and attestation statements are not verified.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// challengeLength is the length of generated challenges in bytes.
+const challengeLength = 32
+
+// publicKeyCredentialType is the only credential type defined by WebAuthn.
+const publicKeyCredentialType = "public-key"
+
+// User verification requirements.
+const (
+	UserVerificationRequired  = "required"
+	UserVerificationPreferred = "preferred"
+)
+
+// ErrInvalidResponse is returned when authenticator response fails verification.
+// All verification errors wrap it, so callers can tell them from internal errors.
+var ErrInvalidResponse = errors.New("invalid webauthn response")
+
+type Config struct {
+	// RPID is the relying party identifier, usually the domain name of the site.
+	RPID string
+	// RPName is the human-palatable relying party name shown by authenticators.
+	RPName string
+	// Origins are the allowed origins of the client data (e.g. "https://example.com").
+	Origins []string
+	// Timeout is the time the client is allowed to spend on the ceremony.
+	Timeout time.Duration
+	// RequireUserVerification makes authenticators verify the user (PIN, biometrics) instead of only testing presence.
+	RequireUserVerification bool
+}
+
+// RelyingParty provides logic for starting and verifying WebAuthn ceremonies.
+type RelyingParty struct {
+	config Config
+	// rpIDHash is the SHA-256 hash of RP ID authenticators put into authenticator data.
+	rpIDHash []byte
+}
+
+func New(config Config) (*RelyingParty, error) {
+	if config.RPID == "" {
+		return nil, errors.New("relying party id is required")
+	}
+	if len(config.Origins) == 0 {
+		return nil, errors.New("at least one origin is required")
+	}
+	if config.RPName == "" {
+		config.RPName = config.RPID
+	}
+
+	rpIDHash := sha256.Sum256([]byte(config.RPID))
+	return &RelyingParty{config: config, rpIDHash: rpIDHash[:]}, nil
+}
+
+// Credential is the registered public key credential which has to be stored by the relying party.
+type Credential struct {
+	// ID is the credential ID generated by authenticator.
+	ID []byte
+	// PublicKey is the COSE encoded credential public key.
+	PublicKey []byte
+	// SignCount is the signature counter reported by authenticator.
+	SignCount uint32
+	// AAGUID identifies the authenticator model.
+	AAGUID []byte
+}
+
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	// ID is the user handle, opaque byte sequence of at most 64 bytes.
+	ID          URLEncodedBytes `json:"id"`
+	Name        string          `json:"name"`
+	DisplayName string          `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type      string `json:"type"`
+	Algorithm int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type string          `json:"type"`
+	ID   URLEncodedBytes `json:"id"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey,omitempty"`
+	UserVerification string `json:"userVerification,omitempty"`
+}
+
+// CreationOptions are the options passed to navigator.credentials.create() as "publicKey".
+type CreationOptions struct {
+	Challenge              URLEncodedBytes        `json:"challenge"`
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout,omitempty"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are the options passed to navigator.credentials.get() as "publicKey".
+type RequestOptions struct {
+	Challenge        URLEncodedBytes        `json:"challenge"`
+	Timeout          int64                  `json:"timeout,omitempty"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                 `json:"userVerification,omitempty"`
+}
+
+// AttestationResponse is the JSON serialized PublicKeyCredential returned by navigator.credentials.create().
+type AttestationResponse struct {
+	ID       string                               `json:"id"`
+	RawID    URLEncodedBytes                      `json:"rawId"`
+	Type     string                               `json:"type"`
+	Response AuthenticatorAttestationResponseData `json:"response"`
+}
+
+type AuthenticatorAttestationResponseData struct {
+	ClientDataJSON    URLEncodedBytes `json:"clientDataJSON"`
+	AttestationObject URLEncodedBytes `json:"attestationObject"`
+}
+
+// AssertionResponse is the JSON serialized PublicKeyCredential returned by navigator.credentials.get().
+type AssertionResponse struct {
+	ID       string                             `json:"id"`
+	RawID    URLEncodedBytes                    `json:"rawId"`
+	Type     string                             `json:"type"`
+	Response AuthenticatorAssertionResponseData `json:"response"`
+}
+
+type AuthenticatorAssertionResponseData struct {
+	ClientDataJSON    URLEncodedBytes `json:"clientDataJSON"`
+	AuthenticatorData URLEncodedBytes `json:"authenticatorData"`
+	Signature         URLEncodedBytes `json:"signature"`
+	// UserHandle is the user ID set on registration, returned for discoverable credentials.
+	UserHandle URLEncodedBytes `json:"userHandle,omitempty"`
+}
+
+// BeginRegistration returns options for creating a new credential for the user. Credentials from exclude list
+// are not allowed to be registered again on the same authenticator.
+func (rp *RelyingParty) BeginRegistration(user UserEntity, exclude [][]byte) (*CreationOptions, error) {
+	challenge, err := generateChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]CredentialParameter, 0, len(supportedAlgorithms))
+	for _, alg := range supportedAlgorithms {
+		params = append(params, CredentialParameter{Type: publicKeyCredentialType, Algorithm: alg})
+	}
+
+	return &CreationOptions{
+		Challenge:          challenge,
+		RP:                 RelyingPartyEntity{ID: rp.config.RPID, Name: rp.config.RPName},
+		User:               user,
+		PubKeyCredParams:   params,
+		Timeout:            rp.config.Timeout.Milliseconds(),
+		ExcludeCredentials: toCredentialDescriptors(exclude),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: rp.userVerification(),
+		},
+		Attestation: "none",
+	}, nil
+}
+
+// FinishRegistration verifies the authenticator response to the creation options with the given challenge
+// and returns the credential to store.
+func (rp *RelyingParty) FinishRegistration(challenge []byte, response *AttestationResponse) (*Credential, error) {
+	if response.Type != publicKeyCredentialType {
+		return nil, fmt.Errorf("%w: unexpected credential type %q", ErrInvalidResponse, response.Type)
+	}
+
+	err := rp.verifyClientData(response.Response.ClientDataJSON, clientDataTypeCreate, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	attestation, err := parseAttestationObject(response.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidResponse, err)
+	}
+	authData, err := parseAuthenticatorData(attestation.authData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidResponse, err)
+	}
+	err = rp.verifyAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.credentialID == nil {
+		return nil, fmt.Errorf("%w: attested credential data is missing", ErrInvalidResponse)
+	}
+	if !bytes.Equal(authData.credentialID, response.RawID) {
+		return nil, fmt.Errorf("%w: credential id mismatch", ErrInvalidResponse)
+	}
+
+	_, err = parsePublicKey(authData.publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidResponse, err)
+	}
+
+	return &Credential{
+		ID:        append([]byte(nil), authData.credentialID...),
+		PublicKey: append([]byte(nil), authData.publicKey...),
+		SignCount: authData.signCount,
+		AAGUID:    append([]byte(nil), authData.aaguid...),
+	}, nil
+}
+
+// BeginLogin returns options for getting an assertion. If allow list is empty, authenticator
+// is asked for a discoverable credential.
+func (rp *RelyingParty) BeginLogin(allow [][]byte) (*RequestOptions, error) {
+	challenge, err := generateChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.config.Timeout.Milliseconds(),
+		RPID:             rp.config.RPID,
+		AllowCredentials: toCredentialDescriptors(allow),
+		UserVerification: rp.userVerification(),
+	}, nil
+}
+
+// FinishLogin verifies the assertion made with the stored credential for the request options with the given
+// challenge and returns the new sign counter value to store.
+func (rp *RelyingParty) FinishLogin(challenge []byte, credential *Credential, response *AssertionResponse) (uint32, error) {
+	if response.Type != publicKeyCredentialType {
+		return 0, fmt.Errorf("%w: unexpected credential type %q", ErrInvalidResponse, response.Type)
+	}
+	if !bytes.Equal(credential.ID, response.RawID) {
+		return 0, fmt.Errorf("%w: credential id mismatch", ErrInvalidResponse)
+	}
+
+	err := rp.verifyClientData(response.Response.ClientDataJSON, clientDataTypeGet, challenge)
+	if err != nil {
+		return 0, err
+	}
+
+	authData, err := parseAuthenticatorData(response.Response.AuthenticatorData)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidResponse, err)
+	}
+	err = rp.verifyAuthenticatorData(authData)
+	if err != nil {
+		return 0, err
+	}
+
+	key, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse stored public key: %w", err)
+	}
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+	signedData := append(append([]byte(nil), response.Response.AuthenticatorData...), clientDataHash[:]...)
+	err = key.verify(signedData, response.Response.Signature)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidResponse, err)
+	}
+
+	// authenticators without counter always report zero, otherwise counter must grow
+	// and not growing counter means the authenticator may have been cloned
+	if (authData.signCount != 0 || credential.SignCount != 0) && authData.signCount <= credential.SignCount {
+		return 0, fmt.Errorf("%w: sign counter did not increase", ErrInvalidResponse)
+	}
+
+	return authData.signCount, nil
+}
+
+func (rp *RelyingParty) verifyClientData(data []byte, expectedType string, challenge []byte) error {
+	var clientData collectedClientData
+	err := json.Unmarshal(data, &clientData)
+	if err != nil {
+		return fmt.Errorf("%w: failed to parse client data: %s", ErrInvalidResponse, err)
+	}
+
+	if clientData.Type != expectedType {
+		return fmt.Errorf("%w: unexpected client data type %q", ErrInvalidResponse, clientData.Type)
+	}
+	receivedChallenge, err := decodeBase64URL(clientData.Challenge)
+	if err != nil || !bytes.Equal(receivedChallenge, challenge) {
+		return fmt.Errorf("%w: challenge mismatch", ErrInvalidResponse)
+	}
+	if !rp.isOriginAllowed(clientData.Origin) {
+		return fmt.Errorf("%w: origin %q is not allowed", ErrInvalidResponse, clientData.Origin)
+	}
+	if clientData.CrossOrigin {
+		return fmt.Errorf("%w: cross-origin ceremonies are not allowed", ErrInvalidResponse)
+	}
+
+	return nil
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(authData *authenticatorData) error {
+	if !bytes.Equal(authData.rpIDHash, rp.rpIDHash) {
+		return fmt.Errorf("%w: relying party id hash mismatch", ErrInvalidResponse)
+	}
+	if !authData.isUserPresent() {
+		return fmt.Errorf("%w: user is not present", ErrInvalidResponse)
+	}
+	if rp.config.RequireUserVerification && !authData.isUserVerified() {
+		return fmt.Errorf("%w: user is not verified", ErrInvalidResponse)
+	}
+	return nil
+}
+
+func (rp *RelyingParty) isOriginAllowed(origin string) bool {
+	for _, allowed := range rp.config.Origins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (rp *RelyingParty) userVerification() string {
+	if rp.config.RequireUserVerification {
+		return UserVerificationRequired
+	}
+	return UserVerificationPreferred
+}
+
+func toCredentialDescriptors(ids [][]byte) []CredentialDescriptor {
+	descriptors := make([]CredentialDescriptor, 0, len(ids))
+	for _, id := range ids {
+		descriptors = append(descriptors, CredentialDescriptor{Type: publicKeyCredentialType, ID: id})
+	}
+	return descriptors
+}
+
+func generateChallenge() ([]byte, error) {
+	challenge := make([]byte, challengeLength)
+	_, err := rand.Read(challenge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	return challenge, nil
+}
@@ -0,0 +1,123 @@
+package webauthn_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/taraslis453/solid-software-test/pkg/webauthn"
+	"github.com/taraslis453/solid-software-test/pkg/webauthn/webauthntest"
+)
+
+const testOrigin = "https://example.com"
+
+func newTestRelyingParty(t *testing.T) *webauthn.RelyingParty {
+	rp, err := webauthn.New(webauthn.Config{RPID: "example.com", RPName: "Example", Origins: []string{testOrigin}})
+	require.NoError(t, err, "failed to create relying party")
+	return rp
+}
+
+// register registers the authenticator credential and returns it.
+func register(t *testing.T, rp *webauthn.RelyingParty, authenticator *webauthntest.Authenticator) *webauthn.Credential {
+	options, err := rp.BeginRegistration(webauthn.UserEntity{ID: []byte("user-id"), Name: "user"}, nil)
+	require.NoError(t, err, "failed to begin registration")
+	response, err := authenticator.Create(options)
+	require.NoError(t, err, "failed to create credential")
+	credential, err := rp.FinishRegistration(options.Challenge, response)
+	require.NoError(t, err, "failed to finish registration")
+	return credential
+}
+
+func TestRegistration(t *testing.T) {
+	rp := newTestRelyingParty(t)
+
+	t.Run("positive", func(t *testing.T) {
+		authenticator := webauthntest.NewAuthenticator(testOrigin)
+		credential := register(t, rp, authenticator)
+		require.Equal(t, authenticator.CredentialID(), credential.ID, "unexpected credential id")
+		require.NotEmpty(t, credential.PublicKey, "public key is empty")
+	})
+
+	t.Run("negative:challenge mismatch", func(t *testing.T) {
+		options, err := rp.BeginRegistration(webauthn.UserEntity{ID: []byte("user-id"), Name: "user"}, nil)
+		require.NoError(t, err, "failed to begin registration")
+		response, err := webauthntest.NewAuthenticator(testOrigin).Create(options)
+		require.NoError(t, err, "failed to create credential")
+
+		_, err = rp.FinishRegistration([]byte("other challenge"), response)
+		require.True(t, errors.Is(err, webauthn.ErrInvalidResponse), "unexpected error: %v", err)
+	})
+
+	t.Run("negative:origin not allowed", func(t *testing.T) {
+		options, err := rp.BeginRegistration(webauthn.UserEntity{ID: []byte("user-id"), Name: "user"}, nil)
+		require.NoError(t, err, "failed to begin registration")
+		response, err := webauthntest.NewAuthenticator("https://evil.com").Create(options)
+		require.NoError(t, err, "failed to create credential")
+
+		_, err = rp.FinishRegistration(options.Challenge, response)
+		require.True(t, errors.Is(err, webauthn.ErrInvalidResponse), "unexpected error: %v", err)
+	})
+}
+
+func TestLogin(t *testing.T) {
+	rp := newTestRelyingParty(t)
+
+	t.Run("positive", func(t *testing.T) {
+		authenticator := webauthntest.NewAuthenticator(testOrigin)
+		credential := register(t, rp, authenticator)
+
+		for i := 0; i < 2; i++ {
+			options, err := rp.BeginLogin([][]byte{credential.ID})
+			require.NoError(t, err, "failed to begin login")
+			response, err := authenticator.Get(options)
+			require.NoError(t, err, "failed to get assertion")
+			signCount, err := rp.FinishLogin(options.Challenge, credential, response)
+			require.NoError(t, err, "failed to finish login")
+			require.Equal(t, authenticator.SignCount, signCount, "unexpected sign count")
+			credential.SignCount = signCount
+		}
+	})
+
+	t.Run("negative:challenge mismatch", func(t *testing.T) {
+		authenticator := webauthntest.NewAuthenticator(testOrigin)
+		credential := register(t, rp, authenticator)
+
+		options, err := rp.BeginLogin(nil)
+		require.NoError(t, err, "failed to begin login")
+		response, err := authenticator.Get(options)
+		require.NoError(t, err, "failed to get assertion")
+
+		_, err = rp.FinishLogin([]byte("other challenge"), credential, response)
+		require.True(t, errors.Is(err, webauthn.ErrInvalidResponse), "unexpected error: %v", err)
+	})
+
+	t.Run("negative:invalid signature", func(t *testing.T) {
+		authenticator := webauthntest.NewAuthenticator(testOrigin)
+		credential := register(t, rp, authenticator)
+		// key of other authenticator does not match the signature
+		credential.PublicKey = register(t, rp, webauthntest.NewAuthenticator(testOrigin)).PublicKey
+
+		options, err := rp.BeginLogin(nil)
+		require.NoError(t, err, "failed to begin login")
+		response, err := authenticator.Get(options)
+		require.NoError(t, err, "failed to get assertion")
+
+		_, err = rp.FinishLogin(options.Challenge, credential, response)
+		require.True(t, errors.Is(err, webauthn.ErrInvalidResponse), "unexpected error: %v", err)
+	})
+
+	t.Run("negative:sign count did not increase", func(t *testing.T) {
+		authenticator := webauthntest.NewAuthenticator(testOrigin)
+		credential := register(t, rp, authenticator)
+		credential.SignCount = 10
+
+		options, err := rp.BeginLogin(nil)
+		require.NoError(t, err, "failed to begin login")
+		response, err := authenticator.Get(options)
+		require.NoError(t, err, "failed to get assertion")
+
+		_, err = rp.FinishLogin(options.Challenge, credential, response)
+		require.True(t, errors.Is(err, webauthn.ErrInvalidResponse), "unexpected error: %v", err)
+	})
+}
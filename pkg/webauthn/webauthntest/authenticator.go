@@ -0,0 +1,175 @@
+// Package webauthntest provides a software authenticator for testing WebAuthn ceremonies end-to-end.
+package webauthntest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/taraslis453/solid-software-test/pkg/webauthn"
+)
+
+// Authenticator is a software authenticator with a single ES256 credential. It behaves like a platform
+// authenticator which always tests user presence and verifies the user.
+type Authenticator struct {
+	// Origin is the origin put into client data, as the browser would do.
+	Origin string
+	// SignCount is the signature counter, incremented on every assertion.
+	SignCount uint32
+
+	rpID         string
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+}
+
+func NewAuthenticator(origin string) *Authenticator {
+	return &Authenticator{Origin: origin}
+}
+
+// CredentialID returns the ID of created credential.
+func (a *Authenticator) CredentialID() []byte {
+	return a.credentialID
+}
+
+// Create creates a new credential for the options, replacing the existing one.
+func (a *Authenticator) Create(options *webauthn.CreationOptions) (*webauthn.AttestationResponse, error) {
+	for _, excluded := range options.ExcludeCredentials {
+		if a.credentialID != nil && bytes.Equal(excluded.ID, a.credentialID) && a.rpID == options.RP.ID {
+			return nil, errors.New("credential is already registered")
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate credential id: %w", err)
+	}
+
+	a.rpID = options.RP.ID
+	a.key = key
+	a.credentialID = credentialID
+	a.userHandle = options.User.ID
+	a.SignCount = 0
+
+	clientDataJSON, err := a.clientDataJSON("webauthn.create", options.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	attestedCredentialData := make([]byte, 16) // zero AAGUID
+	attestedCredentialData = binary.BigEndian.AppendUint16(attestedCredentialData, uint16(len(credentialID)))
+	attestedCredentialData = append(attestedCredentialData, credentialID...)
+	attestedCredentialData = append(attestedCredentialData, a.publicKeyCOSE()...)
+	// user present, user verified, attested credential data included
+	authData := append(a.authenticatorData(0x01|0x04|0x40), attestedCredentialData...)
+
+	attestationObject := encodeCBOR([]mapEntry{
+		{key: "fmt", value: "none"},
+		{key: "attStmt", value: []mapEntry{}},
+		{key: "authData", value: authData},
+	})
+
+	return &webauthn.AttestationResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(credentialID),
+		RawID: credentialID,
+		Type:  "public-key",
+		Response: webauthn.AuthenticatorAttestationResponseData{
+			ClientDataJSON:    clientDataJSON,
+			AttestationObject: attestationObject,
+		},
+	}, nil
+}
+
+// Get makes an assertion with the existing credential for the options.
+func (a *Authenticator) Get(options *webauthn.RequestOptions) (*webauthn.AssertionResponse, error) {
+	if a.credentialID == nil || options.RPID != a.rpID {
+		return nil, errors.New("no credential for relying party")
+	}
+	if len(options.AllowCredentials) > 0 {
+		allowed := false
+		for _, descriptor := range options.AllowCredentials {
+			if bytes.Equal(descriptor.ID, a.credentialID) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, errors.New("credential is not allowed")
+		}
+	}
+
+	clientDataJSON, err := a.clientDataJSON("webauthn.get", options.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	a.SignCount++
+	// user present, user verified
+	authData := a.authenticatorData(0x01 | 0x04)
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign assertion: %w", err)
+	}
+
+	return &webauthn.AssertionResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		RawID: a.credentialID,
+		Type:  "public-key",
+		Response: webauthn.AuthenticatorAssertionResponseData{
+			ClientDataJSON:    clientDataJSON,
+			AuthenticatorData: authData,
+			Signature:         signature,
+			UserHandle:        a.userHandle,
+		},
+	}, nil
+}
+
+func (a *Authenticator) clientDataJSON(ceremonyType string, challenge []byte) ([]byte, error) {
+	clientDataJSON, err := json.Marshal(map[string]interface{}{
+		"type":      ceremonyType,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    a.Origin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal client data: %w", err)
+	}
+	return clientDataJSON, nil
+}
+
+// authenticatorData returns RP ID hash, flags and sign counter.
+func (a *Authenticator) authenticatorData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.SignCount)
+}
+
+// publicKeyCOSE returns the credential public key in COSE format.
+func (a *Authenticator) publicKeyCOSE() []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+
+	return encodeCBOR([]mapEntry{
+		{key: 1, value: 2},  // key type: EC2
+		{key: 3, value: -7}, // algorithm: ES256
+		{key: -1, value: 1}, // curve: P-256
+		{key: -2, value: x},
+		{key: -3, value: y},
+	})
+}
@@ -0,0 +1,58 @@
+package webauthntest
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// mapEntry is a CBOR map entry. Maps are encoded from ordered entries to keep the output deterministic.
+type mapEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+// encodeCBOR encodes the subset of CBOR types used by authenticators.
+func encodeCBOR(value interface{}) []byte {
+	switch v := value.(type) {
+	case int:
+		return encodeCBORInt(int64(v))
+	case int64:
+		return encodeCBORInt(v)
+	case []byte:
+		return append(encodeCBORHead(2, uint64(len(v))), v...)
+	case string:
+		return append(encodeCBORHead(3, uint64(len(v))), v...)
+	case []mapEntry:
+		out := encodeCBORHead(5, uint64(len(v)))
+		for _, entry := range v {
+			out = append(out, encodeCBOR(entry.key)...)
+			out = append(out, encodeCBOR(entry.value)...)
+		}
+		return out
+	default:
+		panic(fmt.Sprintf("unsupported cbor type %T", value))
+	}
+}
+
+func encodeCBORInt(v int64) []byte {
+	if v < 0 {
+		return encodeCBORHead(1, uint64(-1-v))
+	}
+	return encodeCBORHead(0, uint64(v))
+}
+
+func encodeCBORHead(major byte, argument uint64) []byte {
+	major <<= 5
+	switch {
+	case argument < 24:
+		return []byte{major | byte(argument)}
+	case argument <= 0xff:
+		return []byte{major | 24, byte(argument)}
+	case argument <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major | 25}, uint16(argument))
+	case argument <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major | 26}, uint32(argument))
+	default:
+		return binary.BigEndian.AppendUint64([]byte{major | 27}, argument)
+	}
+}
@@ -8,15 +8,27 @@ curl -X POST $BASE_URL/users/register/ -H "Content-Type: application/json" -d '{
     "name": "John",
     "surname": "Doe",
     "email": "john.doe@example.com",
-    "password": "password123",
+    "password": "Blue-Canyon-42-Ridge",
     "phone": "+1234567890"
 }'
 
 echo -e "\n\n"
 
+# Register User with Weak Password
+echo "Testing User Registration with Weak Password..."
+curl -X POST $BASE_URL/users/register/ -H "Content-Type: application/json" -d '{
+    "name": "Jane",
+    "surname": "Doe",
+    "email": "jane.doe@example.com",
+    "password": "jane",
+    "phone": "+1234567891"
+}'
+
+echo -e "\n\n"
+
 # Login User
 echo "Testing User Login..."
-LOGIN_RESPONSE=$(curl -s -X GET "$BASE_URL/users/login?email=john.doe@example.com&password=password123")
+LOGIN_RESPONSE=$(curl -s -X GET "$BASE_URL/users/login?email=john.doe@example.com&password=Blue-Canyon-42-Ridge")
 echo $LOGIN_RESPONSE
 
 ACCESS_TOKEN=$(echo $LOGIN_RESPONSE | jq -r .accessToken)
@@ -48,6 +60,61 @@ curl -X PUT $BASE_URL/users/ -H "Content-Type: application/json" -H "Authorizati
 
 echo -e "\n\n"
 
+# Reauthenticate
+echo "Testing Reauthentication..."
+curl -X POST $BASE_URL/users/reauthenticate -H "Content-Type: application/json" -H "Authorization: Bearer $ACCESS_TOKEN" -d '{
+    "password": "Blue-Canyon-42-Ridge"
+}'
+
+echo -e "\n\n"
+
+# Request Email Change
+echo "Testing Email Change Request..."
+curl -X POST $BASE_URL/users/me/email -H "Content-Type: application/json" -H "Authorization: Bearer $ACCESS_TOKEN" -d '{
+    "email": "john.new@example.com"
+}'
+
+echo -e "\n\n"
+
+# Change Password
+echo "Testing Password Change..."
+curl -X POST $BASE_URL/users/me/password -H "Content-Type: application/json" -H "Authorization: Bearer $ACCESS_TOKEN" -d '{
+    "currentPassword": "Blue-Canyon-42-Ridge",
+    "newPassword": "Green-Valley-17-Summit"
+}'
+
+echo -e "\n\n"
+
+# Forgot Password
+echo "Testing Forgot Password..."
+curl -X POST $BASE_URL/users/password/forgot -H "Content-Type: application/json" -d '{
+    "email": "john.doe@example.com"
+}'
+
+echo -e "\n\n"
+
+# Send Phone Verification Code
+echo "Testing Send Phone Verification Code..."
+curl -X POST $BASE_URL/users/me/phone/verify/send -H "Authorization: Bearer $ACCESS_TOKEN"
+
+echo -e "\n\n"
+
+# Resend Email Verification
+echo "Testing Email Verification Resend..."
+curl -X POST $BASE_URL/users/email/verify/resend -H "Content-Type: application/json" -d '{
+    "email": "john.doe@example.com"
+}'
+
+echo -e "\n\n"
+
+# Magic Link
+echo "Testing Magic Link..."
+curl -X POST $BASE_URL/users/login/magic-link -H "Content-Type: application/json" -d '{
+    "email": "john.doe@example.com"
+}'
+
+echo -e "\n\n"
+
 # Invalid Token
 echo "Testing with Invalid Token..."
 curl -X GET $BASE_URL/users/$USER_ID -H "Authorization: Bearer InvalidTokenHere"